@@ -1,7 +1,9 @@
 package main
 
 import (
+	"crypto/rand"
 	_ "embed"
+	"encoding/hex"
 	"log"
 	"os"
 	"os/signal"
@@ -9,11 +11,12 @@ import (
 	"syscall"
 
 	qzone "github.com/guohuiyuan/qzone-go"
+	"github.com/guohuiyuan/qzonewall-go/internal/caption"
 	"github.com/guohuiyuan/qzonewall-go/internal/config"
-	"github.com/guohuiyuan/qzonewall-go/internal/render"
 	"github.com/guohuiyuan/qzonewall-go/internal/source"
 	"github.com/guohuiyuan/qzonewall-go/internal/store"
 	"github.com/guohuiyuan/qzonewall-go/internal/task"
+	"github.com/guohuiyuan/qzonewall-go/internal/wall"
 	"github.com/guohuiyuan/qzonewall-go/internal/web"
 	"github.com/spf13/cobra"
 )
@@ -74,6 +77,18 @@ func runApp(cmd *cobra.Command, args []string) {
 	}
 	log.Println("[Main] config loaded")
 
+	// 匿名代号密钥: 首次启动时生成并写回配置，之后保持不变
+	if cfg.Wall.AnonSecret == "" {
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err != nil {
+			log.Fatalf("generate anon secret failed: %v", err)
+		}
+		cfg.Wall.AnonSecret = hex.EncodeToString(buf)
+		if err := cfg.Save(cfgPath); err != nil {
+			log.Printf("[Main] save anon secret failed: %v", err)
+		}
+	}
+
 	st, err := store.New(cfg.Database.Path)
 	if err != nil {
 		log.Fatalf("init sqlite failed: %v", err)
@@ -88,38 +103,51 @@ func runApp(cmd *cobra.Command, args []string) {
 	censorWords := store.LoadCensorWords(cfg.Censor.Words, cfg.Censor.WordsFile)
 	log.Printf("[Main] loaded censor words: %d", len(censorWords))
 
-	renderer := render.NewRenderer()
-	if renderer.Available() {
+	walls, err := wall.NewRegistry(cfg, st)
+	if err != nil {
+		log.Fatalf("init walls failed: %v", err)
+	}
+	if walls.Default().Renderer.Available() {
 		log.Println("[Main] renderer enabled")
 	} else {
 		log.Println("[Main] renderer disabled")
 	}
 
-	qqBot := source.NewQQBot(cfg, st, renderer, nil, censorWords)
-	if err := qqBot.Start(); err != nil {
-		log.Fatalf("start qq bot failed: %v", err)
+	captions, err := caption.New(cfg)
+	if err != nil {
+		log.Fatalf("parse caption templates failed: %v", err)
 	}
-	log.Println("[Main] qq bot started")
 
 	// Start with a valid-form placeholder cookie to avoid blocking startup.
 	// Real cookie bootstrap (GetCookies -> QR fallback) runs asynchronously below.
 	initCookie := "uin=o1;skey=@bootstrap;p_skey=bootstrap"
 
-	qzClient, err := qzone.NewClient(initCookie,
-		qzone.WithTimeout(cfg.Qzone.Timeout.Duration),
-		qzone.WithMaxRetry(cfg.Qzone.MaxRetry),
-		qzone.WithOnSessionExpired(task.RefreshCookie(cfg)),
-	)
-	if err != nil {
-		log.Fatalf("[Main] qzone client create failed: %v", err)
+	// 每面墙一个 QQ 空间客户端，登录态互不影响
+	for _, w := range walls.All() {
+		qzClient, err := qzone.NewClient(initCookie,
+			qzone.WithTimeout(cfg.Qzone.Timeout.Duration),
+			qzone.WithMaxRetry(cfg.Qzone.MaxRetry),
+			qzone.WithOnSessionExpired(task.RefreshCookie(cfg, w)),
+		)
+		if err != nil {
+			log.Fatalf("[Main] qzone client create failed (%s): %v", w.Name, err)
+		}
+
+		if qzClient == nil {
+			log.Fatalf("[Main] qzone client is nil after initialization (%s)", w.Name)
+		}
+		w.Client = qzClient
 	}
+	log.Printf("[Main] qzone clients created: %s", walls.Names())
 
-	if qzClient == nil {
-		log.Fatal("[Main] qzone client is nil after initialization")
+	qqBot := source.NewQQBot(cfg, st, walls, captions, censorWords)
+	if err := qqBot.Start(); err != nil {
+		log.Fatalf("start qq bot failed: %v", err)
 	}
-	log.Println("[Main] qzone client created")
+	log.Println("[Main] qq bot started")
 
 	go func() {
+		qzClient := walls.Default().Client
 		log.Println("[Main] async cookie bootstrap started")
 		res := <-task.TryGetCookieAsync(cfg)
 		if res.Err != nil {
@@ -133,23 +161,34 @@ func runApp(cmd *cobra.Command, args []string) {
 		}
 		log.Printf("[Main] async cookie bootstrap success, uin=%d", qzClient.UIN())
 
-		if err := task.EnsureCookieValidOnStartup(cfg, qzClient); err != nil {
+		if err := task.EnsureCookieValidOnStartup(cfg, walls.Default()); err != nil {
 			log.Printf("[Main] startup cookie validation failed: %v", err)
 		}
 	}()
 
-	qqBot.SetClient(qzClient)
+	// 附加墙使用独立账号，从上次扫码保存的 Cookie 恢复
+	for _, w := range walls.All()[1:] {
+		go func(w *wall.Wall) {
+			if err := task.RestoreWallCookie(cfg, st, w); err != nil {
+				log.Printf("[Main] %s cookie restore failed: %v", w.Name, err)
+				return
+			}
+			log.Printf("[Main] %s cookie restored, uin=%d", w.Name, w.Client.UIN())
+		}(w)
+	}
 
-	worker := task.NewWorker(cfg, qzClient, st, renderer)
-	worker.Start()
-	defer worker.Stop()
+	for _, w := range walls.All() {
+		worker := task.NewWorker(cfg, w, st, captions)
+		worker.Start()
+		defer worker.Stop()
 
-	keepAlive := task.NewKeepAlive(cfg, qzClient)
-	keepAlive.Start()
-	defer keepAlive.Stop()
+		keepAlive := task.NewKeepAlive(cfg, w)
+		keepAlive.Start()
+		defer keepAlive.Stop()
+	}
 
 	if cfg.Web.Enable {
-		webServer := web.NewServer(cfg, cfgPath, st, qzClient, renderer)
+		webServer := web.NewServer(cfg, cfgPath, st, walls, captions)
 		go func() {
 			if err := webServer.Start(); err != nil {
 				log.Printf("[Main] web server stopped: %v", err)
@@ -3,15 +3,19 @@ module github.com/guohuiyuan/qzonewall-go
 go 1.24.0
 
 require (
+	github.com/HugoSmits86/nativewebp v0.9.3
 	github.com/fogleman/gg v1.3.0
 	github.com/glebarez/sqlite v1.11.0
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
 	github.com/guohuiyuan/qzone-go v1.0.0
 	github.com/mdp/qrterminal/v3 v3.2.1
 	github.com/spf13/cobra v1.10.2
+	github.com/tidwall/gjson v1.18.0
 	github.com/tuotoo/qrcode v0.0.0-20220425170535-52ccc2bebf5d
 	github.com/wdvxdr1123/ZeroBot v1.8.3-0.20260211080057-bb01972ba5f9
 	golang.org/x/image v0.36.0
+	golang.org/x/net v0.26.0
+	rsc.io/qr v0.2.0
 )
 
 require (
@@ -31,11 +35,9 @@ require (
 	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
 	github.com/sirupsen/logrus v1.9.4 // indirect
 	github.com/spf13/pflag v1.0.9 // indirect
-	github.com/tidwall/gjson v1.18.0 // indirect
 	github.com/tidwall/match v1.1.1 // indirect
 	github.com/tidwall/pretty v1.2.0 // indirect
 	golang.org/x/exp v0.0.0-20250408133849-7e4ce0ab07d0 // indirect
-	golang.org/x/net v0.26.0 // indirect
 	golang.org/x/sys v0.33.0 // indirect
 	golang.org/x/term v0.21.0 // indirect
 	gorm.io/gorm v1.25.7 // indirect
@@ -43,5 +45,4 @@ require (
 	modernc.org/mathutil v1.7.1 // indirect
 	modernc.org/memory v1.11.0 // indirect
 	modernc.org/sqlite v1.37.1 // indirect
-	rsc.io/qr v0.2.0 // indirect
 )
@@ -0,0 +1,240 @@
+// Package caption 按配置中的 text/template 模板生成说说正文。
+package caption
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/config"
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+)
+
+// PostData 单条稿件在模板中可用的字段
+type PostData struct {
+	ID         int64
+	Author     string // 显示名，匿名稿件为 "匿名"
+	Anon       bool
+	ShowAuthor bool   // 已开启署名且不是匿名稿件
+	Type       string // 类型名，普通稿件为空
+	Item       string // 分类稿件的物品
+	Text       string // 原始正文
+	Body       string // 默认正文: 分类稿件带【类型】物品前缀，投票附选项和参与方式
+	Images     int
+	Created    time.Time
+	Now        time.Time
+	Index      int // 批量发布中的序号，从1开始
+}
+
+// BatchData 批量合并发布的开头/结尾模板可用的字段
+type BatchData struct {
+	Posts []PostData
+	Count int
+	Now   time.Time
+}
+
+// Captions 已解析的说说模板，配置修改后可通过 Reload 热更新
+type Captions struct {
+	cfg *config.Config
+
+	mu     sync.RWMutex
+	loc    *time.Location
+	post   *template.Template
+	header *template.Template
+	item   *template.Template
+	footer *template.Template
+}
+
+// New 解析 cfg.Caption 中的模板，模板语法错误时返回错误
+func New(cfg *config.Config) (*Captions, error) {
+	c := &Captions{cfg: cfg}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload 重新解析配置中的模板，失败时保留原模板
+func (c *Captions) Reload() error {
+	cc := c.cfg.Caption
+	parsed := make([]*template.Template, 4)
+	for i, f := range []struct{ name, text, def string }{
+		{"post", cc.Post, config.DefaultCaption.Post},
+		{"header", cc.Header, config.DefaultCaption.Header},
+		{"item", cc.Item, config.DefaultCaption.Item},
+		{"footer", cc.Footer, config.DefaultCaption.Footer},
+	} {
+		text := f.text
+		if strings.TrimSpace(text) == "" {
+			text = f.def
+		}
+		t, err := template.New(f.name).Funcs(funcMap).Parse(text)
+		if err != nil {
+			return fmt.Errorf("%s 模板: %w", f.name, err)
+		}
+		// 用示例数据试运行，提前发现引用了不存在字段等执行期错误
+		var sample interface{} = samplePost
+		if f.name == "header" || f.name == "footer" {
+			sample = BatchData{Posts: []PostData{samplePost}, Count: 1, Now: samplePost.Now}
+		}
+		if _, err := execute(t, sample); err != nil {
+			return fmt.Errorf("%s 模板: %w", f.name, err)
+		}
+		parsed[i] = t
+	}
+
+	loc := time.Local
+	if tz := c.cfg.Render.Brand.Timezone; tz != "" {
+		if l, err := time.LoadLocation(tz); err == nil {
+			loc = l
+		}
+	}
+
+	c.mu.Lock()
+	c.post, c.header, c.item, c.footer = parsed[0], parsed[1], parsed[2], parsed[3]
+	c.loc = loc
+	c.mu.Unlock()
+	return nil
+}
+
+// samplePost 校验模板时使用的示例稿件
+var samplePost = PostData{ID: 1, Author: "示例", Text: "示例投稿", Body: "示例投稿", Created: time.Unix(0, 0), Now: time.Unix(0, 0), Index: 1}
+
+var funcMap = template.FuncMap{
+	// date 按布局格式化时间，如 {{date .Now "01/02"}}
+	"date": func(t time.Time, layout string) string {
+		return t.Format(layout)
+	},
+	// trunc 截取前 n 个字符，超出时加 "..."
+	"trunc": func(n int, s string) string {
+		r := []rune(s)
+		if len(r) <= n {
+			return s
+		}
+		return string(r[:n]) + "..."
+	},
+}
+
+// Post 单条稿件的说说正文，模板执行失败时记录日志并退回默认正文
+func (c *Captions) Post(post *model.Post) string {
+	out, err := c.ExecPost(post)
+	if err != nil {
+		log.Printf("[Caption] 稿件 #%d 模板执行失败: %v", post.ID, err)
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		return c.body(post)
+	}
+	return out
+}
+
+// ExecPost 执行单条稿件模板，返回执行错误 (用于预览)
+func (c *Captions) ExecPost(post *model.Post) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out, err := execute(c.post, c.postData(post, 1))
+	if err != nil {
+		return "", fmt.Errorf("post 模板: %w", err)
+	}
+	return out, nil
+}
+
+// Batch 批量合并发布的说说正文: 开头 + 每条一行 + 结尾，各部分之间换行
+func (c *Captions) Batch(posts []*model.Post) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	data := BatchData{Count: len(posts), Now: time.Now().In(c.loc)}
+	for i, p := range posts {
+		data.Posts = append(data.Posts, c.postData(p, i+1))
+	}
+
+	var parts []string
+	header, err := execute(c.header, data)
+	if err != nil {
+		return "", fmt.Errorf("header 模板: %w", err)
+	}
+	parts = append(parts, header)
+	for _, d := range data.Posts {
+		line, err := execute(c.item, d)
+		if err != nil {
+			return "", fmt.Errorf("item 模板: %w", err)
+		}
+		parts = append(parts, line)
+	}
+	footer, err := execute(c.footer, data)
+	if err != nil {
+		return "", fmt.Errorf("footer 模板: %w", err)
+	}
+	parts = append(parts, footer)
+
+	var kept []string
+	for _, p := range parts {
+		if p = strings.TrimRight(p, "\n"); p != "" {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, "\n"), nil
+}
+
+func (c *Captions) postData(post *model.Post, index int) PostData {
+	d := PostData{
+		ID:         post.ID,
+		Author:     post.ShowName(),
+		Anon:       post.Anon,
+		ShowAuthor: c.cfg.Wall.ShowAuthor && !post.Anon,
+		Text:       post.Text,
+		Body:       c.body(post),
+		Images:     len(post.Images),
+		Created:    time.Unix(post.CreateTime, 0).In(c.loc),
+		Now:        time.Now().In(c.loc),
+		Index:      index,
+	}
+	if post.Type != model.TypeNormal {
+		d.Type = post.Type.Label()
+	}
+	if post.Fields != nil {
+		d.Item = post.Fields.Item
+	}
+	return d
+}
+
+// body 默认正文
+func (c *Captions) body(post *model.Post) string {
+	if post.Poll != nil {
+		var b strings.Builder
+		fmt.Fprintf(&b, "【投票】%s\n", post.Text)
+		for i, opt := range post.Poll.Options {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, opt)
+		}
+		fmt.Fprintf(&b, "\n在群内发送 /投票 %d 序号 参与投票", post.ID)
+		if url := PollPageURL(c.cfg, post.ID); url != "" {
+			fmt.Fprintf(&b, "\n网页投票: %s", url)
+		}
+		return b.String()
+	}
+	if post.Type.Structured() && post.Fields != nil {
+		// 分类稿件的说说正文带上类型和物品，便于在空间内搜索
+		return strings.TrimSpace(fmt.Sprintf("【%s】%s\n%s", post.Type.Label(), post.Fields.Item, post.Text))
+	}
+	return post.Text
+}
+
+// PollPageURL 由网页投稿地址推出投票页面地址，未配置时返回空
+func PollPageURL(cfg *config.Config, id int64) string {
+	base, ok := strings.CutSuffix(cfg.Render.Brand.SubmitURL, "/submit")
+	if !ok || base == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/poll?id=%d", base, id)
+}
+
+func execute(t *template.Template, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
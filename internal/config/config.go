@@ -44,8 +44,11 @@ type Config struct {
 	Database DatabaseConfig `json:"database"`
 	Web      WebConfig      `json:"web"`
 	Censor   CensorConfig   `json:"censor"`
+	Render   RenderConfig   `json:"render"`
+	Caption  CaptionConfig  `json:"caption"`
 	Worker   WorkerConfig   `json:"worker"`
 	Log      LogConfig      `json:"log"`
+	Walls    []WallEntry    `json:"walls"` // 附加墙，为空时只有默认墙
 }
 
 // QzoneConfig QQ空间账号配置
@@ -57,9 +60,10 @@ type QzoneConfig struct {
 
 // BotConfig QQ机器人配置
 type BotConfig struct {
-	Zero        ZeroBotConfig `json:"zero"`
-	WS          []WSConfig    `json:"ws"`
-	ManageGroup int64         `json:"manage_group"`
+	Zero           ZeroBotConfig `json:"zero"`
+	WS             []WSConfig    `json:"ws"`
+	ManageGroup    int64         `json:"manage_group"`
+	GroupAllowlist bool          `json:"group_allowlist"` // 开启后只有 /本群开启投稿 的群可以投稿，私聊不受影响
 }
 
 // ZeroBotConfig ZeroBot 核心配置
@@ -85,6 +89,22 @@ type WallConfig struct {
 	MaxImages    int      `json:"max_images"`
 	MaxTextLen   int      `json:"max_text_len"`
 	PublishDelay Duration `json:"publish_delay"`
+	BatchDigest  bool     `json:"batch_digest"`  // 批量过稿时合并渲染为合集长图
+	AnonSecret   string   `json:"anon_secret"`   // 匿名代号/头像的派生密钥，留空时启动自动生成
+	AnonPerUIN   bool     `json:"anon_per_uin"`  // 同一 QQ 号的匿名稿件使用固定代号 (可被关联)，默认每条稿件独立生成
+	CWOriginal   bool     `json:"cw_original"`   // 内容警告图片在截图中模糊，另附原图到说说
+	GIFOriginal  bool     `json:"gif_original"`  // 动图在截图中只显示首帧，另附原始 GIF 到说说
+	DraftTimeout Duration `json:"draft_timeout"` // 多条消息投稿的草稿超过该时间未追加则丢弃
+	RelayLimit   int      `json:"relay_limit"`   // 匿名回复: 每个 QQ 每小时可转达次数，<0 表示关闭匿名回复
+}
+
+// WallEntry 附加墙: 一个部署同时运营多面墙时，每面附加墙使用独立的QQ空间账号。
+// 顶层的 qzone / bot.manage_group / render.brand 对应编号为 0 的默认墙。
+type WallEntry struct {
+	ID          int64       `json:"id"`           // 墙编号，>0 且不重复，稿件按编号归属
+	Name        string      `json:"name"`         // 墙名称 (不含空格)，投稿时首行写 "墙: 名称" 选择
+	ManageGroup int64       `json:"manage_group"` // 本墙审核群，0 表示使用 bot.manage_group
+	Brand       BrandConfig `json:"brand"`        // 截图品牌，留空的字段沿用 render.brand，墙名默认为 name
 }
 
 // DatabaseConfig 数据库配置
@@ -94,8 +114,9 @@ type DatabaseConfig struct {
 
 // WebConfig 网页配置
 type WebConfig struct {
-	Enable bool   `json:"enable"`
-	Addr   string `json:"-"`
+	Enable       bool   `json:"enable"`
+	Addr         string `json:"-"`
+	PreviewLimit int    `json:"preview_limit"` // 投稿页截图预览: 每个 IP 每分钟次数，<0 表示关闭
 }
 
 // CensorConfig 敏感词过滤配置
@@ -105,6 +126,52 @@ type CensorConfig struct {
 	WordsFile string   `json:"words_file"`
 }
 
+// RenderConfig 截图渲染配置
+type RenderConfig struct {
+	CacheSize int     `json:"cache_size"` // 内存缓存的渲染结果条数，<0 表示禁用
+	Format    string  `json:"format"`     // 输出格式: jpeg / png / webp
+	Scale     float64 `json:"scale"`      // 渲染倍率 1~3，高 DPI 手机建议 2
+	Quality   int     `json:"quality"`    // JPEG 初始质量 1~100
+	MaxBytes  int     `json:"max_bytes"`  // 单张图片字节上限，超出时自动压缩，0 表示不限制
+	MaxHeight int     `json:"max_height"` // 单页最大高度 (1x 像素)，长文超出后按段落分页，<0 表示不分页
+
+	Brand       BrandConfig       `json:"brand"`
+	LinkPreview LinkPreviewConfig `json:"link_preview"`
+}
+
+// LinkPreviewConfig 正文链接预览卡片配置
+type LinkPreviewConfig struct {
+	Enable   bool     `json:"enable"`
+	Allow    []string `json:"allow"`     // 允许抓取的域名 (含子域名)，不在列表中的链接不生成卡片
+	MaxCards int      `json:"max_cards"` // 单条稿件最多卡片数
+}
+
+// BrandConfig 截图品牌元素配置
+type BrandConfig struct {
+	WallName  string `json:"wall_name"`  // 页眉显示的表白墙名称
+	Logo      string `json:"logo"`       // 页眉 Logo (图片URL或本地路径)
+	Footer    string `json:"footer"`     // 页脚文字
+	SubmitURL string `json:"submit_url"` // 网页投稿地址，如 https://example.com/wall/submit，设置后页脚绘制二维码
+	Watermark string `json:"watermark"`  // 水印前缀文字
+	Timezone  string `json:"timezone"`   // 水印时间所用时区，如 Asia/Shanghai，留空使用本机时区
+}
+
+// CaptionConfig 说说正文模板 (Go text/template 语法)，留空使用默认模板
+type CaptionConfig struct {
+	Post   string `json:"post"`   // 单条稿件的说说正文
+	Header string `json:"header"` // 批量合并发布: 开头
+	Item   string `json:"item"`   // 批量合并发布: 每条稿件一行
+	Footer string `json:"footer"` // 批量合并发布: 结尾
+}
+
+// DefaultCaption 默认说说正文模板
+var DefaultCaption = CaptionConfig{
+	Post:   "{{if .ShowAuthor}}【来自 {{.Author}} 的投稿】\n\n{{end}}{{.Body}}",
+	Header: "【表白墙更新】 {{date .Now \"01/02\"}}\n----------------",
+	Item:   "#{{.ID}}: {{if .Text}}{{trunc 20 .Text}}{{else}}[图片]{{end}}",
+	Footer: "----------------\n详情见图 👇",
+}
+
 // WorkerConfig 任务调度配置
 type WorkerConfig struct {
 	Workers      int      `json:"workers"`
@@ -169,9 +236,48 @@ func (c *Config) setDefaults() {
 	if c.Wall.MaxTextLen == 0 {
 		c.Wall.MaxTextLen = 2000
 	}
+	if c.Wall.DraftTimeout.Duration == 0 {
+		c.Wall.DraftTimeout.Duration = 10 * time.Minute
+	}
+	if c.Wall.RelayLimit == 0 {
+		c.Wall.RelayLimit = 10
+	}
 	if c.Database.Path == "" {
 		c.Database.Path = "data/data.db"
 	}
+	if c.Web.PreviewLimit == 0 {
+		c.Web.PreviewLimit = 10
+	}
+	if c.Render.CacheSize == 0 {
+		c.Render.CacheSize = 64
+	}
+	if c.Render.Format == "" {
+		c.Render.Format = "jpeg"
+	}
+	if c.Render.Scale == 0 {
+		c.Render.Scale = 1
+	}
+	if c.Render.Quality == 0 {
+		c.Render.Quality = 90
+	}
+	if c.Render.MaxHeight == 0 {
+		c.Render.MaxHeight = 2400
+	}
+	if c.Render.LinkPreview.MaxCards == 0 {
+		c.Render.LinkPreview.MaxCards = 3
+	}
+	if c.Caption.Post == "" {
+		c.Caption.Post = DefaultCaption.Post
+	}
+	if c.Caption.Header == "" {
+		c.Caption.Header = DefaultCaption.Header
+	}
+	if c.Caption.Item == "" {
+		c.Caption.Item = DefaultCaption.Item
+	}
+	if c.Caption.Footer == "" {
+		c.Caption.Footer = DefaultCaption.Footer
+	}
 	if c.Worker.Workers == 0 {
 		c.Worker.Workers = 1
 	}
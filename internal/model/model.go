@@ -1,9 +1,14 @@
 package model
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // ──────────────────────────────────────────
@@ -20,24 +25,103 @@ const (
 	StatusPublished PostStatus = "published" // 已发布到QQ空间
 )
 
+var statusLabels = map[PostStatus]string{
+	StatusPending:   "待审核",
+	StatusApproved:  "已通过",
+	StatusRejected:  "已拒绝",
+	StatusFailed:    "发布失败",
+	StatusPublished: "已发布",
+}
+
+// Label 状态的中文名
+func (s PostStatus) Label() string {
+	if l, ok := statusLabels[s]; ok {
+		return l
+	}
+	return string(s)
+}
+
+// ──────────────────────────────────────────
+// PostType 稿件类型
+// ──────────────────────────────────────────
+
+type PostType string
+
+const (
+	TypeNormal PostType = ""       // 普通图文
+	TypeChat   PostType = "chat"   // 聊天记录
+	TypeLost   PostType = "lost"   // 寻物启事
+	TypeFound  PostType = "found"  // 失物招领
+	TypeMarket PostType = "market" // 二手交易
+	TypePoll   PostType = "poll"   // 投票
+)
+
+// PostTypes 全部稿件类型，按展示顺序
+var PostTypes = []PostType{TypeNormal, TypeChat, TypeLost, TypeFound, TypeMarket, TypePoll}
+
+// Label 类型的中文名称
+func (t PostType) Label() string {
+	switch t {
+	case TypeNormal:
+		return "普通"
+	case TypeChat:
+		return "聊天记录"
+	case TypeLost:
+		return "寻物启事"
+	case TypeFound:
+		return "失物招领"
+	case TypeMarket:
+		return "二手交易"
+	case TypePoll:
+		return "投票"
+	}
+	return string(t)
+}
+
+// Structured 是否为带结构化字段 (PostFields) 的分类稿件
+func (t PostType) Structured() bool {
+	return t == TypeLost || t == TypeFound || t == TypeMarket
+}
+
+// ParsePostType 解析类型参数，"normal" 表示普通稿件
+func ParsePostType(s string) (PostType, bool) {
+	if s == "normal" {
+		return TypeNormal, true
+	}
+	for _, t := range PostTypes {
+		if t != TypeNormal && string(t) == s {
+			return t, true
+		}
+	}
+	return TypeNormal, false
+}
+
 // ──────────────────────────────────────────
 // Post 投稿/说说
 // ──────────────────────────────────────────
 
 type Post struct {
-	ID         int64      `json:"id"`
-	TID        string     `json:"tid,omitempty"`      // QQ空间说说ID（发布后回填）
-	UIN        int64      `json:"uin"`                // 投稿者QQ
-	Name       string     `json:"name"`               // 投稿者昵称
-	GroupID    int64      `json:"group_id,omitempty"` // 来源群号
-	Text       string     `json:"text"`               // 文字内容
-	Images     []string   `json:"images,omitempty"`   // 图片URL列表
-	Anon       bool       `json:"anon"`               // 是否匿名
-	Status     PostStatus `json:"status"`
-	Reason     string     `json:"reason,omitempty"`     // 拒绝理由
-	AvatarURL  string     `json:"avatar_url,omitempty"` // 头像URL
-	CreateTime int64      `json:"create_time"`
-	UpdateTime int64      `json:"update_time,omitempty"`
+	ID         int64       `json:"id"`
+	TID        string      `json:"tid,omitempty"`       // QQ空间说说ID（发布后回填）
+	UIN        int64       `json:"uin"`                 // 投稿者QQ
+	Name       string      `json:"name"`                // 投稿者昵称
+	GroupID    int64       `json:"group_id,omitempty"`  // 来源群号
+	Text       string      `json:"text"`                // 文字内容
+	Images     []string    `json:"images,omitempty"`    // 图片URL列表
+	Anon       bool        `json:"anon"`                // 是否匿名
+	Type       PostType    `json:"type,omitempty"`      // 稿件类型
+	Fields     *PostFields `json:"fields,omitempty"`    // 分类稿件的结构化字段
+	Poll       *Poll       `json:"poll,omitempty"`      // 投票稿件的选项与状态，正文为问题
+	CW         string      `json:"cw,omitempty"`        // 内容警告理由，非空时图片模糊处理
+	CWImages   []int       `json:"cw_images,omitempty"` // 需模糊的图片序号 (从1开始)，为空表示全部
+	Status     PostStatus  `json:"status"`
+	Reason     string      `json:"reason,omitempty"`     // 拒绝理由
+	AvatarURL  string      `json:"avatar_url,omitempty"` // 头像URL
+	SourceMsg  string      `json:"source_msg,omitempty"` // 来源消息: 回复投稿的原消息ID或合并转发ID，便于追溯
+	IP         string      `json:"-"`                    // 网页投稿的来源 IP，仅用于封禁，不对外输出
+	WallID     int64       `json:"wall_id,omitempty"`    // 所属墙，0 为默认墙
+	CreateTime int64       `json:"create_time"`
+	UpdateTime int64       `json:"update_time,omitempty"`
 }
 
 // ShowName 显示名称
@@ -48,6 +132,46 @@ func (p *Post) ShowName() string {
 	return p.Name
 }
 
+// IsCWImage 第 i 张图片 (从0开始) 是否需要模糊处理
+func (p *Post) IsCWImage(i int) bool {
+	if p.CW == "" {
+		return false
+	}
+	if len(p.CWImages) == 0 {
+		return true
+	}
+	for _, n := range p.CWImages {
+		if n == i+1 {
+			return true
+		}
+	}
+	return false
+}
+
+// AnonKey 匿名身份种子: HMAC-SHA256(secret, QQ号)，无法反推出 QQ 号。
+// perPost 为 true 或投稿人未知时按稿件派生，同一人的不同稿件互不关联；
+// 否则同一 QQ 号在所有稿件中保持同一身份。非匿名或未配置密钥时返回 nil。
+func (p *Post) AnonKey(secret string, perPost bool) []byte {
+	if !p.Anon || secret == "" {
+		return nil
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	if perPost || p.UIN == 0 {
+		fmt.Fprintf(mac, "post:%d", p.ID)
+	} else {
+		fmt.Fprintf(mac, "uin:%d", p.UIN)
+	}
+	return mac.Sum(nil)
+}
+
+// Pseudonym 由匿名身份种子生成的代号，如 "匿名 #A3F"
+func Pseudonym(key []byte) string {
+	if len(key) < 2 {
+		return "匿名用户"
+	}
+	return fmt.Sprintf("匿名 #%03X", (int(key[0])<<8|int(key[1]))>>4)
+}
+
 // QQAvatarURL 返回QQ头像地址
 func (p *Post) QQAvatarURL() string {
 	if p.AvatarURL != "" {
@@ -64,8 +188,18 @@ func (p *Post) Summary() string {
 	t := time.Unix(p.CreateTime, 0).Format("01-02 15:04")
 	var b strings.Builder
 	fmt.Fprintf(&b, "#%d %s [%s] %s\n", p.ID, p.ShowName(), p.Status, t)
+	if p.Type.Structured() {
+		fmt.Fprintf(&b, "【%s】", p.Type.Label())
+		if p.Fields != nil {
+			b.WriteString(p.Fields.Item)
+		}
+		b.WriteByte('\n')
+	}
 	if p.Text != "" {
 		text := p.Text
+		if p.Poll != nil {
+			text = fmt.Sprintf("[投票] %s (%d 个选项)", text, len(p.Poll.Options))
+		}
 		if len([]rune(text)) > 60 {
 			text = string([]rune(text)[:60]) + "..."
 		}
@@ -78,15 +212,34 @@ func (p *Post) Summary() string {
 	return b.String()
 }
 
+// QzoneURL 已发布说说的访问地址，uin 为表白墙空间的QQ号；未发布时返回空
+func (p *Post) QzoneURL(uin int64) string {
+	if p.TID == "" || uin == 0 {
+		return ""
+	}
+	return fmt.Sprintf("https://user.qzone.qq.com/%d/mood/%s", uin, p.TID)
+}
+
 // String 完整信息
 func (p *Post) String() string {
 	t := time.Unix(p.CreateTime, 0).Format("2006-01-02 15:04")
 	var b strings.Builder
 	fmt.Fprintf(&b, "【#%d】%s 投稿于 %s\n", p.ID, p.ShowName(), t)
+	if p.Type.Structured() && p.Fields != nil {
+		fmt.Fprintf(&b, "[%s]\n", p.Type.Label())
+		for _, f := range p.Fields.Lines(p.Type) {
+			fmt.Fprintf(&b, "%s: %s\n", f.Label, f.Value)
+		}
+	}
 	if p.Text != "" {
 		b.WriteString(p.Text)
 		b.WriteByte('\n')
 	}
+	if p.Poll != nil {
+		for i, opt := range p.Poll.Options {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, opt)
+		}
+	}
 	if len(p.Images) > 0 {
 		for i, img := range p.Images {
 			fmt.Fprintf(&b, "[图片%d] %s\n", i+1, img)
@@ -110,11 +263,690 @@ type Account struct {
 	Username     string `json:"username"`
 	PasswordHash string `json:"-"`
 	Salt         string `json:"-"`
-	Role         string `json:"role"` // "admin" or "user"
+	Role         string `json:"role"`         // 角色，见 RoleOwner 等
+	QQ           int64  `json:"qq,omitempty"` // 绑定的QQ号，机器人命令同样按该账号角色授权
 	CreateTime   int64  `json:"create_time"`
 }
 
-// IsAdmin 是否为管理员
-func (a *Account) IsAdmin() bool {
-	return a.Role == "admin"
+// HasRole 账号角色是否不低于 role
+func (a *Account) HasRole(role string) bool {
+	return RoleLevel(a.Role) >= RoleLevel(role)
+}
+
+// 角色，权限依次递增
+const (
+	RoleUser      = "user"      // 普通用户，仅可投稿
+	RoleViewer    = "viewer"    // 查看待审核列表
+	RoleModerator = "moderator" // 审核: 看稿、过稿、拒稿、打码
+	RoleOwner     = "owner"     // 管理配置、账号与权限
+	roleAdmin     = "admin"     // 旧版管理员，等同 owner
+)
+
+// Roles 可授予的角色，权限从高到低
+var Roles = []string{RoleOwner, RoleModerator, RoleViewer}
+
+var roleLabels = map[string]string{
+	RoleUser:      "普通用户",
+	RoleViewer:    "观察员",
+	RoleModerator: "审核员",
+	RoleOwner:     "所有者",
+}
+
+// RoleLevel 角色的权限等级，未知角色视为普通用户
+func RoleLevel(role string) int {
+	switch role {
+	case RoleOwner, roleAdmin:
+		return 3
+	case RoleModerator:
+		return 2
+	case RoleViewer:
+		return 1
+	}
+	return 0
+}
+
+// RoleLabel 角色的中文名
+func RoleLabel(role string) string {
+	if role == roleAdmin {
+		role = RoleOwner
+	}
+	if l, ok := roleLabels[role]; ok {
+		return l
+	}
+	return role
+}
+
+// ParseRole 解析角色名，支持英文名和中文名；"user"/"取消" 表示收回权限
+func ParseRole(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "取消", "none":
+		return RoleUser, true
+	case roleAdmin:
+		return RoleOwner, true
+	}
+	for role, label := range roleLabels {
+		if s == role || s == label {
+			return role, true
+		}
+	}
+	return "", false
+}
+
+// QQRole 通过机器人 /授权 或网页授予某个QQ号的角色
+type QQRole struct {
+	UIN        int64  `json:"uin"`
+	Role       string `json:"role"`
+	GrantedBy  int64  `json:"granted_by,omitempty"` // 授权人QQ，网页授权时为0
+	CreateTime int64  `json:"create_time"`
+}
+
+// ──────────────────────────────────────────
+// ChatLine 聊天记录
+// ──────────────────────────────────────────
+
+// ChatLine 聊天记录中的一条消息
+type ChatLine struct {
+	Speaker string
+	Text    string
+}
+
+// ParseChatLog 解析 "A: 内容" 格式的聊天记录。
+// 每行一条消息，支持中英文冒号；没有说话人前缀的行并入上一条消息。
+func ParseChatLog(text string) []ChatLine {
+	var lines []ChatLine
+	for _, raw := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		idx := strings.IndexAny(line, ":：")
+		if idx > 0 && len([]rune(line[:idx])) <= 16 {
+			speaker := strings.TrimSpace(line[:idx])
+			_, size := utf8.DecodeRuneInString(line[idx:])
+			content := strings.TrimSpace(line[idx+size:])
+			if speaker != "" {
+				lines = append(lines, ChatLine{Speaker: speaker, Text: content})
+				continue
+			}
+		}
+		if len(lines) > 0 {
+			last := &lines[len(lines)-1]
+			if last.Text == "" {
+				last.Text = line
+			} else {
+				last.Text += "\n" + line
+			}
+		}
+	}
+	return lines
+}
+
+// FormatChatLog 将聊天记录转换为 ParseChatLog 可解析的文本
+func FormatChatLog(lines []ChatLine) string {
+	var b strings.Builder
+	for i, l := range lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "%s: %s", l.Speaker, l.Text)
+	}
+	return b.String()
+}
+
+// ──────────────────────────────────────────
+// 内容警告
+// ──────────────────────────────────────────
+
+// CWDefaultLabel 未填写理由时的内容警告标签
+const CWDefaultLabel = "敏感内容"
+
+// ParseCW 解析正文首行的内容警告标记:
+// "CW: 理由" 模糊全部图片，"CW2,3: 理由" 只模糊第 2、3 张。
+// 没有标记时 cw 为空，rest 为原文。
+func ParseCW(text string) (cw string, images []int, rest string) {
+	first, remain, _ := strings.Cut(text, "\n")
+	line := strings.TrimSpace(first)
+	if len(line) < 2 || !strings.EqualFold(line[:2], "cw") {
+		return "", nil, text
+	}
+	head, label, ok := strings.Cut(line[2:], ":")
+	if !ok {
+		head, label, ok = strings.Cut(line[2:], "：")
+	}
+	if !ok {
+		return "", nil, text
+	}
+	images, err := ParseIndexList(head)
+	if err != nil {
+		return "", nil, text
+	}
+	cw = strings.TrimSpace(label)
+	if cw == "" {
+		cw = CWDefaultLabel
+	}
+	return cw, images, strings.TrimSpace(remain)
+}
+
+// ParseWallLine 解析正文开头的目标墙标记 "墙: 名称" (名称不含空格)，标记后同一行的文字仍属于正文。
+// 没有标记时 name 为空，rest 为原文。
+func ParseWallLine(text string) (name, rest string) {
+	line, ok := strings.CutPrefix(strings.TrimSpace(text), "墙")
+	if !ok {
+		return "", text
+	}
+	line = strings.TrimSpace(line)
+	if line, ok = strings.CutPrefix(line, ":"); !ok {
+		if line, ok = strings.CutPrefix(line, "："); !ok {
+			return "", text
+		}
+	}
+	line = strings.TrimLeft(line, " \t")
+	end := strings.IndexAny(line, " \t\r\n")
+	if end < 0 {
+		end = len(line)
+	}
+	if end == 0 {
+		return "", text
+	}
+	return line[:end], strings.TrimSpace(line[end:])
+}
+
+// ParseIndexList 解析 "2,3" / "2 3" 形式的序号列表，空串返回 nil
+func ParseIndexList(s string) ([]int, error) {
+	var list []int
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '，' || r == ' '
+	}) {
+		n, err := strconv.Atoi(f)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("序号 %s 错误", f)
+		}
+		list = append(list, n)
+	}
+	return list, nil
+}
+
+// ──────────────────────────────────────────
+// 分类稿件字段
+// ──────────────────────────────────────────
+
+// PostFields 寻物/招领/二手交易稿件的结构化字段
+type PostFields struct {
+	Item     string `json:"item,omitempty"`     // 物品
+	Location string `json:"location,omitempty"` // 丢失/拾到/交易地点
+	Time     string `json:"time,omitempty"`     // 丢失/拾到时间
+	Contact  string `json:"contact,omitempty"`  // 联系方式
+	Price    string `json:"price,omitempty"`    // 价格 (二手交易)
+}
+
+// FieldLine 一行展示用的字段
+type FieldLine struct {
+	Label string
+	Value string
+}
+
+// fieldAliases 表单中可识别的字段名
+var fieldAliases = map[string]func(f *PostFields) *string{
+	"物品":   func(f *PostFields) *string { return &f.Item },
+	"物品名称": func(f *PostFields) *string { return &f.Item },
+	"名称":   func(f *PostFields) *string { return &f.Item },
+	"地点":   func(f *PostFields) *string { return &f.Location },
+	"位置":   func(f *PostFields) *string { return &f.Location },
+	"时间":   func(f *PostFields) *string { return &f.Time },
+	"联系":   func(f *PostFields) *string { return &f.Contact },
+	"联系方式": func(f *PostFields) *string { return &f.Contact },
+	"价格":   func(f *PostFields) *string { return &f.Price },
+	"售价":   func(f *PostFields) *string { return &f.Price },
+}
+
+// ParseFields 解析 "物品: 校园卡" 形式的字段行 (中英文冒号均可)，
+// 无法识别的行按原顺序作为补充说明返回
+func ParseFields(text string) (PostFields, string) {
+	var f PostFields
+	var rest []string
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if idx := strings.IndexAny(trimmed, ":："); idx > 0 {
+			if field, ok := fieldAliases[strings.TrimSpace(trimmed[:idx])]; ok {
+				_, size := utf8.DecodeRuneInString(trimmed[idx:])
+				*field(&f) = strings.TrimSpace(trimmed[idx+size:])
+				continue
+			}
+		}
+		rest = append(rest, line)
+	}
+	return f, strings.TrimSpace(strings.Join(rest, "\n"))
+}
+
+// Lines 按类型返回需要展示的字段，空字段跳过 (物品单独作为标题，不包含在内)
+func (f *PostFields) Lines(t PostType) []FieldLine {
+	place, when := "地点", "时间"
+	switch t {
+	case TypeLost:
+		place, when = "丢失地点", "丢失时间"
+	case TypeFound:
+		place, when = "拾到地点", "拾到时间"
+	case TypeMarket:
+		place = "交易地点"
+	}
+	var lines []FieldLine
+	add := func(label, value string) {
+		if value != "" {
+			lines = append(lines, FieldLine{label, value})
+		}
+	}
+	if t == TypeMarket {
+		add("价格", f.Price)
+	}
+	add(place, f.Location)
+	add(when, f.Time)
+	add("联系方式", f.Contact)
+	return lines
+}
+
+// String 字段的纯文本形式，用于敏感词检查等
+func (f *PostFields) String() string {
+	return strings.Join([]string{f.Item, f.Location, f.Time, f.Contact, f.Price}, "\n")
+}
+
+// FieldsForm 分类稿件的填写模板
+func FieldsForm(t PostType) string {
+	var b strings.Builder
+	b.WriteString("物品: \n")
+	if t == TypeMarket {
+		b.WriteString("价格: \n")
+	}
+	b.WriteString("地点: \n")
+	if t != TypeMarket {
+		b.WriteString("时间: \n")
+	}
+	b.WriteString("联系方式: \n")
+	b.WriteString("(其余内容作为补充说明)")
+	return b.String()
+}
+
+// ──────────────────────────────────────────
+// 投票
+// ──────────────────────────────────────────
+
+const (
+	PollMinOptions = 2
+	PollMaxOptions = 10
+)
+
+// Poll 投票稿件的选项与状态
+type Poll struct {
+	Options   []string `json:"options"`
+	CloseTime int64    `json:"close_time,omitempty"` // 截止时间，0 表示由管理员手动结束
+	Closed    bool     `json:"closed,omitempty"`     // 已结束并处理过结果
+	ResultTID string   `json:"result_tid,omitempty"` // 结果说说ID
+}
+
+// Open 投票在 now 时是否仍可投
+func (p *Poll) Open(now time.Time) bool {
+	return !p.Closed && (p.CloseTime == 0 || now.Unix() < p.CloseTime)
+}
+
+// pollOptionPrefix 选项行前的序号，如 "1." "A、" "-"
+var pollOptionPrefix = regexp.MustCompile(`^(?:[-*•]|\d{1,2}[.、)）]|[A-Ja-j][.、)）])\s*`)
+
+// ParsePoll 解析投票投稿: 首行为问题，其后每行一个选项；
+// 可选的 "截止: 24h" 行设置投票时长 (相对 now)。
+func ParsePoll(text string, now time.Time) (question string, poll *Poll, err error) {
+	poll = &Poll{}
+	for _, raw := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		if question == "" {
+			question = line
+			continue
+		}
+		if idx := strings.IndexAny(line, ":："); idx > 0 && strings.TrimSpace(line[:idx]) == "截止" {
+			_, size := utf8.DecodeRuneInString(line[idx:])
+			d, err := time.ParseDuration(strings.TrimSpace(line[idx+size:]))
+			if err != nil || d <= 0 {
+				return "", nil, fmt.Errorf("截止时长格式错误，如 \"截止: 24h\"")
+			}
+			poll.CloseTime = now.Add(d).Unix()
+			continue
+		}
+		if opt := strings.TrimSpace(pollOptionPrefix.ReplaceAllString(line, "")); opt != "" {
+			poll.Options = append(poll.Options, opt)
+		}
+	}
+	if question == "" {
+		return "", nil, fmt.Errorf("缺少投票问题")
+	}
+	if len(poll.Options) < PollMinOptions || len(poll.Options) > PollMaxOptions {
+		return "", nil, fmt.Errorf("选项数量需在 %d~%d 个之间", PollMinOptions, PollMaxOptions)
+	}
+	return question, poll, nil
+}
+
+// ──────────────────────────────────────────
+// 草稿
+// ──────────────────────────────────────────
+
+// Draft 多条消息组成的投稿草稿，按投稿人和会话 (群号，私聊为0) 区分
+type Draft struct {
+	UIN        int64    `json:"uin"`
+	GroupID    int64    `json:"group_id"`
+	Name       string   `json:"name"`
+	Anon       bool     `json:"anon"`
+	Text       string   `json:"text"`
+	Images     []string `json:"images,omitempty"`
+	WallID     int64    `json:"wall_id,omitempty"` // 提交到的墙
+	CreateTime int64    `json:"create_time"`
+	UpdateTime int64    `json:"update_time"` // 最后一次追加的时间，超时从此刻算起
+}
+
+// Append 追加一条消息的文字和图片，文字按段落换行拼接
+func (d *Draft) Append(text string, images []string) {
+	if text = strings.TrimSpace(text); text != "" {
+		if d.Text != "" {
+			d.Text += "\n"
+		}
+		d.Text += text
+	}
+	d.Images = append(d.Images, images...)
+}
+
+// Expired 草稿在 now 时是否已超过 timeout 未更新
+func (d *Draft) Expired(now time.Time, timeout time.Duration) bool {
+	return timeout > 0 && now.Sub(time.Unix(d.UpdateTime, 0)) >= timeout
+}
+
+// Post 将草稿转为待提交的稿件，首行 "CW: 理由" 同单条投稿
+func (d *Draft) Post() *Post {
+	cw, cwImages, text := ParseCW(d.Text)
+	return &Post{
+		UIN:        d.UIN,
+		Name:       d.Name,
+		GroupID:    d.GroupID,
+		Text:       strings.TrimSpace(text),
+		Images:     append([]string(nil), d.Images...),
+		Anon:       d.Anon,
+		CW:         cw,
+		CWImages:   cwImages,
+		WallID:     d.WallID,
+		CreateTime: d.CreateTime,
+	}
+}
+
+// ──────────────────────────────────────────
+// 修订记录
+// ──────────────────────────────────────────
+
+// Revision 待审核稿件的一次修改，保存修改前后的正文
+type Revision struct {
+	ID         int64  `json:"id"`
+	PostID     int64  `json:"post_id"`
+	OldText    string `json:"old_text"`
+	NewText    string `json:"new_text"`
+	Editor     string `json:"editor"` // 修改人: "qq:<QQ号>" 或 "web:<用户名>"
+	CreateTime int64  `json:"create_time"`
+}
+
+// DiffLine 按行比较的一行结果，Op 为 "=" 未变、"-" 删除、"+" 新增
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// DiffLines 按行比较两段文字 (最长公共子序列)
+func DiffLines(oldText, newText string) []DiffLine {
+	a, b := diffSplit(oldText), diffSplit(newText)
+	// lcs[i][j] 为 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []DiffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, DiffLine{"=", a[i]})
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, DiffLine{"-", a[i]})
+			i++
+		default:
+			out = append(out, DiffLine{"+", b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, DiffLine{"-", a[i]})
+	}
+	for ; j < len(b); j++ {
+		out = append(out, DiffLine{"+", b[j]})
+	}
+	return out
+}
+
+// diffSplit 按行拆分，空文本没有行，末尾换行不算作一个空行
+func diffSplit(text string) []string {
+	if text = strings.TrimSuffix(text, "\n"); text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// FormatDiff 将比较结果格式化为文本，删除行以 "- " 开头，新增行以 "+ " 开头，未变的行省略
+func FormatDiff(lines []DiffLine) string {
+	var b strings.Builder
+	for _, l := range lines {
+		if l.Op == "=" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "%s %s", l.Op, l.Text)
+	}
+	return b.String()
+}
+
+// ──────────────────────────────────────────
+// 匿名回复转达
+// ──────────────────────────────────────────
+
+// Relay 一条经机器人转达的匿名回复，双方只能看到会话编号，看不到对方QQ号。
+// 全部转达记录保留，供审核员处理滥用。
+type Relay struct {
+	ID         int64  `json:"id"`
+	PostID     int64  `json:"post_id"`
+	FromUIN    int64  `json:"from_uin"`
+	ToUIN      int64  `json:"to_uin"`
+	Text       string `json:"text"`
+	MsgID      int64  `json:"msg_id"` // 机器人发给接收人的私聊消息ID，用于回复该消息继续对话
+	CreateTime int64  `json:"create_time"`
+}
+
+// Code 会话编号，如 "R12"
+func (r *Relay) Code() string {
+	return "R" + strconv.FormatInt(r.ID, 10)
+}
+
+// ParseRelayCode 解析会话编号 "R12" (不区分大小写)
+func ParseRelayCode(s string) (int64, bool) {
+	if len(s) < 2 || (s[0] != 'R' && s[0] != 'r') {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(s[1:], 10, 64)
+	return id, err == nil && id > 0
+}
+
+// ──────────────────────────────────────────
+// 封禁
+// ──────────────────────────────────────────
+
+// 封禁对象类型
+const (
+	BanQQ    = "qq"
+	BanGroup = "group"
+	BanIP    = "ip"
+)
+
+// Ban 一条封禁: 被封禁的QQ号、群或 IP 无法投稿、使用匿名回复
+type Ban struct {
+	ID         int64  `json:"id"`
+	Kind       string `json:"kind"`
+	Target     string `json:"target,omitempty"`  // QQ号 / 群号 / IP；按稿件拉黑时不对外展示
+	PostID     int64  `json:"post_id,omitempty"` // 按稿件拉黑作者时的稿件编号
+	Reason     string `json:"reason,omitempty"`
+	Operator   string `json:"operator"`    // 操作人: "qq:<QQ号>" 或 "web:<用户名>"
+	ExpireTime int64  `json:"expire_time"` // 到期时间，0 表示永久
+	CreateTime int64  `json:"create_time"`
+}
+
+// Active 封禁在 now 时是否生效
+func (b *Ban) Active(now time.Time) bool {
+	return b.ExpireTime == 0 || b.ExpireTime > now.Unix()
+}
+
+// TargetLabel 封禁对象的展示文字，按稿件拉黑的只显示稿件编号，不暴露作者身份
+func (b *Ban) TargetLabel() string {
+	if b.PostID > 0 {
+		return fmt.Sprintf("稿件 #%d 的作者", b.PostID)
+	}
+	switch b.Kind {
+	case BanGroup:
+		return "群 " + b.Target
+	case BanIP:
+		return "IP " + b.Target
+	}
+	return "QQ " + b.Target
+}
+
+// ExpireLabel 到期时间的展示文字
+func (b *Ban) ExpireLabel() string {
+	if b.ExpireTime == 0 {
+		return "永久"
+	}
+	return time.Unix(b.ExpireTime, 0).Format("2006-01-02 15:04") + " 到期"
+}
+
+// ParseBanDuration 解析封禁时长，支持 Go 时长格式 (如 30m、12h) 及按天 "7d"；
+// "永久" / "0" 返回 0
+func ParseBanDuration(s string) (time.Duration, bool) {
+	switch s {
+	case "永久", "0":
+		return 0, true
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, false
+		}
+		return time.Duration(n) * 24 * time.Hour, true
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, false
+	}
+	return d, true
+}
+
+// ──────────────────────────────────────────
+// 群登记
+// ──────────────────────────────────────────
+
+// 群默认匿名设置
+const (
+	GroupAnonFollow = ""      // 跟随全局 wall.anon_default
+	GroupAnonOn     = "anon"  // /投稿 默认匿名
+	GroupAnonOff    = "named" // /投稿 默认实名
+)
+
+// 群审核结果通知方式
+const (
+	GroupNotifyGroup   = ""        // 在来源群内通知 (默认)
+	GroupNotifyPrivate = "private" // 私聊通知投稿人
+	GroupNotifyOff     = "off"     // 不通知
+)
+
+// GroupSetting 群登记: 是否允许投稿及群级别的投稿设置
+type GroupSetting struct {
+	GroupID    int64  `json:"group_id"`
+	Name       string `json:"name,omitempty"`
+	Enabled    bool   `json:"enabled"`
+	Anon       string `json:"anon"`
+	Notify     string `json:"notify"`
+	WallID     int64  `json:"wall_id"`              // 本群投稿默认进入的墙
+	UpdatedBy  int64  `json:"updated_by,omitempty"` // 最后修改人QQ，网页修改时为0
+	UpdateTime int64  `json:"update_time"`
+}
+
+// AnonDefault 本群 /投稿 是否默认匿名，跟随全局时返回 global
+func (g *GroupSetting) AnonDefault(global bool) bool {
+	switch g.Anon {
+	case GroupAnonOn:
+		return true
+	case GroupAnonOff:
+		return false
+	}
+	return global
+}
+
+// GroupAnonLabel 群默认匿名设置的中文名
+func GroupAnonLabel(anon string) string {
+	switch anon {
+	case GroupAnonOn:
+		return "匿名"
+	case GroupAnonOff:
+		return "实名"
+	}
+	return "跟随全局"
+}
+
+// GroupNotifyLabel 群通知方式的中文名
+func GroupNotifyLabel(notify string) string {
+	switch notify {
+	case GroupNotifyPrivate:
+		return "私聊"
+	case GroupNotifyOff:
+		return "不通知"
+	}
+	return "群内"
+}
+
+// ParseGroupAnon 解析群默认匿名设置，支持中英文
+func ParseGroupAnon(s string) (string, bool) {
+	switch strings.ToLower(s) {
+	case "", "follow", "跟随", "跟随全局", "默认":
+		return GroupAnonFollow, true
+	case GroupAnonOn, "on", "匿名", "开":
+		return GroupAnonOn, true
+	case GroupAnonOff, "off", "实名", "关":
+		return GroupAnonOff, true
+	}
+	return "", false
+}
+
+// ParseGroupNotify 解析群通知方式，支持中英文
+func ParseGroupNotify(s string) (string, bool) {
+	switch strings.ToLower(s) {
+	case "", "group", "群内", "群":
+		return GroupNotifyGroup, true
+	case GroupNotifyPrivate, "私聊":
+		return GroupNotifyPrivate, true
+	case GroupNotifyOff, "不通知", "关":
+		return GroupNotifyOff, true
+	}
+	return "", false
 }
@@ -0,0 +1,152 @@
+package model
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestParseFields 测试分类稿件字段行解析
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want PostFields
+		rest string
+	}{
+		{"空文本", "", PostFields{}, ""},
+		{"英文冒号", "物品: 校园卡\n地点: 图书馆", PostFields{Item: "校园卡", Location: "图书馆"}, ""},
+		{"中文冒号与别名", "物品名称：耳机\n位置：三食堂\n联系方式：微信 abc", PostFields{Item: "耳机", Location: "三食堂", Contact: "微信 abc"}, ""},
+		{"价格", "售价: 20元", PostFields{Price: "20元"}, ""},
+		{"补充说明保留顺序", "早上丢的\n物品: 伞\n黑色，有划痕", PostFields{Item: "伞"}, "早上丢的\n黑色，有划痕"},
+		{"未知字段按正文", "颜色: 红色", PostFields{}, "颜色: 红色"},
+		{"冒号开头不是字段", ": 物品", PostFields{}, ": 物品"},
+		{"空值", "时间:", PostFields{}, ""},
+		{"前后空白", "  联系 ：  QQ 123  ", PostFields{Contact: "QQ 123"}, ""},
+		{"重复字段取后者", "物品: A\n物品: B", PostFields{Item: "B"}, ""},
+	}
+	for _, tt := range tests {
+		got, rest := ParseFields(tt.text)
+		if got != tt.want {
+			t.Errorf("%s: 字段 %+v, 期望 %+v", tt.name, got, tt.want)
+		}
+		if rest != tt.rest {
+			t.Errorf("%s: 补充说明 %q, 期望 %q", tt.name, rest, tt.rest)
+		}
+	}
+}
+
+// TestParsePoll 测试投票投稿解析
+func TestParsePoll(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name     string
+		text     string
+		question string
+		options  []string
+		close    int64
+		wantErr  bool
+	}{
+		{"序号前缀", "吃什么？\n1. 米饭\n2、面条", "吃什么？", []string{"米饭", "面条"}, 0, false},
+		{"字母与符号前缀", "选哪个\nA) 甲\nb. 乙\n- 丙\n• 丁", "选哪个", []string{"甲", "乙", "丙", "丁"}, 0, false},
+		{"空行忽略", "\n问题\n\n甲\n\n乙\n", "问题", []string{"甲", "乙"}, 0, false},
+		{"截止时长", "问题\n甲\n乙\n截止：24h", "问题", []string{"甲", "乙"}, now.Add(24 * time.Hour).Unix(), false},
+		{"截止格式错误", "问题\n甲\n乙\n截止: 明天", "", nil, 0, true},
+		{"截止非正数", "问题\n甲\n乙\n截止: -1h", "", nil, 0, true},
+		{"选项过少", "问题\n甲", "", nil, 0, true},
+		{"只有前缀的选项不计", "问题\n甲\n2.", "", nil, 0, true},
+		{"选项过多", "问题\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11", "", nil, 0, true},
+		{"空文本", "", "", nil, 0, true},
+	}
+	for _, tt := range tests {
+		question, poll, err := ParsePoll(tt.text, now)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: 应返回错误", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: 意外错误 %v", tt.name, err)
+			continue
+		}
+		if question != tt.question || !slices.Equal(poll.Options, tt.options) || poll.CloseTime != tt.close {
+			t.Errorf("%s: 得到 %q %q close=%d, 期望 %q %q close=%d", tt.name, question, poll.Options, poll.CloseTime, tt.question, tt.options, tt.close)
+		}
+	}
+}
+
+// TestDiffLines 测试按行比较及差异文本
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []DiffLine
+		text     string
+	}{
+		{"相同", "a\nb", "a\nb", []DiffLine{{"=", "a"}, {"=", "b"}}, ""},
+		{"修改一行", "a\nb\nc", "a\nx\nc", []DiffLine{{"=", "a"}, {"-", "b"}, {"+", "x"}, {"=", "c"}}, "- b\n+ x"},
+		{"新增行", "a", "a\nb", []DiffLine{{"=", "a"}, {"+", "b"}}, "+ b"},
+		{"删除行", "a\nb", "b", []DiffLine{{"-", "a"}, {"=", "b"}}, "- a"},
+		{"原文为空", "", "a\nb", []DiffLine{{"+", "a"}, {"+", "b"}}, "+ a\n+ b"},
+		{"新文为空", "a", "", []DiffLine{{"-", "a"}}, "- a"},
+		{"都为空", "", "", nil, ""},
+		{"末尾换行不算改动", "a\nb\n", "a\nb", []DiffLine{{"=", "a"}, {"=", "b"}}, ""},
+		{"保留中间空行", "a\n\nb", "a\nb", []DiffLine{{"=", "a"}, {"-", ""}, {"=", "b"}}, "- "},
+	}
+	for _, tt := range tests {
+		got := DiffLines(tt.old, tt.new)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: DiffLines = %v, 期望 %v", tt.name, got, tt.want)
+		}
+		if text := FormatDiff(got); text != tt.text {
+			t.Errorf("%s: FormatDiff = %q, 期望 %q", tt.name, text, tt.text)
+		}
+	}
+}
+
+// TestParseBanDuration 测试封禁时长解析
+func TestParseBanDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"永久", 0, true},
+		{"0", 0, true},
+		{"30m", 30 * time.Minute, true},
+		{"12h", 12 * time.Hour, true},
+		{"7d", 7 * 24 * time.Hour, true},
+		{"0d", 0, false},
+		{"-1d", 0, false},
+		{"xd", 0, false},
+		{"-5m", 0, false},
+		{"理由", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseBanDuration(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseBanDuration(%q) = %v, %v; 期望 %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// TestBanTargetLabel 测试封禁对象展示文字，按稿件拉黑的不显示作者
+func TestBanTargetLabel(t *testing.T) {
+	tests := []struct {
+		ban  Ban
+		want string
+	}{
+		{Ban{Kind: BanQQ, Target: "10001"}, "QQ 10001"},
+		{Ban{Kind: BanGroup, Target: "20002"}, "群 20002"},
+		{Ban{Kind: BanIP, Target: "1.2.3.4"}, "IP 1.2.3.4"},
+		{Ban{Kind: BanQQ, Target: "10001", PostID: 17}, "稿件 #17 的作者"},
+		{Ban{Kind: BanIP, Target: "1.2.3.4", PostID: 18}, "稿件 #18 的作者"},
+	}
+	for _, tt := range tests {
+		if got := tt.ban.TargetLabel(); got != tt.want {
+			t.Errorf("%+v: TargetLabel = %q, 期望 %q", tt.ban, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package render
+
+import (
+	"fmt"
+	"hash/fnv"
+	"image"
+	"log"
+	"math"
+	"time"
+	_ "time/tzdata" // 内置时区数据，保证 Windows 等环境下时区配置可用
+
+	"github.com/fogleman/gg"
+	"github.com/guohuiyuan/qzonewall-go/internal/config"
+	"rsc.io/qr"
+)
+
+// loadLocation 解析水印时区，留空或无效时使用本机时区
+func loadLocation(cfg config.BrandConfig) *time.Location {
+	if cfg.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		log.Printf("[Renderer] 时区 %s 无效，使用本机时区: %v", cfg.Timezone, err)
+		return time.Local
+	}
+	return loc
+}
+
+// brandTag 品牌配置标识，参与缓存键计算
+func (r *Renderer) brandTag() string {
+	h := fnv.New32a()
+	_, _ = fmt.Fprintf(h, "%+v", r.cfg.Brand)
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+func (r *Renderer) brandHeaderH() float64 {
+	b := r.cfg.Brand
+	if b.WallName == "" && b.Logo == "" {
+		return 0
+	}
+	return 80 * r.scale()
+}
+
+func (r *Renderer) brandFooterH() float64 {
+	b := r.cfg.Brand
+	switch {
+	case b.SubmitURL != "":
+		return 150 * r.scale()
+	case b.Footer != "":
+		return 60 * r.scale()
+	}
+	return 0
+}
+
+// maxPageHeight 单页正文的最大高度 (像素)，已扣除品牌页眉页脚，<=0 表示不分页
+func (r *Renderer) maxPageHeight() float64 {
+	if r.cfg.MaxHeight <= 0 {
+		return 0
+	}
+	full := float64(r.cfg.MaxHeight) * r.scale()
+	return math.Max(full-r.brandHeaderH()-r.brandFooterH(), full/2)
+}
+
+// brand 为渲染好的页面加上品牌页眉 (每页) 和页脚 (仅末页)，未配置时原样返回
+func (r *Renderer) brand(body image.Image, index, total int) image.Image {
+	headerH := r.brandHeaderH()
+	footerH := 0.0
+	if index == total-1 {
+		footerH = r.brandFooterH()
+	}
+	if headerH == 0 && footerH == 0 {
+		return body
+	}
+
+	k := r.scale()
+	px := func(v float64) float64 { return v * k }
+	b := r.cfg.Brand
+	w := float64(body.Bounds().Dx())
+	bodyH := float64(body.Bounds().Dy())
+	padding := px(40)
+
+	dc := gg.NewContext(int(w), int(headerH+bodyH+footerH))
+	dc.SetHexColor("#F5F5F5")
+	dc.Clear()
+	dc.DrawImage(body, 0, int(headerH))
+	dc.SetLineWidth(px(1))
+
+	// 页眉: Logo + 墙名
+	if headerH > 0 {
+		x := padding
+		logoSize := px(48)
+		if b.Logo != "" {
+			if logo := r.downloadAndCrop(b.Logo, int(logoSize)); logo != nil {
+				dc.Push()
+				dc.DrawCircle(x+logoSize/2, headerH/2, logoSize/2)
+				dc.Clip()
+				dc.DrawImageAnchored(logo, int(x+logoSize/2), int(headerH/2), 0.5, 0.5)
+				dc.Pop()
+				dc.ResetClip()
+				x += logoSize + px(16)
+			}
+		}
+		if b.WallName != "" {
+			dc.SetFontFace(r.getFace(px(30)))
+			dc.SetHexColor("#333333")
+			dc.DrawStringAnchored(b.WallName, x, headerH/2, 0, 0.35)
+		}
+		dc.SetHexColor("#E0E0E0")
+		dc.DrawLine(padding, headerH-px(1), w-padding, headerH-px(1))
+		dc.Stroke()
+	}
+
+	// 页脚: 文字 + 投稿二维码
+	if footerH > 0 {
+		top := headerH + bodyH
+		dc.SetHexColor("#E0E0E0")
+		dc.DrawLine(padding, top, w-padding, top)
+		dc.Stroke()
+
+		textX, textRight := padding, w-padding
+		if b.SubmitURL != "" {
+			qrSize := footerH - px(30)
+			qrX := w - padding - qrSize
+			if drawQRCode(dc, b.SubmitURL, qrX, top+px(15), qrSize) {
+				textRight = qrX - px(20)
+				dc.SetFontFace(r.getFace(px(20)))
+				dc.SetHexColor("#AAAAAA")
+				dc.DrawStringAnchored("扫码投稿 →", textRight, top+footerH/2+px(28), 1, 0.5)
+			}
+		}
+		if b.Footer != "" {
+			dc.SetFontFace(r.getFace(px(22)))
+			dc.SetHexColor("#888888")
+			lines := WordWrap(dc, b.Footer, textRight-textX)
+			if len(lines) > 2 {
+				lines = lines[:2]
+			}
+			lineH := dc.FontHeight() * 1.4
+			y := top + footerH/2 - lineH*float64(len(lines))/2
+			for i, line := range lines {
+				dc.DrawStringAnchored(line, textX, y+lineH*(float64(i)+0.5), 0, 0.35)
+			}
+		}
+	}
+	return dc.Image()
+}
+
+// drawQRCode 在 (x, y) 绘制边长为 size 的二维码，生成失败返回 false
+func drawQRCode(dc *gg.Context, content string, x, y, size float64) bool {
+	code, err := qr.Encode(content, qr.M)
+	if err != nil {
+		log.Printf("[Renderer] 生成二维码失败: %v", err)
+		return false
+	}
+	dc.SetHexColor("#FFFFFF")
+	dc.DrawRectangle(x, y, size, size)
+	dc.Fill()
+
+	// 四周留 2 格静区
+	cell := size / float64(code.Size+4)
+	dc.SetHexColor("#000000")
+	for row := 0; row < code.Size; row++ {
+		for col := 0; col < code.Size; col++ {
+			if code.Black(col, row) {
+				dc.DrawRectangle(x+float64(col+2)*cell, y+float64(row+2)*cell, math.Ceil(cell), math.Ceil(cell))
+			}
+		}
+	}
+	dc.Fill()
+	return true
+}
@@ -0,0 +1,109 @@
+package render
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+)
+
+// ThemeVersion 渲染主题版本，修改布局/配色后递增，使旧缓存全部失效
+const ThemeVersion = "3"
+
+// Cache 渲染结果内存缓存 (LRU)
+type Cache struct {
+	mu    sync.Mutex
+	max   int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key   string
+	pages [][]byte
+}
+
+// NewCache 创建容量为 max 的缓存，max<=0 时不缓存
+func NewCache(max int) *Cache {
+	return &Cache{
+		max:   max,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Get 读取缓存
+func (c *Cache) Get(key string) ([][]byte, bool) {
+	if c == nil || c.max <= 0 {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		return el.Value.(*cacheEntry).pages, true
+	}
+	return nil, false
+}
+
+// Put 写入缓存，超出容量时淘汰最久未使用的条目
+func (c *Cache) Put(key string, pages [][]byte) {
+	if c == nil || c.max <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		el.Value.(*cacheEntry).pages = pages
+		return
+	}
+	c.items[key] = c.ll.PushFront(&cacheEntry{key: key, pages: pages})
+	for c.ll.Len() > c.max {
+		last := c.ll.Back()
+		c.ll.Remove(last)
+		delete(c.items, last.Value.(*cacheEntry).key)
+	}
+}
+
+// Len 当前缓存条目数
+func (c *Cache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// ContentHash 计算影响渲染结果的稿件字段哈希。
+// 状态/理由/TID 等不影响画面的字段不参与计算，因此审核通过后仍能命中预览时的缓存。
+func ContentHash(post *model.Post) string {
+	// 投票只有选项和截止时间出现在画面上，结束状态与结果说说不参与
+	var pollOptions []string
+	var pollClose int64
+	if post.Poll != nil {
+		pollOptions, pollClose = post.Poll.Options, post.Poll.CloseTime
+	}
+	raw, _ := json.Marshal(struct {
+		ID         int64             `json:"id"`
+		UIN        int64             `json:"uin"`
+		Name       string            `json:"name"`
+		Text       string            `json:"text"`
+		Images     []string          `json:"images"`
+		Anon       bool              `json:"anon"`
+		Type       string            `json:"type"`
+		Fields     *model.PostFields `json:"fields"`
+		Poll       []string          `json:"poll"`
+		PollClose  int64             `json:"poll_close"`
+		CW         string            `json:"cw"`
+		CWImages   []int             `json:"cw_images"`
+		AvatarURL  string            `json:"avatar_url"`
+		CreateTime int64             `json:"create_time"`
+	}{post.ID, post.UIN, post.Name, post.Text, post.Images, post.Anon, string(post.Type), post.Fields, pollOptions, pollClose, post.CW, post.CWImages, post.AvatarURL, post.CreateTime})
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,78 @@
+package render
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/config"
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+)
+
+// TestCacheEvict 测试 LRU 淘汰
+func TestCacheEvict(t *testing.T) {
+	c := NewCache(2)
+	c.Put("a", [][]byte{[]byte("1")})
+	c.Put("b", [][]byte{[]byte("2")})
+	c.Get("a")
+	c.Put("c", [][]byte{[]byte("3")})
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("最久未使用的 b 应被淘汰")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("a 不应被淘汰")
+	}
+	if c.Len() != 2 {
+		t.Fatalf("缓存条数应为 2, 实际 %d", c.Len())
+	}
+}
+
+// TestContentHash 测试内容哈希只受画面相关字段影响
+func TestContentHash(t *testing.T) {
+	p := &model.Post{ID: 1, Text: "hello", Status: model.StatusPending}
+	h := ContentHash(p)
+
+	p.Status = model.StatusApproved
+	p.Reason = "ok"
+	if ContentHash(p) != h {
+		t.Fatal("审核状态变化不应影响内容哈希")
+	}
+
+	p.Text = "hello!"
+	if ContentHash(p) == h {
+		t.Fatal("修改正文后内容哈希应变化")
+	}
+}
+
+// TestRenderPostCachedSkipsFailure 测试图片读取失败时不缓存占位图，下次重新读取
+func TestRenderPostCachedSkipsFailure(t *testing.T) {
+	r := newGoldenRenderer(t, config.RenderConfig{CacheSize: 4})
+	fail := true
+	calls := 0
+	r.SetImageFetcher(ImageFetcherFunc(func(url string) ([]byte, error) {
+		calls++
+		if fail {
+			return nil, fmt.Errorf("网络错误")
+		}
+		return fixtureFetcher(url)
+	}))
+	post := &model.Post{ID: 1, Text: "hello", Images: []string{"fixture://grid1"}}
+
+	if _, cacheable, err := r.RenderPostCacheable(post, nil); err != nil || cacheable {
+		t.Fatalf("图片读取失败时应报告不可缓存, cacheable=%v err=%v", cacheable, err)
+	}
+	if r.cache.Len() != 0 {
+		t.Fatal("图片读取失败时不应缓存渲染结果")
+	}
+
+	fail = false
+	if _, cacheable, err := r.RenderPostCacheable(post, nil); err != nil || !cacheable {
+		t.Fatalf("读取成功后应报告可缓存, cacheable=%v err=%v", cacheable, err)
+	}
+	if calls != 2 || r.cache.Len() != 1 {
+		t.Fatalf("应重新读取图片并缓存, 读取 %d 次, 缓存 %d 条", calls, r.cache.Len())
+	}
+	if _, cacheable, err := r.RenderPostCacheable(post, nil); err != nil || !cacheable || calls != 2 {
+		t.Fatalf("命中缓存时不应再读取图片, 读取 %d 次", calls)
+	}
+}
@@ -0,0 +1,304 @@
+package render
+
+import (
+	"fmt"
+	"hash/fnv"
+	"image"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/fogleman/gg"
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+	"golang.org/x/image/font"
+)
+
+// chatImageRe 聊天记录中引用稿件图片的占位符，如 [图片1]
+var chatImageRe = regexp.MustCompile(`^\[图片(\d+)\]$`)
+
+// chatAvatarColors 字母头像的背景色
+var chatAvatarColors = []string{"#F87171", "#FB923C", "#FACC15", "#4ADE80", "#2DD4BF", "#60A5FA", "#A78BFA", "#F472B6"}
+
+// chatBubble 一条聊天消息的排版结果
+type chatBubble struct {
+	speaker string // 显示名 (匿名时为 A/B/C...)
+	right   bool
+	lines   []string
+	img     image.Image
+	cw      string // 图片的内容警告理由，非空时已模糊处理
+	imgW    float64
+	imgH    float64
+	bubbleW float64
+	bubbleH float64
+	height  float64
+}
+
+// chatLayout 聊天记录排版参数 (已按 scale 换算为像素)
+type chatLayout struct {
+	px func(float64) float64
+
+	CanvasWidth float64
+	Padding     float64
+	AvatarSize  float64
+	AvatarGap   float64
+	BubblePadH  float64
+	BubblePadV  float64
+	NameGap     float64
+	MsgGap      float64
+	HeaderH     float64
+	FooterH     float64
+
+	textFace  font.Face
+	nameFace  font.Face
+	titleFace font.Face
+	lineH     float64
+	nameH     float64
+}
+
+// renderChat 将聊天记录稿件渲染为对话截图。
+// "我" 或第一个说话人显示在右侧；匿名稿件的说话人按出场顺序替换为 A/B/C。
+func (r *Renderer) renderChat(post *model.Post, msgs []model.ChatLine) ([][]byte, error) {
+	// ── 1. 样式配置 ──
+	k := r.scale()
+	px := func(v float64) float64 { return v * k }
+	l := &chatLayout{
+		px:          px,
+		CanvasWidth: px(800),
+		Padding:     px(40),
+		AvatarSize:  px(64),
+		AvatarGap:   px(16),
+		BubblePadH:  px(22),
+		BubblePadV:  px(16),
+		NameGap:     px(6),
+		MsgGap:      px(24),
+		HeaderH:     px(90),
+		FooterH:     px(50),
+		textFace:    r.getFace(px(28)),
+		nameFace:    r.getFace(px(20)),
+		titleFace:   r.getFace(px(28)),
+	}
+	MaxHeight := r.maxPageHeight()
+	maxBubbleW := l.CanvasWidth - l.Padding*2 - (l.AvatarSize+l.AvatarGap)*2
+	maxImg := px(300)
+
+	measureDc := gg.NewContext(1, 1)
+	measureDc.SetFontFace(l.textFace)
+	l.lineH = measureDc.FontHeight() * 1.4
+	measureDc.SetFontFace(l.nameFace)
+	l.nameH = measureDc.FontHeight()
+
+	// ── 2. 说话人 ──
+	names, rightSpeaker := chatSpeakers(msgs, post.Anon)
+
+	// ── 3. 计算每条消息尺寸 ──
+	bubbles := make([]*chatBubble, 0, len(msgs))
+	for _, m := range msgs {
+		b := &chatBubble{speaker: names[m.Speaker], right: m.Speaker == rightSpeaker}
+		if img, idx := r.chatImage(post, m.Text); img != nil {
+			bounds := img.Bounds()
+			scale := math.Min(maxImg/float64(bounds.Dx()), maxImg/float64(bounds.Dy()))
+			if scale > 1 {
+				scale = 1
+			}
+			b.imgW = math.Max(1, float64(bounds.Dx())*scale)
+			b.imgH = math.Max(1, float64(bounds.Dy())*scale)
+			b.img = resizeImage(img, int(b.imgW), int(b.imgH))
+			if post.IsCWImage(idx) {
+				b.img, b.cw = blurImage(b.img), post.CW
+			}
+			b.bubbleW, b.bubbleH = b.imgW, b.imgH
+		} else {
+			measureDc.SetFontFace(l.textFace)
+			b.lines = trimBlankLines(WordWrap(measureDc, m.Text, maxBubbleW-l.BubblePadH*2))
+			if len(b.lines) == 0 {
+				b.lines = []string{""}
+			}
+			widest := 0.0
+			for _, line := range b.lines {
+				if w, _ := measureDc.MeasureString(line); w > widest {
+					widest = w
+				}
+			}
+			b.bubbleW = widest + l.BubblePadH*2
+			b.bubbleH = float64(len(b.lines))*l.lineH + l.BubblePadV*2
+		}
+		b.height = math.Max(l.nameH+l.NameGap+b.bubbleH, l.AvatarSize)
+		bubbles = append(bubbles, b)
+	}
+
+	// ── 4. 分页 ──
+	var pages [][]*chatBubble
+	var cur []*chatBubble
+	curH := 0.0
+	budget := MaxHeight - l.HeaderH - l.FooterH
+	for _, b := range bubbles {
+		if len(cur) > 0 && MaxHeight > 0 && curH+l.MsgGap+b.height > budget {
+			pages = append(pages, cur)
+			cur, curH = nil, 0
+		}
+		if len(cur) > 0 {
+			curH += l.MsgGap
+		}
+		cur = append(cur, b)
+		curH += b.height
+	}
+	pages = append(pages, cur)
+
+	// ── 5. 绘制 ──
+	out := make([][]byte, 0, len(pages))
+	for pi, page := range pages {
+		totalH := l.HeaderH + l.FooterH
+		for i, b := range page {
+			if i > 0 {
+				totalH += l.MsgGap
+			}
+			totalH += b.height
+		}
+
+		dc := gg.NewContext(int(l.CanvasWidth), int(totalH))
+		dc.SetHexColor("#F5F5F5")
+		dc.Clear()
+
+		// 标题
+		dc.SetFontFace(l.titleFace)
+		dc.SetHexColor("#333333")
+		dc.DrawStringAnchored(r.showName(post)+" 分享的聊天记录", l.CanvasWidth/2, l.HeaderH/2, 0.5, 0.5)
+		dc.SetHexColor("#E0E0E0")
+		dc.DrawLine(l.Padding, l.HeaderH-px(12), l.CanvasWidth-l.Padding, l.HeaderH-px(12))
+		dc.SetLineWidth(px(1))
+		dc.Stroke()
+
+		y := l.HeaderH
+		for i, b := range page {
+			if i > 0 {
+				y += l.MsgGap
+			}
+			r.drawBubble(dc, l, b, y)
+			y += b.height
+		}
+
+		r.drawWatermark(dc, post, pi, len(pages))
+
+		data, err := r.encode(r.brand(dc.Image(), pi, len(pages)))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, data)
+	}
+	return out, nil
+}
+
+func (r *Renderer) drawBubble(dc *gg.Context, l *chatLayout, b *chatBubble, y float64) {
+	px := l.px
+
+	// 头像 + 昵称 + 气泡的横向位置
+	avatarX := l.Padding
+	nameX, nameAnchor := l.Padding+l.AvatarSize+l.AvatarGap, 0.0
+	bubbleX := nameX
+	if b.right {
+		avatarX = l.CanvasWidth - l.Padding - l.AvatarSize
+		nameX, nameAnchor = avatarX-l.AvatarGap, 1.0
+		bubbleX = nameX - b.bubbleW
+	}
+
+	drawLetterAvatar(dc, b.speaker, avatarX, y, l.AvatarSize)
+
+	dc.SetFontFace(l.nameFace)
+	dc.SetHexColor("#888888")
+	dc.DrawStringAnchored(b.speaker, nameX, y+l.nameH/2, nameAnchor, 0.5)
+
+	by := y + l.nameH + l.NameGap
+	if b.img != nil {
+		dc.Push()
+		dc.DrawRoundedRectangle(bubbleX, by, b.imgW, b.imgH, px(12))
+		dc.Clip()
+		dc.DrawImage(b.img, int(bubbleX), int(by))
+		if b.cw != "" {
+			r.drawCWLabel(dc, b.cw, bubbleX, by, b.imgW, b.imgH)
+		}
+		dc.Pop()
+		dc.ResetClip()
+		return
+	}
+
+	if b.right {
+		dc.SetHexColor("#12B7F5")
+	} else {
+		dc.SetHexColor("#FFFFFF")
+	}
+	dc.DrawRoundedRectangle(bubbleX, by, b.bubbleW, b.bubbleH, px(16))
+	dc.Fill()
+
+	dc.SetFontFace(l.textFace)
+	if b.right {
+		dc.SetHexColor("#FFFFFF")
+	} else {
+		dc.SetHexColor("#000000")
+	}
+	ascent := float64(l.textFace.Metrics().Ascent.Ceil())
+	for i, line := range b.lines {
+		dc.DrawString(line, bubbleX+l.BubblePadH, by+l.BubblePadV+ascent+float64(i)*l.lineH)
+	}
+}
+
+// chatSpeakers 计算说话人的显示名和显示在右侧的说话人
+func chatSpeakers(msgs []model.ChatLine, anon bool) (map[string]string, string) {
+	names := make(map[string]string)
+	right := ""
+	for _, m := range msgs {
+		if _, ok := names[m.Speaker]; ok {
+			continue
+		}
+		if right == "" {
+			right = m.Speaker
+		}
+		if anon {
+			names[m.Speaker] = anonLabel(len(names))
+		} else {
+			names[m.Speaker] = m.Speaker
+		}
+	}
+	if _, ok := names["我"]; ok {
+		right = "我"
+	}
+	return names, right
+}
+
+// anonLabel 第 i 个匿名说话人的代号: A..Z, 之后 A1, B1...
+func anonLabel(i int) string {
+	label := string(rune('A' + i%26))
+	if i >= 26 {
+		label += strconv.Itoa(i / 26)
+	}
+	return label
+}
+
+// chatImage 若消息为 [图片N] 占位符，返回对应的稿件图片及其下标 (从0开始)
+func (r *Renderer) chatImage(post *model.Post, text string) (image.Image, int) {
+	m := chatImageRe.FindStringSubmatch(strings.TrimSpace(text))
+	if m == nil {
+		return nil, 0
+	}
+	idx, _ := strconv.Atoi(m[1])
+	if idx < 1 || idx > len(post.Images) {
+		return nil, 0
+	}
+	return r.downloadImage(post.Images[idx-1]), idx - 1
+}
+
+// drawLetterAvatar 绘制以名字首字为内容的圆形头像，颜色由名字决定
+func drawLetterAvatar(dc *gg.Context, name string, x, y, size float64) {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	dc.SetHexColor(chatAvatarColors[h.Sum32()%uint32(len(chatAvatarColors))])
+	dc.DrawCircle(x+size/2, y+size/2, size/2)
+	dc.Fill()
+
+	initial := []rune(name)
+	if len(initial) == 0 {
+		return
+	}
+	dc.SetHexColor("#FFFFFF")
+	dc.DrawStringAnchored(fmt.Sprintf("%c", initial[0]), x+size/2, y+size/2, 0.5, 0.35)
+}
@@ -0,0 +1,48 @@
+package render
+
+import (
+	"image"
+
+	"github.com/fogleman/gg"
+	xdraw "golang.org/x/image/draw"
+)
+
+// cwBlurSize 模糊处理时先缩小到的边长，越小越模糊
+const cwBlurSize = 12
+
+// blurImage 重度模糊: 缩小到极小尺寸后再平滑放大回原尺寸
+func blurImage(src image.Image) image.Image {
+	b := src.Bounds()
+	w, h := cwBlurSize, cwBlurSize
+	if b.Dx() > b.Dy() {
+		h = max(1, cwBlurSize*b.Dy()/b.Dx())
+	} else {
+		w = max(1, cwBlurSize*b.Dx()/b.Dy())
+	}
+	small := image.NewRGBA(image.Rect(0, 0, w, h))
+	xdraw.ApproxBiLinear.Scale(small, small.Bounds(), src, b, xdraw.Src, nil)
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	xdraw.BiLinear.Scale(dst, dst.Bounds(), small, small.Bounds(), xdraw.Src, nil)
+	return dst
+}
+
+// drawCWLabel 在已绘制的模糊图片上叠加半透明遮罩和内容警告标签
+func (r *Renderer) drawCWLabel(dc *gg.Context, label string, x, y, w, h float64) {
+	k := r.scale()
+	dc.SetRGBA(0, 0, 0, 0.35)
+	dc.DrawRectangle(x, y, w, h)
+	dc.Fill()
+
+	size := min(26*k, w/6)
+	dc.SetFontFace(r.getFace(size))
+	dc.SetHexColor("#FFFFFF")
+	lines := WordWrap(dc, "⚠ CW: "+label, w-16*k)
+	if len(lines) > 3 {
+		lines = lines[:3]
+	}
+	lineH := dc.FontHeight() * 1.3
+	top := y + h/2 - lineH*float64(len(lines))/2
+	for i, line := range lines {
+		dc.DrawStringAnchored(line, x+w/2, top+lineH*(float64(i)+0.5), 0.5, 0.35)
+	}
+}
@@ -0,0 +1,228 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/fogleman/gg"
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+	"golang.org/x/image/font"
+)
+
+// digestSection 合集中一条稿件的排版结果
+type digestSection struct {
+	post   *model.Post
+	meta   string
+	lines  []string
+	thumbs int
+	rows   int
+	height float64
+}
+
+// RenderDigest 把多条短稿件合并渲染到一张或多张长图上，
+// 每条稿件一个卡片，带编号和投稿时间；超出单页最大高度时分页。
+func (r *Renderer) RenderDigest(posts []*model.Post) ([][]byte, error) {
+	if !r.Available() {
+		return nil, fmt.Errorf("渲染器未初始化(字体缺失)")
+	}
+	if len(posts) == 0 {
+		return nil, fmt.Errorf("合集为空")
+	}
+
+	// ── 1. 样式配置 ──
+	k := r.scale()
+	px := func(v float64) float64 { return v * k }
+	l := &digestLayout{
+		r:           r,
+		px:          px,
+		CanvasWidth: px(800),
+		Padding:     px(40),
+		CardPad:     px(24),
+		ThumbSize:   px(120),
+		ThumbGap:    px(8),
+		textFace:    r.getFace(px(28)),
+		metaFace:    r.getFace(px(20)),
+	}
+	var (
+		CanvasWidth = l.CanvasWidth
+		Padding     = l.Padding
+		CardGap     = px(20)
+		SizeTitle   = px(30)
+		HeaderH     = px(70)
+		FooterH     = px(50)
+		MaxHeight   = r.maxPageHeight()
+	)
+	innerW := CanvasWidth - Padding*2 - l.CardPad*2
+	l.thumbCols = int((innerW + l.ThumbGap) / (l.ThumbSize + l.ThumbGap))
+
+	measureDc := gg.NewContext(1, 1)
+	measureDc.SetFontFace(l.textFace)
+	l.lineH = measureDc.FontHeight() * 1.4
+	measureDc.SetFontFace(l.metaFace)
+	metaH := measureDc.FontHeight()
+
+	// ── 2. 计算每条稿件的卡片高度 ──
+	sections := make([]*digestSection, 0, len(posts))
+	for _, p := range posts {
+		sec := &digestSection{
+			post: p,
+			meta: fmt.Sprintf("#%d  %s  %s", p.ID, r.showName(p), time.Unix(p.CreateTime, 0).In(r.loc).Format("01-02 15:04")),
+		}
+		h := l.CardPad + metaH
+		if p.Text != "" {
+			measureDc.SetFontFace(l.textFace)
+			sec.lines = trimBlankLines(WordWrap(measureDc, p.Text, innerW))
+			h += px(10) + float64(len(sec.lines))*l.lineH
+		}
+		sec.thumbs = len(p.Images)
+		if sec.thumbs > 9 {
+			sec.thumbs = 9
+		}
+		if sec.thumbs > 0 {
+			sec.rows = int(math.Ceil(float64(sec.thumbs) / float64(l.thumbCols)))
+			h += px(10) + float64(sec.rows)*l.ThumbSize + float64(sec.rows-1)*l.ThumbGap
+		}
+		sec.height = h + l.CardPad
+		sections = append(sections, sec)
+	}
+
+	// ── 3. 分页: 按卡片整体装箱，单个卡片超高时独占一页 ──
+	var pages [][]*digestSection
+	var cur []*digestSection
+	curH := 0.0
+	budget := MaxHeight - HeaderH - FooterH
+	for _, sec := range sections {
+		if len(cur) > 0 && MaxHeight > 0 && curH+CardGap+sec.height > budget {
+			pages = append(pages, cur)
+			cur, curH = nil, 0
+		}
+		if len(cur) > 0 {
+			curH += CardGap
+		}
+		cur = append(cur, sec)
+		curH += sec.height
+	}
+	pages = append(pages, cur)
+
+	// ── 4. 绘制 ──
+	out := make([][]byte, 0, len(pages))
+	for pi, page := range pages {
+		totalH := HeaderH + FooterH
+		for i, sec := range page {
+			if i > 0 {
+				totalH += CardGap
+			}
+			totalH += sec.height
+		}
+
+		dc := gg.NewContext(int(CanvasWidth), int(totalH))
+		dc.SetHexColor("#F5F5F5")
+		dc.Clear()
+
+		// 标题
+		dc.SetFontFace(r.getFace(SizeTitle))
+		dc.SetHexColor("#333333")
+		title := fmt.Sprintf("投稿合集 · %d 条", len(posts))
+		dc.DrawStringAnchored(title, Padding, HeaderH/2+px(6), 0, 0.5)
+
+		y := HeaderH
+		for i, sec := range page {
+			if i > 0 {
+				y += CardGap
+			}
+			l.drawSection(dc, sec, Padding, y, CanvasWidth-Padding*2)
+			y += sec.height
+		}
+
+		// 页码
+		if len(pages) > 1 {
+			dc.SetFontFace(l.metaFace)
+			dc.SetHexColor("#AAAAAA")
+			dc.DrawStringAnchored(fmt.Sprintf("%d/%d", pi+1, len(pages)), CanvasWidth-Padding, totalH-FooterH/2, 1, 0.5)
+		}
+
+		data, err := r.encode(r.brand(dc.Image(), pi, len(pages)))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, data)
+	}
+	return out, nil
+}
+
+// digestLayout 合集排版参数 (已按 scale 换算为像素)
+type digestLayout struct {
+	r  *Renderer
+	px func(float64) float64
+
+	CanvasWidth float64
+	Padding     float64
+	CardPad     float64
+	ThumbSize   float64
+	ThumbGap    float64
+
+	textFace  font.Face
+	metaFace  font.Face
+	lineH     float64
+	thumbCols int
+}
+
+func (l *digestLayout) drawSection(dc *gg.Context, sec *digestSection, x, y, w float64) {
+	px := l.px
+	dc.SetHexColor("#FFFFFF")
+	dc.DrawRoundedRectangle(x, y, w, sec.height, px(16))
+	dc.Fill()
+
+	cx := x + l.CardPad
+	cy := y + l.CardPad
+
+	// 编号 + 昵称 + 时间
+	dc.SetFontFace(l.metaFace)
+	dc.SetHexColor("#999999")
+	ascent := float64(l.metaFace.Metrics().Ascent.Ceil())
+	dc.DrawString(sec.meta, cx, cy+ascent)
+	cy += dc.FontHeight()
+
+	// 正文
+	if len(sec.lines) > 0 {
+		cy += px(10)
+		dc.SetFontFace(l.textFace)
+		dc.SetHexColor("#000000")
+		ascent = float64(l.textFace.Metrics().Ascent.Ceil())
+		for i, line := range sec.lines {
+			dc.DrawString(line, cx, cy+ascent+float64(i)*l.lineH)
+		}
+		cy += float64(len(sec.lines)) * l.lineH
+	}
+
+	// 缩略图
+	if sec.thumbs > 0 {
+		cy += px(10)
+		for i := 0; i < sec.thumbs; i++ {
+			ix := cx + float64(i%l.thumbCols)*(l.ThumbSize+l.ThumbGap)
+			iy := cy + float64(i/l.thumbCols)*(l.ThumbSize+l.ThumbGap)
+			img, animated := l.r.loadImage(sec.post.Images[i])
+			if img == nil {
+				drawErrorPlaceholder(dc, ix, iy, l.ThumbSize, l.ThumbSize)
+				continue
+			}
+			img = cropToSquare(img, int(l.ThumbSize))
+			cw := sec.post.IsCWImage(i)
+			if cw {
+				img = blurImage(img)
+			}
+			dc.Push()
+			dc.DrawRoundedRectangle(ix, iy, l.ThumbSize, l.ThumbSize, px(8))
+			dc.Clip()
+			dc.DrawImage(img, int(ix), int(iy))
+			if cw {
+				l.r.drawCWLabel(dc, sec.post.CW, ix, iy, l.ThumbSize, l.ThumbSize)
+			} else if animated {
+				l.r.drawGIFBadge(dc, ix, iy, l.ThumbSize)
+			}
+			dc.Pop()
+			dc.ResetClip()
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"strings"
+
+	"github.com/HugoSmits86/nativewebp"
+)
+
+// 输出格式
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+const (
+	defaultQuality = 90
+	minQuality     = 40  // 自适应压缩的最低 JPEG 质量
+	minShrinkWidth = 400 // 压缩到此宽度仍超限时放弃继续缩小
+)
+
+// scale 渲染倍率，限制在 1~3
+func (r *Renderer) scale() float64 {
+	k := r.cfg.Scale
+	if k < 1 {
+		return 1
+	}
+	if k > 3 {
+		return 3
+	}
+	return k
+}
+
+func (r *Renderer) format() string {
+	switch strings.ToLower(r.cfg.Format) {
+	case FormatPNG:
+		return FormatPNG
+	case FormatWebP:
+		return FormatWebP
+	default:
+		return FormatJPEG
+	}
+}
+
+func (r *Renderer) quality() int {
+	if r.cfg.Quality <= 0 || r.cfg.Quality > 100 {
+		return defaultQuality
+	}
+	return r.cfg.Quality
+}
+
+// outputTag 输出参数标识，参与缓存键计算
+func (r *Renderer) outputTag() string {
+	return fmt.Sprintf("%s@%gx-q%d-b%d-h%d-%s%s", r.format(), r.scale(), r.quality(), r.cfg.MaxBytes, r.cfg.MaxHeight, r.brandTag(), r.linkTag())
+}
+
+// encode 按配置编码图片。
+// 设置了 MaxBytes 时尽量压到上限以内：无损格式超限退回 JPEG，
+// JPEG 逐级降低质量，质量到底后再缩小尺寸；宽度将低于 minShrinkWidth 时
+// 不再缩小，记录日志并返回仍超限的结果，不保证一定不超过上限。
+func (r *Renderer) encode(img image.Image) ([]byte, error) {
+	var data []byte
+	var err error
+	switch r.format() {
+	case FormatPNG:
+		var buf bytes.Buffer
+		err = png.Encode(&buf, img)
+		data = buf.Bytes()
+	case FormatWebP:
+		var buf bytes.Buffer
+		err = nativewebp.Encode(&buf, img, nil)
+		data = buf.Bytes()
+	default:
+		return r.encodeJPEG(img)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encode %s: %w", r.format(), err)
+	}
+	if r.cfg.MaxBytes <= 0 || len(data) <= r.cfg.MaxBytes {
+		return data, nil
+	}
+	log.Printf("[Renderer] %s 输出 %d 字节超出上限 %d，改用 JPEG 压缩", r.format(), len(data), r.cfg.MaxBytes)
+	return r.encodeJPEG(img)
+}
+
+func (r *Renderer) encodeJPEG(img image.Image) ([]byte, error) {
+	q := r.quality()
+	for {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: q}); err != nil {
+			return nil, fmt.Errorf("encode jpeg: %w", err)
+		}
+		if r.cfg.MaxBytes <= 0 || buf.Len() <= r.cfg.MaxBytes {
+			return buf.Bytes(), nil
+		}
+		if q > minQuality {
+			q -= 10
+			if q < minQuality {
+				q = minQuality
+			}
+			continue
+		}
+		b := img.Bounds()
+		w, h := b.Dx()*4/5, b.Dy()*4/5
+		if w < minShrinkWidth {
+			log.Printf("[Renderer] 压缩后仍有 %d 字节，超出上限 %d", buf.Len(), r.cfg.MaxBytes)
+			return buf.Bytes(), nil
+		}
+		img = resizeImage(img, w, h)
+	}
+}
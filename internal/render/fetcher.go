@@ -0,0 +1,160 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ImageFetcher 渲染所用图片的数据来源。
+// 默认从本地上传目录或网络读取，测试时可替换为离线夹具。
+type ImageFetcher interface {
+	Fetch(url string) ([]byte, error)
+}
+
+// ImageFetcherFunc 函数形式的 ImageFetcher
+type ImageFetcherFunc func(url string) ([]byte, error)
+
+func (f ImageFetcherFunc) Fetch(url string) ([]byte, error) {
+	return f(url)
+}
+
+// HTTPFetcher 读取本地上传文件，或通过 HTTP 下载网络图片
+type HTTPFetcher struct {
+	client *http.Client
+}
+
+// NewHTTPFetcher 创建默认图片来源
+func NewHTTPFetcher() *HTTPFetcher {
+	return &HTTPFetcher{client: &http.Client{Timeout: 8 * time.Second}}
+}
+
+func (f *HTTPFetcher) Fetch(url string) ([]byte, error) {
+	if url == "" {
+		return nil, fmt.Errorf("empty url")
+	}
+	if local := resolveLocalUploadPath(url); local != "" {
+		if info, err := os.Stat(local); err == nil && info.Size() > maxImageBytes {
+			return nil, fmt.Errorf("本地图片过大 (%d 字节)", info.Size())
+		}
+		return os.ReadFile(local)
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("http status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageBytes {
+		return nil, fmt.Errorf("图片超过 %d 字节", maxImageBytes)
+	}
+	return data, nil
+}
+
+// SetImageFetcher 替换图片数据来源，传 nil 恢复默认
+func (r *Renderer) SetImageFetcher(f ImageFetcher) {
+	if f == nil {
+		f = NewHTTPFetcher()
+	}
+	r.fetcher = f
+}
+
+// WithImageFetcher 返回使用指定图片来源的渲染器副本，共享字体与缓存，
+// 用于单次渲染 (如预览未保存的上传图片) 而不影响原渲染器
+func (r *Renderer) WithImageFetcher(f ImageFetcher) *Renderer {
+	clone := *r
+	clone.SetImageFetcher(f)
+	return &clone
+}
+
+// Session 返回记住已读取图片数据的渲染器副本 (共享字体与缓存)，
+// 用于同一次发布中先渲染截图、再附原图时不重复下载
+func (r *Renderer) Session() *Renderer {
+	return r.WithImageFetcher(newRecordingFetcher(r.fetcher))
+}
+
+// recordingFetcher 记录一次渲染读取的图片数据及是否有读取失败，
+// 失败时画面中是占位图，结果不能缓存；读取成功的数据可供发布原图时复用
+type recordingFetcher struct {
+	base   ImageFetcher
+	data   map[string][]byte
+	failed bool
+}
+
+func newRecordingFetcher(base ImageFetcher) *recordingFetcher {
+	return &recordingFetcher{base: base, data: make(map[string][]byte)}
+}
+
+func (f *recordingFetcher) Fetch(url string) ([]byte, error) {
+	if data, ok := f.data[url]; ok {
+		return data, nil
+	}
+	data, err := f.base.Fetch(url)
+	if err != nil {
+		f.failed = true
+		return nil, err
+	}
+	f.data[url] = data
+	return data, nil
+}
+
+// fetch 读取图片原始数据，失败时记录日志并返回 nil
+func (r *Renderer) fetch(url string) []byte {
+	if url == "" {
+		return nil
+	}
+	data, err := r.fetcher.Fetch(url)
+	if err != nil {
+		log.Printf("[Renderer] 加载图片失败: %v | %s", err, url)
+		return nil
+	}
+	return data
+}
+
+func resolveLocalUploadPath(raw string) string {
+	// 1. 如果是 http/https 网络链接，直接返回空，交给后续的 http 下载逻辑处理
+	if strings.HasPrefix(raw, "http://") || strings.HasPrefix(raw, "https://") {
+		return ""
+	}
+
+	// 2. 清理路径格式 (处理 Windows/Linux 分隔符差异)
+	path := filepath.Clean(raw)
+
+	// 3. 核心逻辑：直接检查文件是否存在
+	// server.go 传递过来的是绝对路径，os.Stat 能直接找到它
+	info, err := os.Stat(path)
+	if err == nil && !info.IsDir() {
+		// 文件存在且不是文件夹，返回该路径供 os.Open 使用
+		return path
+	}
+
+	// 4. (保底逻辑) 如果传进来的是相对路径，尝试拼接当前运行目录下的 uploads
+	// 这一步通常用不到，因为 server.go 已经转成绝对路径了，但留着防守
+	wd, _ := os.Getwd()
+	absPath := filepath.Join(wd, path)
+	info, err = os.Stat(absPath)
+	if err == nil && !info.IsDir() {
+		return absPath
+	}
+
+	return ""
+}
@@ -0,0 +1,222 @@
+package render
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/freetype/truetype"
+	"github.com/guohuiyuan/qzonewall-go/internal/config"
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// 重新生成 golden 图片: go test ./internal/render/ -run TestGolden -update
+var update = flag.Bool("update", false, "更新 testdata/golden 下的基准图片")
+
+const (
+	goldenChannelTolerance = 16    // 单个颜色通道允许的差值 (0~255)
+	goldenPixelTolerance   = 0.005 // 允许超出通道容差的像素比例
+)
+
+// fixtureFetcher 离线图片夹具: fixture://<名称>，按名称生成确定的图案
+func fixtureFetcher(url string) ([]byte, error) {
+	name, ok := strings.CutPrefix(url, "fixture://")
+	if !ok {
+		return nil, fmt.Errorf("测试中不允许访问外部图片: %s", url)
+	}
+	var w, h int
+	var hue uint8
+	switch {
+	case name == "avatar":
+		w, h, hue = 640, 640, 40
+	case name == "landscape":
+		w, h, hue = 800, 600, 120
+	case strings.HasPrefix(name, "grid"):
+		w, h = 300, 300
+		hue = uint8(name[len(name)-1]-'0') * 20
+	default:
+		return nil, fmt.Errorf("未知夹具: %s", name)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			stripe := uint8(0)
+			if (x/40+y/40)%2 == 0 {
+				stripe = 60
+			}
+			img.Set(x, y, color.RGBA{hue + stripe, uint8(x * 255 / w), uint8(y * 255 / h), 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// newGoldenRenderer 使用 Go 自带字体与离线夹具，使渲染结果与内置字体和网络无关
+func newGoldenRenderer(t *testing.T, cfg config.RenderConfig) *Renderer {
+	t.Helper()
+	cfg.Format = FormatPNG
+	cfg.Brand.Timezone = "UTC"
+	r := NewRenderer(cfg)
+	f, err := truetype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.font = f
+	r.SetImageFetcher(ImageFetcherFunc(fixtureFetcher))
+	r.SetAnonIdentity("golden-secret", false)
+	return r
+}
+
+// TestGolden 对比典型排版与 testdata/golden 下的基准图片
+func TestGolden(t *testing.T) {
+	const created = 1700000000
+	grid := make([]string, 9)
+	for i := range grid {
+		grid[i] = fmt.Sprintf("fixture://grid%d", i)
+	}
+
+	cases := []struct {
+		name   string
+		cfg    config.RenderConfig
+		post   *model.Post
+		counts []int // 非 nil 时渲染投票结果
+	}{
+		{"anonymous", config.RenderConfig{}, &model.Post{
+			ID: 1, UIN: 10001, Anon: true, CreateTime: created,
+			Text: "Anonymous post.\nThe avatar is an identicon derived from the server secret.",
+		}, nil},
+		{"single-image", config.RenderConfig{}, &model.Post{
+			ID: 2, UIN: 10002, Name: "Alice", AvatarURL: "fixture://avatar", CreateTime: created,
+			Text: "One landscape photo below.", Images: []string{"fixture://landscape"},
+		}, nil},
+		{"nine-grid", config.RenderConfig{}, &model.Post{
+			ID: 3, UIN: 10003, Name: "Bob", AvatarURL: "fixture://avatar", CreateTime: created,
+			Text: "Nine images in a grid.", Images: grid,
+		}, nil},
+		{"market", config.RenderConfig{}, &model.Post{
+			ID: 5, UIN: 10005, Name: "Dave", Type: model.TypeMarket, CreateTime: created,
+			Fields: &model.PostFields{Item: "Road bike, 90% new", Price: "350", Location: "East gate", Contact: "QQ 10005"},
+			Text:   "Brakes replaced last month.", Images: []string{"fixture://grid1", "fixture://grid2"},
+		}, nil},
+		{"poll", config.RenderConfig{}, &model.Post{
+			ID: 6, UIN: 10006, Name: "Erin", Type: model.TypePoll, CreateTime: created, Text: "Where should the spring outing go?",
+			Poll: &model.Poll{Options: []string{"Botanical garden", "Lakeside park", "Mountain trail with a picnic at the top"}, CloseTime: created + 86400},
+		}, nil},
+		{"poll-result", config.RenderConfig{}, &model.Post{
+			ID: 6, UIN: 10006, Name: "Erin", Type: model.TypePoll, CreateTime: created, Text: "Where should the spring outing go?",
+			Poll: &model.Poll{Options: []string{"Botanical garden", "Lakeside park", "Mountain trail with a picnic at the top"}, CloseTime: created + 86400},
+		}, []int{3, 9, 0}},
+		{"long-text", config.RenderConfig{MaxHeight: 1000}, &model.Post{
+			ID: 4, UIN: 10004, Name: "Carol", AvatarURL: "fixture://avatar", CreateTime: created,
+			Text: strings.Repeat("A long paragraph that wraps over several lines to exercise pagination. ", 6) +
+				"\n" + strings.Repeat("Another paragraph follows the first one.\n", 12),
+		}, nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newGoldenRenderer(t, tc.cfg)
+			var pages [][]byte
+			var err error
+			if tc.counts != nil {
+				pages, err = r.RenderPollResult(tc.post, tc.counts)
+			} else {
+				pages, err = r.RenderPost(tc.post)
+			}
+			if err != nil {
+				t.Fatalf("❌ 渲染失败: %v", err)
+			}
+			for i, data := range pages {
+				name := tc.name
+				if len(pages) > 1 {
+					name = fmt.Sprintf("%s-p%d", tc.name, i+1)
+				}
+				checkGolden(t, name, data)
+			}
+			if !*update {
+				// 页数变化时多出或缺少的基准图片也视为回归
+				matches, _ := filepath.Glob(filepath.Join("testdata", "golden", tc.name+"-p[0-9]*.png"))
+				if _, err := os.Stat(filepath.Join("testdata", "golden", tc.name+".png")); err == nil {
+					matches = append(matches, tc.name+".png")
+				}
+				if len(matches) != len(pages) {
+					t.Fatalf("❌ 页数与基准不一致: 渲染 %d 页, 基准 %d 张", len(pages), len(matches))
+				}
+			}
+		})
+	}
+}
+
+func checkGolden(t *testing.T, name string, data []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name+".png")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("❌ 读取基准图片失败 (可用 -update 生成): %v", err)
+	}
+	got, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("❌ 渲染结果解码失败: %v", err)
+	}
+	exp, _, err := image.Decode(bytes.NewReader(want))
+	if err != nil {
+		t.Fatalf("❌ 基准图片解码失败: %v", err)
+	}
+
+	if diff := imageDiff(got, exp); diff > goldenPixelTolerance {
+		out := filepath.Join(t.TempDir(), name+".png")
+		_ = os.WriteFile(out, data, 0644)
+		t.Fatalf("❌ %s 与基准差异 %.2f%% 超出容差, 实际结果: %s", name, diff*100, out)
+	}
+}
+
+// imageDiff 返回超出通道容差的像素比例，尺寸不同时返回 1
+func imageDiff(a, b image.Image) float64 {
+	ab, bb := a.Bounds(), b.Bounds()
+	if ab.Dx() != bb.Dx() || ab.Dy() != bb.Dy() {
+		return 1
+	}
+	bad := 0
+	for y := 0; y < ab.Dy(); y++ {
+		for x := 0; x < ab.Dx(); x++ {
+			r1, g1, b1, _ := a.At(ab.Min.X+x, ab.Min.Y+y).RGBA()
+			r2, g2, b2, _ := b.At(bb.Min.X+x, bb.Min.Y+y).RGBA()
+			if channelDiff(r1, r2) > goldenChannelTolerance ||
+				channelDiff(g1, g2) > goldenChannelTolerance ||
+				channelDiff(b1, b2) > goldenChannelTolerance {
+				bad++
+			}
+		}
+	}
+	return float64(bad) / float64(ab.Dx()*ab.Dy())
+}
+
+func channelDiff(a, b uint32) uint32 {
+	a, b = a>>8, b>>8
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -0,0 +1,84 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+)
+
+const identiconGrid = 5
+
+// SetAnonIdentity 设置匿名代号与头像的派生密钥。
+// 密钥为空时匿名稿件不绘制头像，昵称显示为 "匿名用户"。
+func (r *Renderer) SetAnonIdentity(secret string, perPost bool) {
+	r.anonSecret = secret
+	r.anonPerPost = perPost
+}
+
+// anonKey 匿名稿件的身份种子，非匿名或未配置密钥时为 nil
+func (r *Renderer) anonKey(post *model.Post) []byte {
+	return post.AnonKey(r.anonSecret, r.anonPerPost)
+}
+
+// showName 渲染用的显示名: 匿名稿件优先使用代号
+func (r *Renderer) showName(post *model.Post) string {
+	if key := r.anonKey(post); key != nil {
+		return model.Pseudonym(key)
+	}
+	return post.ShowName()
+}
+
+// identicon 根据种子生成 5x5 左右对称的像素头像
+func identicon(key []byte, size int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{0xF0, 0xF0, 0xF0, 0xFF}}, image.Point{}, draw.Src)
+	if len(key) < 3+identiconGrid*3 {
+		return img
+	}
+
+	fg := &image.Uniform{hslColor(float64(key[0])/255*360, 0.55, 0.55)}
+	margin := size / 10
+	cell := (size - margin*2) / identiconGrid
+	offset := (size - cell*identiconGrid) / 2
+
+	half := (identiconGrid + 1) / 2
+	for row := 0; row < identiconGrid; row++ {
+		for col := 0; col < half; col++ {
+			if key[3+row*half+col]&1 == 0 {
+				continue
+			}
+			for _, c := range []int{col, identiconGrid - 1 - col} {
+				x, y := offset+c*cell, offset+row*cell
+				draw.Draw(img, image.Rect(x, y, x+cell, y+cell), fg, image.Point{}, draw.Src)
+			}
+		}
+	}
+	return img
+}
+
+// hslColor HSL 转 RGB，h 取值 0~360，s/l 取值 0~1
+func hslColor(h, s, l float64) color.RGBA {
+	c := (1 - math.Abs(2*l-1)) * s
+	hp := h / 60
+	x := c * (1 - math.Abs(math.Mod(hp, 2)-1))
+	var r, g, b float64
+	switch {
+	case hp < 1:
+		r, g = c, x
+	case hp < 2:
+		r, g = x, c
+	case hp < 3:
+		g, b = c, x
+	case hp < 4:
+		g, b = x, c
+	case hp < 5:
+		r, b = x, c
+	default:
+		r, b = c, x
+	}
+	m := l - c/2
+	return color.RGBA{uint8((r + m) * 255), uint8((g + m) * 255), uint8((b + m) * 255), 0xFF}
+}
@@ -0,0 +1,231 @@
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"log"
+
+	"github.com/fogleman/gg"
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+	xdraw "golang.org/x/image/draw"
+)
+
+const (
+	maxImageBytes   = 30 << 20   // 单张图片下载/读取上限
+	maxDecodePixels = 50_000_000 // 解码像素上限，超出视为加载失败
+	maxWorkingEdge  = 2400       // 解码后长边超过此值先缩小，后续排版最大只用到 500px×3 倍
+)
+
+// loadImage 读取并解码图片: 校验尺寸、按 EXIF 方向摆正，返回图片及是否为动图
+func (r *Renderer) loadImage(url string) (image.Image, bool) {
+	data := r.fetch(url)
+	if data == nil {
+		return nil, false
+	}
+	return decodeImage(data, url), isAnimatedGIF(data)
+}
+
+func decodeImage(data []byte, name string) image.Image {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	if cfg.Width*cfg.Height > maxDecodePixels {
+		log.Printf("[Renderer] 图片尺寸过大 %dx%d，跳过: %s", cfg.Width, cfg.Height, name)
+		return nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+
+	b := img.Bounds()
+	if edge := max(b.Dx(), b.Dy()); edge > maxWorkingEdge {
+		w := max(1, b.Dx()*maxWorkingEdge/edge)
+		h := max(1, b.Dy()*maxWorkingEdge/edge)
+		dst := image.NewRGBA(image.Rect(0, 0, w, h))
+		xdraw.ApproxBiLinear.Scale(dst, dst.Bounds(), img, b, xdraw.Src, nil)
+		img = dst
+	}
+	return applyOrientation(img, exifOrientation(data))
+}
+
+// isAnimatedGIF 判断是否为多帧 GIF: 按块结构遍历，图像描述块出现多次即视为动图。
+// 只跳过数据子块而不解码 LZW 数据，格式错误时返回 false
+func isAnimatedGIF(data []byte) bool {
+	if len(data) < 13 || !bytes.HasPrefix(data, []byte("GIF8")) {
+		return false
+	}
+	i := 13 // 文件头 6 字节 + 逻辑屏幕描述 7 字节
+	if flags := data[10]; flags&0x80 != 0 {
+		i += 3 << (flags&0x07 + 1) // 全局颜色表
+	}
+	frames := 0
+	for i < len(data) {
+		switch data[i] {
+		case 0x21: // 扩展块: 标签后为数据子块
+			i += 2
+		case 0x2C: // 图像描述块
+			if frames++; frames > 1 {
+				return true
+			}
+			if i+10 > len(data) {
+				return false
+			}
+			if flags := data[i+9]; flags&0x80 != 0 {
+				i += 3 << (flags&0x07 + 1) // 局部颜色表
+			}
+			i += 11 // 描述块 10 字节 + LZW 最小码长 1 字节
+		case 0x3B: // 结束
+			return false
+		default:
+			return false
+		}
+		// 跳过数据子块，以长度 0 的块结束
+		for {
+			if i >= len(data) {
+				return false
+			}
+			n := int(data[i])
+			i += 1 + n
+			if n == 0 {
+				break
+			}
+		}
+	}
+	return false
+}
+
+// exifOrientation 读取 JPEG 的 EXIF 方向 (1~8)，没有或解析失败时返回 1
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 { // 图像数据开始 / 结束
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(data[i+2:]))
+		if size < 2 || i+2+size > len(data) {
+			return 1
+		}
+		if marker == 0xE1 {
+			if o := parseExifOrientation(data[i+4 : i+2+size]); o > 0 {
+				return o
+			}
+		}
+		i += 2 + size
+	}
+	return 1
+}
+
+func parseExifOrientation(seg []byte) int {
+	if len(seg) < 14 || string(seg[:6]) != "Exif\x00\x00" {
+		return 0
+	}
+	tiff := seg[6:]
+	var bo binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		bo = binary.LittleEndian
+	case "MM":
+		bo = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(bo.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0
+	}
+	n := int(bo.Uint16(tiff[ifd:]))
+	for k := 0; k < n; k++ {
+		e := ifd + 2 + k*12
+		if e+12 > len(tiff) {
+			return 0
+		}
+		if bo.Uint16(tiff[e:]) == 0x0112 {
+			if v := int(bo.Uint16(tiff[e+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+// applyOrientation 按 EXIF 方向把图片摆正
+func applyOrientation(src image.Image, o int) image.Image {
+	if o <= 1 || o > 8 {
+		return src
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if o >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch o {
+			case 2: // 水平翻转
+				sx, sy = w-1-x, y
+			case 3: // 旋转 180°
+				sx, sy = w-1-x, h-1-y
+			case 4: // 垂直翻转
+				sx, sy = x, h-1-y
+			case 5: // 转置
+				sx, sy = y, x
+			case 6: // 顺时针 90°
+				sx, sy = y, h-1-x
+			case 7: // 反转置
+				sx, sy = w-1-y, h-1-x
+			case 8: // 逆时针 90°
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, src.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
+
+// drawGIFBadge 在图片左下角绘制 "GIF" 角标
+func (r *Renderer) drawGIFBadge(dc *gg.Context, x, y, h float64) {
+	k := r.scale()
+	bw, bh := 44*k, 24*k
+	bx, by := x+8*k, y+h-bh-8*k
+	dc.SetRGBA(0, 0, 0, 0.6)
+	dc.DrawRoundedRectangle(bx, by, bw, bh, 6*k)
+	dc.Fill()
+	dc.SetFontFace(r.getFace(16 * k))
+	dc.SetHexColor("#FFFFFF")
+	dc.DrawStringAnchored("GIF", bx+bw/2, by+bh/2, 0.5, 0.35)
+}
+
+// OriginalImages 返回需要作为说说附加图片的原图数据:
+// cw 为 true 时附带被打码图片的原图，gif 为 true 时附带未打码的动图原图。
+// post 应为已解析图片地址的稿件，读取失败的图片会被跳过。
+// 在 Session 副本上调用时复用渲染截图时已读取的数据。
+func (r *Renderer) OriginalImages(post *model.Post, cw, gif bool) [][]byte {
+	var out [][]byte
+	for i, img := range post.Images {
+		isCW := post.IsCWImage(i)
+		if (isCW && !cw) || (!isCW && !gif) {
+			continue
+		}
+		data := r.fetch(img)
+		if data == nil {
+			continue
+		}
+		if isCW || isAnimatedGIF(data) {
+			out = append(out, data)
+		}
+	}
+	return out
+}
@@ -0,0 +1,125 @@
+package render
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"testing"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/config"
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+)
+
+// TestExifOrientation 测试按 EXIF 方向摆正图片
+func TestExifOrientation(t *testing.T) {
+	// 200x100 的图片，左半边红色
+	src := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			if x < 100 {
+				src.Set(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				src.Set(x, y, color.RGBA{0, 0, 255, 255})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, src, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 在 SOI 之后插入只含 Orientation=6 的 APP1 段
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x06\x00\x00\x00\x00\x00\x00")
+	seg := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(seg)+2))
+	data := append(append(append([]byte{}, buf.Bytes()[:2]...), append(app1, seg...)...), buf.Bytes()[2:]...)
+
+	if o := exifOrientation(data); o != 6 {
+		t.Fatalf("❌ EXIF 方向应为 6, 实际 %d", o)
+	}
+	img := decodeImage(data, "test")
+	if img == nil {
+		t.Fatal("❌ 解码失败")
+	}
+	if b := img.Bounds(); b.Dx() != 100 || b.Dy() != 200 {
+		t.Fatalf("❌ 顺时针旋转后应为 100x200, 实际 %dx%d", b.Dx(), b.Dy())
+	}
+	// 原图左半边红色，顺时针旋转 90° 后位于上半部分
+	if r, _, b, _ := img.At(50, 20).RGBA(); r < b {
+		t.Fatal("❌ 旋转方向错误")
+	}
+}
+
+// TestDecodeGuard 测试超大尺寸图片不解码
+func TestDecodeGuard(t *testing.T) {
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], 20000)
+	binary.BigEndian.PutUint32(ihdr[4:], 20000)
+	ihdr[8], ihdr[9] = 8, 2 // 8bit RGB
+
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(ihdr)))
+	chunk := append([]byte("IHDR"), ihdr...)
+	buf.Write(chunk)
+	_ = binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(chunk))
+
+	if img := decodeImage(buf.Bytes(), "huge.png"); img != nil {
+		t.Fatal("❌ 超过像素上限的图片不应被解码")
+	}
+}
+
+// TestAnimatedGIF 测试动图识别
+func TestAnimatedGIF(t *testing.T) {
+	pal := color.Palette{color.Black, color.White}
+	frame := func() *image.Paletted { return image.NewPaletted(image.Rect(0, 0, 10, 10), pal) }
+
+	var one, two bytes.Buffer
+	_ = gif.EncodeAll(&one, &gif.GIF{Image: []*image.Paletted{frame()}, Delay: []int{10}})
+	_ = gif.EncodeAll(&two, &gif.GIF{Image: []*image.Paletted{frame(), frame()}, Delay: []int{10, 10}})
+
+	if isAnimatedGIF(one.Bytes()) {
+		t.Fatal("❌ 单帧 GIF 不应视为动图")
+	}
+	if !isAnimatedGIF(two.Bytes()) {
+		t.Fatal("❌ 多帧 GIF 应视为动图")
+	}
+
+	// 数据中出现图形控制扩展的字节序列 (此处放在注释扩展里) 不应误判
+	data := one.Bytes()
+	comment := []byte{0x21, 0xFE, 8, 0x21, 0xF9, 0x04, 0, 0x21, 0xF9, 0x04, 0, 0}
+	tricky := append(append(append([]byte{}, data[:len(data)-1]...), comment...), 0x3B)
+	if isAnimatedGIF(tricky) {
+		t.Fatal("❌ 单帧 GIF 数据中的 21 F9 04 不应视为多帧")
+	}
+	if _, err := gif.DecodeAll(bytes.NewReader(tricky)); err != nil {
+		t.Fatalf("❌ 构造的 GIF 无效: %v", err)
+	}
+}
+
+// TestSessionReusesImages 测试同一会话内附原图不重复下载
+func TestSessionReusesImages(t *testing.T) {
+	r := newGoldenRenderer(t, config.RenderConfig{})
+	calls := 0
+	r.SetImageFetcher(ImageFetcherFunc(func(url string) ([]byte, error) {
+		calls++
+		return fixtureFetcher(url)
+	}))
+	post := &model.Post{ID: 1, Text: "cw", CW: "剧透", Images: []string{"fixture://grid1", "fixture://grid2"}}
+
+	s := r.Session()
+	if _, err := s.RenderPost(post); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(s.OriginalImages(post, true, false)); got != 2 {
+		t.Fatalf("❌ 应附 2 张打码原图, 实际 %d 张", got)
+	}
+	if calls != 2 {
+		t.Fatalf("❌ 每张图片应只下载一次, 实际下载 %d 次", calls)
+	}
+}
@@ -0,0 +1,334 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+	"golang.org/x/net/html"
+)
+
+// maxLinkPageBytes 抓取链接页面时最多读取的字节数，OpenGraph 标签都在 <head> 中
+const maxLinkPageBytes = 1 << 20
+
+// LinkMeta 链接页面的 OpenGraph 信息
+type LinkMeta struct {
+	Title string
+	Image string // 预览图地址 (已解析为绝对地址)
+}
+
+// LinkFetcher 链接预览信息来源，默认抓取网页的 OpenGraph 标签，测试时可替换
+type LinkFetcher interface {
+	FetchLink(url string) (*LinkMeta, error)
+}
+
+// OGFetcher 通过 HTTP 抓取页面并解析 OpenGraph 标签，重定向也必须留在白名单域名内
+type OGFetcher struct {
+	client *http.Client
+}
+
+// NewOGFetcher 创建默认链接预览来源，allow 为允许访问的域名
+func NewOGFetcher(allow []string) *OGFetcher {
+	return &OGFetcher{client: &http.Client{
+		Timeout: 5 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("重定向次数过多")
+			}
+			if !hostAllowed(req.URL.Hostname(), allow) {
+				return fmt.Errorf("重定向到未允许的域名: %s", req.URL.Hostname())
+			}
+			return nil
+		},
+	}}
+}
+
+func (f *OGFetcher) FetchLink(rawURL string) (*LinkMeta, error) {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("http status %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" && !strings.Contains(ct, "html") {
+		return nil, fmt.Errorf("不是网页: %s", ct)
+	}
+
+	meta := parseOpenGraph(io.LimitReader(resp.Body, maxLinkPageBytes))
+	if meta.Image != "" {
+		// 预览图可能是相对地址，按最终页面地址解析
+		if ref, err := resp.Request.URL.Parse(meta.Image); err == nil && (ref.Scheme == "http" || ref.Scheme == "https") {
+			meta.Image = ref.String()
+		} else {
+			meta.Image = ""
+		}
+	}
+	return meta, nil
+}
+
+// FetchImage 通过同一客户端下载预览图，地址及每次重定向都必须在白名单域名内
+func (f *OGFetcher) FetchImage(rawURL string) ([]byte, error) {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("http status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageBytes {
+		return nil, fmt.Errorf("图片超过 %d 字节", maxImageBytes)
+	}
+	return data, nil
+}
+
+// linkImageFetcher 可由链接预览来源实现，用受白名单约束的客户端下载预览图。
+// 未实现时不加载预览图，避免经通用图片来源访问任意地址。
+type linkImageFetcher interface {
+	FetchImage(url string) ([]byte, error)
+}
+
+// parseOpenGraph 从 <head> 中读取 og:title / og:image，没有 og:title 时退回 <title>。
+// 仅按 UTF-8 处理，其他编码的页面标题可能乱码。
+func parseOpenGraph(r io.Reader) *LinkMeta {
+	meta := &LinkMeta{}
+	var title string
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if meta.Title == "" {
+				meta.Title = title
+			}
+			return meta
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			switch tok.Data {
+			case "body":
+				if meta.Title == "" {
+					meta.Title = title
+				}
+				return meta
+			case "title":
+				if title == "" && z.Next() == html.TextToken {
+					title = strings.TrimSpace(html.UnescapeString(string(z.Text())))
+				}
+			case "meta":
+				var prop, content string
+				for _, a := range tok.Attr {
+					switch a.Key {
+					case "property", "name":
+						prop = strings.ToLower(a.Val)
+					case "content":
+						content = strings.TrimSpace(a.Val)
+					}
+				}
+				switch {
+				case prop == "og:title" && meta.Title == "":
+					meta.Title = content
+				case (prop == "og:image" || prop == "og:image:url") && meta.Image == "":
+					meta.Image = content
+				}
+			}
+		}
+	}
+}
+
+// SetLinkFetcher 替换链接预览来源，传 nil 恢复默认
+func (r *Renderer) SetLinkFetcher(f LinkFetcher) {
+	if f == nil {
+		f = NewOGFetcher(r.cfg.LinkPreview.Allow)
+	}
+	r.links = f
+}
+
+// linkTag 链接卡片配置标识，参与缓存键计算，未开启时为空
+func (r *Renderer) linkTag() string {
+	cfg := r.cfg.LinkPreview
+	if !cfg.Enable {
+		return ""
+	}
+	h := fnv.New32a()
+	_, _ = fmt.Fprintf(h, "%d|%s", cfg.MaxCards, strings.Join(cfg.Allow, ","))
+	return fmt.Sprintf("-l%08x", h.Sum32())
+}
+
+// linkPattern 匹配正文中的网址，遇到空白或中文标点截止
+var linkPattern = regexp.MustCompile(`https?://[^\s<>"'，。！？；、）」】]+`)
+
+// extractLinks 按出现顺序返回去重后的网址，去掉末尾的英文标点
+func extractLinks(text string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, m := range linkPattern.FindAllString(text, -1) {
+		m = strings.TrimRight(m, ".,;:!?)]}")
+		if !seen[m] {
+			seen[m] = true
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// hostAllowed 判断域名是否在白名单中，白名单项同时匹配其子域名
+func hostAllowed(host string, allow []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" {
+		return false
+	}
+	for _, a := range allow {
+		a = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(a), "."))
+		if a != "" && (host == a || strings.HasSuffix(host, "."+a)) {
+			return true
+		}
+	}
+	return false
+}
+
+// linkCard 一张链接预览卡片
+type linkCard struct {
+	host  string
+	title string
+	thumb image.Image
+}
+
+// linkCards 为正文中白名单域名的链接生成卡片，未开启时返回 nil。
+// 抓取失败的链接仍生成只有域名和网址的卡片。
+func (r *Renderer) linkCards(text string) []linkCard {
+	cfg := r.cfg.LinkPreview
+	if !cfg.Enable || r.links == nil {
+		return nil
+	}
+	var cards []linkCard
+	for _, raw := range extractLinks(text) {
+		if cfg.MaxCards > 0 && len(cards) >= cfg.MaxCards {
+			break
+		}
+		u, err := url.Parse(raw)
+		if err != nil || !hostAllowed(u.Hostname(), cfg.Allow) {
+			continue
+		}
+		card := linkCard{host: u.Hostname(), title: raw}
+		meta, err := r.links.FetchLink(raw)
+		if err != nil {
+			log.Printf("[Renderer] 获取链接预览失败: %v | %s", err, raw)
+		} else {
+			if meta.Title != "" {
+				card.title = meta.Title
+			}
+			if meta.Image != "" {
+				card.thumb = r.linkThumb(u, meta.Image)
+			}
+		}
+		cards = append(cards, card)
+	}
+	return cards
+}
+
+// linkThumb 按页面地址解析预览图地址，仅在白名单域名内下载，否则不显示预览图
+func (r *Renderer) linkThumb(page *url.URL, src string) image.Image {
+	ref, err := page.Parse(src)
+	if err != nil || (ref.Scheme != "http" && ref.Scheme != "https") {
+		return nil
+	}
+	if !hostAllowed(ref.Hostname(), r.cfg.LinkPreview.Allow) {
+		log.Printf("[Renderer] 预览图不在白名单域名内，跳过: %s", ref)
+		return nil
+	}
+	f, ok := r.links.(linkImageFetcher)
+	if !ok {
+		return nil
+	}
+	data, err := f.FetchImage(ref.String())
+	if err != nil {
+		log.Printf("[Renderer] 获取预览图失败: %v | %s", err, ref)
+		return nil
+	}
+	return decodeImage(data, ref.String())
+}
+
+// drawLinkCard 绘制紧凑链接卡片: 左侧预览图，右侧标题 (最多两行) 和域名
+func (r *Renderer) drawLinkCard(dc *gg.Context, c linkCard, x, y, w, h float64) {
+	k := r.scale()
+	radius := 12 * k
+
+	dc.SetHexColor("#FFFFFF")
+	dc.DrawRoundedRectangle(x, y, w, h, radius)
+	dc.Fill()
+	dc.SetLineWidth(1 * k)
+	dc.SetHexColor("#E5E5E5")
+	dc.DrawRoundedRectangle(x, y, w, h, radius)
+	dc.Stroke()
+
+	textX := x + 20*k
+	if c.thumb != nil {
+		dc.Push()
+		dc.DrawRoundedRectangle(x, y, h, h, radius)
+		dc.Clip()
+		dc.DrawImage(cropToSquare(c.thumb, int(h)), int(x), int(y))
+		dc.Pop()
+		dc.ResetClip()
+		textX = x + h + 16*k
+	}
+	textW := x + w - 20*k - textX
+
+	dc.SetFontFace(r.getFace(26 * k))
+	dc.SetHexColor("#222222")
+	lines := WordWrap(dc, c.title, textW)
+	if len(lines) > 2 {
+		lines = lines[:2]
+		lines[1] = ellipsize(dc, lines[1], textW)
+	}
+	lineH := dc.FontHeight() * 1.3
+	for i, line := range lines {
+		dc.DrawStringAnchored(line, textX, y+16*k+lineH*(float64(i)+0.5), 0, 0.35)
+	}
+
+	dc.SetFontFace(r.getFace(20 * k))
+	dc.SetHexColor("#999999")
+	dc.DrawStringAnchored(c.host, textX, y+h-18*k, 0, 0.35)
+}
+
+// ellipsize 截断文字并加省略号，保证不超过 maxW
+func ellipsize(dc *gg.Context, s string, maxW float64) string {
+	runes := []rune(s)
+	for len(runes) > 0 {
+		out := string(runes) + "…"
+		if w, _ := dc.MeasureString(out); w <= maxW {
+			return out
+		}
+		runes = runes[:len(runes)-1]
+	}
+	return "…"
+}
@@ -0,0 +1,134 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/config"
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+)
+
+// TestLinkCards 使用本地桩服务器验证链接卡片: 只抓取白名单域名，卡片画在正文下方
+func TestLinkCards(t *testing.T) {
+	var pageHits atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		pageHits.Add(1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><head><title>fallback</title>
+<meta property="og:title" content="Stub &amp; Page">
+<meta property="og:image" content="/thumb.png">
+</head><body>hello</body></html>`))
+	})
+	mux.HandleFunc("/thumb.png", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := fixtureFetcher("fixture://grid3")
+		_, _ = w.Write(data)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	allowed := srv.URL + "/page"
+	blocked := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1) + "/page"
+	post := &model.Post{ID: 1, Name: "Tester", Text: "look " + allowed + " and " + blocked + "."}
+
+	cfg := config.RenderConfig{Format: FormatPNG, LinkPreview: config.LinkPreviewConfig{Enable: true, Allow: []string{"127.0.0.1"}}}
+	r := NewRenderer(cfg)
+	cards := r.linkCards(post.Text)
+	if len(cards) != 1 {
+		t.Fatalf("❌ 应只为白名单链接生成 1 张卡片, 实际 %d", len(cards))
+	}
+	if cards[0].title != "Stub & Page" || cards[0].thumb == nil {
+		t.Fatalf("❌ OpenGraph 解析错误: title=%q thumb=%v", cards[0].title, cards[0].thumb != nil)
+	}
+	if n := pageHits.Load(); n != 1 {
+		t.Fatalf("❌ 未在白名单的链接不应被抓取, 页面请求 %d 次", n)
+	}
+
+	// 开启卡片后画布变高
+	height := func(r *Renderer) int {
+		pages, err := r.RenderPost(post)
+		if err != nil {
+			t.Fatalf("❌ 渲染失败: %v", err)
+		}
+		img, _, err := image.Decode(bytes.NewReader(pages[0]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return img.Bounds().Dy()
+	}
+	plain := NewRenderer(config.RenderConfig{Format: FormatPNG})
+	if with, without := height(r), height(plain); with <= without {
+		t.Fatalf("❌ 链接卡片未绘制: 开启 %d, 关闭 %d", with, without)
+	}
+
+	// 替换为桩实现时同样受白名单约束
+	var stubCalls int
+	r.SetLinkFetcher(linkFetcherFunc(func(url string) (*LinkMeta, error) {
+		stubCalls++
+		return &LinkMeta{Title: "stub"}, nil
+	}))
+	if cards := r.linkCards(post.Text); len(cards) != 1 || cards[0].title != "stub" || stubCalls != 1 {
+		t.Fatalf("❌ 自定义链接来源未生效: %d 张卡片, 调用 %d 次", len(cards), stubCalls)
+	}
+}
+
+// TestLinkThumbAllowlist 预览图地址及其重定向不在白名单内时不下载，卡片不带预览图
+func TestLinkThumbAllowlist(t *testing.T) {
+	var thumbHits atomic.Int32
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	blockedHost := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+
+	page := func(image string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<meta property="og:title" content="t"><meta property="og:image" content="` + image + `">`))
+		}
+	}
+	mux.HandleFunc("/direct", page(blockedHost+"/thumb.png"))
+	mux.HandleFunc("/redirect", page("/hop"))
+	mux.HandleFunc("/hop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, blockedHost+"/thumb.png", http.StatusFound)
+	})
+	mux.HandleFunc("/thumb.png", func(w http.ResponseWriter, r *http.Request) {
+		thumbHits.Add(1)
+		data, _ := fixtureFetcher("fixture://grid3")
+		_, _ = w.Write(data)
+	})
+
+	cfg := config.RenderConfig{Format: FormatPNG, LinkPreview: config.LinkPreviewConfig{Enable: true, Allow: []string{"127.0.0.1"}}}
+	r := NewRenderer(cfg)
+	for _, path := range []string{"/direct", "/redirect"} {
+		cards := r.linkCards(srv.URL + path)
+		if len(cards) != 1 || cards[0].title != "t" {
+			t.Fatalf("❌ %s: 卡片生成错误 %+v", path, cards)
+		}
+		if cards[0].thumb != nil {
+			t.Fatalf("❌ %s: 白名单外的预览图不应显示", path)
+		}
+	}
+	if n := thumbHits.Load(); n != 0 {
+		t.Fatalf("❌ 白名单外的预览图不应被请求, 实际 %d 次", n)
+	}
+}
+
+type linkFetcherFunc func(url string) (*LinkMeta, error)
+
+func (f linkFetcherFunc) FetchLink(url string) (*LinkMeta, error) { return f(url) }
+
+func TestExtractLinks(t *testing.T) {
+	got := extractLinks("看看 https://a.com/x?y=1。还有(https://b.com/p), 重复 https://a.com/x?y=1")
+	want := []string{"https://a.com/x?y=1", "https://b.com/p"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("❌ 链接提取错误: %v", got)
+	}
+	if !hostAllowed("www.bilibili.com", []string{"bilibili.com"}) || hostAllowed("evilbilibili.com", []string{"bilibili.com"}) {
+		t.Fatal("❌ 域名白名单匹配错误")
+	}
+}
@@ -0,0 +1,181 @@
+package render
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fogleman/gg"
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+)
+
+const (
+	pollColor     = "#8B5CF6"
+	pollBarColor  = "#EDE9FE" // 票数条底色 / 非领先选项
+	pollLoseColor = "#C4B5FD"
+)
+
+// pollLetter 选项序号 A、B、C…
+func pollLetter(i int) string {
+	return string(rune('A' + i))
+}
+
+// renderPoll 渲染投票稿件: 问题 + 选项列表 + 补充图片
+func (r *Renderer) renderPoll(post *model.Post) ([][]byte, error) {
+	l := r.newTypedLayout()
+	return r.renderCard(post, l, pollColor, post.Type.Label(), r.pollBlocks(post, l, nil))
+}
+
+// RenderPollResult 渲染投票结果: 每个选项的票数条、百分比和票数，领先选项高亮。
+// counts 与选项一一对应；结果随票数变化，不写入渲染缓存。
+func (r *Renderer) RenderPollResult(post *model.Post, counts []int) ([][]byte, error) {
+	if !r.Available() {
+		return nil, fmt.Errorf("渲染器未初始化(字体缺失)")
+	}
+	if post.Poll == nil {
+		return nil, fmt.Errorf("稿件 #%d 不是投票", post.ID)
+	}
+	if counts == nil {
+		counts = []int{}
+	}
+	l := r.newTypedLayout()
+	return r.renderCard(post, l, pollColor, "投票结果", r.pollBlocks(post, l, counts))
+}
+
+// pollBlocks 生成投票卡片的排版块，counts 为 nil 时绘制空白选项，否则绘制结果
+func (r *Renderer) pollBlocks(post *model.Post, l *typedLayout, counts []int) []typedBlock {
+	px := l.px
+	var options []string
+	if post.Poll != nil {
+		options = post.Poll.Options
+	}
+	blocks := []typedBlock{l.titleBlock(post.Text)}
+
+	total, best := 0, 0
+	for i := range options {
+		if i < len(counts) {
+			total += counts[i]
+			best = max(best, counts[i])
+		}
+	}
+
+	measureDc := gg.NewContext(1, 1)
+	measureDc.SetFontFace(l.textFace)
+	lineH := measureDc.FontHeight() * 1.4
+	badge := px(44)
+	textX := badge + px(16)
+
+	for i, opt := range options {
+		lines := WordWrap(measureDc, opt, l.innerW-textX-px(24))
+		rowH := max(float64(len(lines))*lineH, badge) + px(24)
+		gap := px(14)
+		if i == 0 {
+			gap = px(24)
+		}
+		letter := pollLetter(i)
+
+		if counts == nil {
+			blocks = append(blocks, typedBlock{
+				gap: gap,
+				h:   rowH,
+				draw: func(dc *gg.Context, x, y float64) {
+					dc.SetLineWidth(px(2))
+					dc.SetHexColor(pollBarColor)
+					dc.DrawRoundedRectangle(x, y, l.innerW, rowH, px(12))
+					dc.Stroke()
+					drawPollBadge(dc, l, letter, pollColor, x+px(12), y+(rowH-badge)/2, badge)
+					dc.SetFontFace(l.textFace)
+					dc.SetHexColor("#222222")
+					top := y + (rowH-float64(len(lines))*lineH)/2
+					for j, line := range lines {
+						dc.DrawStringAnchored(line, x+px(12)+textX, top+lineH*(float64(j)+0.5), 0, 0.35)
+					}
+				},
+			})
+			continue
+		}
+
+		n := 0
+		if i < len(counts) {
+			n = counts[i]
+		}
+		ratio := 0.0
+		if total > 0 {
+			ratio = float64(n) / float64(total)
+		}
+		lead := n > 0 && n == best
+		stat := fmt.Sprintf("%.0f%% · %d 票", ratio*100, n)
+		measureDc.SetFontFace(l.labelFace)
+		statW, _ := measureDc.MeasureString(stat)
+		measureDc.SetFontFace(l.textFace)
+		lines = WordWrap(measureDc, opt, l.innerW-textX-statW-px(36))
+		barH := px(16)
+		rowH = max(float64(len(lines))*lineH, badge) + px(12) + barH
+		blocks = append(blocks, typedBlock{
+			gap: gap,
+			h:   rowH,
+			draw: func(dc *gg.Context, x, y float64) {
+				color, textColor := pollLoseColor, "#555555"
+				if lead {
+					color, textColor = pollColor, "#111111"
+				}
+				headH := rowH - px(12) - barH
+				drawPollBadge(dc, l, letter, color, x, y+(headH-badge)/2, badge)
+				dc.SetFontFace(l.textFace)
+				dc.SetHexColor(textColor)
+				top := y + (headH-float64(len(lines))*lineH)/2
+				for j, line := range lines {
+					dc.DrawStringAnchored(line, x+textX, top+lineH*(float64(j)+0.5), 0, 0.35)
+				}
+				dc.SetFontFace(l.labelFace)
+				dc.DrawStringAnchored(stat, x+l.innerW, y+headH/2, 1, 0.35)
+
+				barY := y + headH + px(12)
+				dc.SetHexColor(pollBarColor)
+				dc.DrawRoundedRectangle(x, barY, l.innerW, barH, barH/2)
+				dc.Fill()
+				if w := l.innerW * ratio; w > 0 {
+					dc.SetHexColor(color)
+					dc.DrawRoundedRectangle(x, barY, max(w, barH), barH, barH/2)
+					dc.Fill()
+				}
+			},
+		})
+	}
+
+	// 底部说明: 总票数 / 截止时间
+	var note string
+	switch {
+	case counts != nil:
+		note = fmt.Sprintf("共 %d 票", total)
+	case post.Poll != nil && post.Poll.CloseTime > 0:
+		note = "截止 " + time.Unix(post.Poll.CloseTime, 0).In(r.loc).Format("01-02 15:04")
+	}
+	if note != "" {
+		measureDc.SetFontFace(l.labelFace)
+		noteH := measureDc.FontHeight() * 1.4
+		blocks = append(blocks, typedBlock{
+			gap: px(20),
+			h:   noteH,
+			draw: func(dc *gg.Context, x, y float64) {
+				dc.SetFontFace(l.labelFace)
+				dc.SetHexColor("#888888")
+				dc.DrawStringAnchored(note, x+l.innerW, y+noteH/2, 1, 0.35)
+			},
+		})
+	}
+
+	if counts == nil {
+		blocks = append(blocks, r.imageBlocks(post, l)...)
+	}
+	return blocks
+}
+
+// drawPollBadge 绘制圆形选项序号
+func drawPollBadge(dc *gg.Context, l *typedLayout, letter, color string, x, y, size float64) {
+	dc.SetHexColor(color)
+	dc.DrawCircle(x+size/2, y+size/2, size/2)
+	dc.Fill()
+	dc.SetHexColor("#FFFFFF")
+	dc.SetFontFace(l.labelFace)
+	dc.DrawStringAnchored(letter, x+size/2, y+size/2, 0.5, 0.35)
+}
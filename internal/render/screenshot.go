@@ -1,23 +1,19 @@
 package render
 
 import (
-	"bytes"
 	_ "embed"
 	"fmt"
 	"image"
 	"image/color"
 	"image/draw" // 标准库
-	"image/jpeg"
 	"log"
 	"math"
-	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/fogleman/gg"
 	"github.com/golang/freetype/truetype"
+	"github.com/guohuiyuan/qzonewall-go/internal/config"
 	"github.com/guohuiyuan/qzonewall-go/internal/model"
 	xdraw "golang.org/x/image/draw" // 扩展库
 	"golang.org/x/image/font"
@@ -28,16 +24,33 @@ import (
 var fontData []byte
 
 type Renderer struct {
-	font *truetype.Font
+	font    *truetype.Font
+	cache   *Cache
+	cfg     config.RenderConfig
+	loc     *time.Location // 水印时间时区
+	fetcher ImageFetcher   // 图片数据来源
+	links   LinkFetcher    // 链接预览信息来源
+
+	anonSecret  string // 匿名代号/头像的派生密钥
+	anonPerPost bool   // 匿名身份按稿件派生，而非按 QQ 号
 }
 
-func NewRenderer() *Renderer {
+func NewRenderer(cfg config.RenderConfig) *Renderer {
+	cache := NewCache(cfg.CacheSize)
+	r := &Renderer{
+		cache:   cache,
+		cfg:     cfg,
+		loc:     loadLocation(cfg.Brand),
+		fetcher: NewHTTPFetcher(),
+		links:   NewOGFetcher(cfg.LinkPreview.Allow),
+	}
 	f, err := truetype.Parse(fontData)
 	if err != nil {
 		log.Printf("[Renderer] ❌ 严重错误: 内置字体解析失败: %v", err)
-		return &Renderer{font: nil}
+		return r
 	}
-	return &Renderer{font: f}
+	r.font = f
+	return r
 }
 
 func (r *Renderer) Available() bool {
@@ -55,105 +68,298 @@ func (r *Renderer) getFace(size float64) font.Face {
 	})
 }
 
-// RenderPost 渲染图文合一
-func (r *Renderer) RenderPost(post *model.Post) ([]byte, error) {
+// CacheKey 渲染缓存键: 稿件ID + 内容哈希 + 主题版本 + 输出参数
+func (r *Renderer) CacheKey(post *model.Post) string {
+	return fmt.Sprintf("%d-%s-v%s-%s", post.ID, ContentHash(post)[:16], ThemeVersion, r.outputTag())
+}
+
+// RenderPostCached 优先返回缓存的渲染结果，未命中时渲染并写入缓存。
+// 缓存键基于数据库中的原始稿件计算，resolve 仅在未命中时调用，
+// 用于把图片 fileID 解析为可下载地址，可为 nil。
+// 有图片读取失败时画面中是占位图，不写入缓存，下次 (如过稿发布时) 重新读取。
+func (r *Renderer) RenderPostCached(post *model.Post, resolve func(*model.Post) *model.Post) ([][]byte, error) {
+	pages, _, err := r.RenderPostCacheable(post, resolve)
+	return pages, err
+}
+
+// RenderPostCacheable 同 RenderPostCached，另外返回结果是否可缓存 (命中或已写入缓存)。
+// 不可缓存的结果含占位图，调用方不应让客户端按缓存键长期缓存。
+func (r *Renderer) RenderPostCacheable(post *model.Post, resolve func(*model.Post) *model.Post) ([][]byte, bool, error) {
+	key := r.CacheKey(post)
+	if pages, ok := r.cache.Get(key); ok {
+		return pages, true, nil
+	}
+	renderPost := post
+	if resolve != nil {
+		renderPost = resolve(post)
+	}
+	rec := newRecordingFetcher(r.fetcher)
+	pages, err := r.WithImageFetcher(rec).RenderPost(renderPost)
+	if err != nil {
+		return nil, false, err
+	}
+	if rec.failed {
+		log.Printf("[Renderer] 稿件 #%d 有图片读取失败，渲染结果不缓存", post.ID)
+		return pages, false, nil
+	}
+	r.cache.Put(key, pages)
+	return pages, true, nil
+}
+
+// postLayout 一条稿件的排版参数 (已按 scale 换算为像素)
+type postLayout struct {
+	px func(float64) float64
+
+	CanvasWidth float64
+	Padding     float64
+	SizeText    float64
+	SizeName    float64
+	SizeMeta    float64
+	AvatarSize  float64
+	AvatarRight float64
+	BubblePadH  float64
+	BubblePadV  float64
+	LineHeight  float64
+	ImgGap      float64
+	ImgSizeMax  float64 // 九宫格单图最大尺寸
+	MaxHeight   float64 // 单页最大高度，超出时分页
+
+	hasAvatar    bool
+	anonKey      []byte // 匿名身份种子，非 nil 时绘制生成头像
+	contentMaxW  float64
+	textFace     font.Face
+	lineH        float64 // 单行文字占用高度
+	imgCount     int
+	imgCols      int
+	gridItemSize float64 // 动态计算的图片大小
+	imgAreaH     float64
+	cards        []linkCard // 链接预览卡片
+	CardH        float64
+}
+
+// pageContent 单页内容
+type pageContent struct {
+	lines  []string // 气泡内的文字行
+	images bool     // 是否绘制附件区 (链接卡片 + 图片)
+}
+
+// RenderPost 渲染图文合一。
+// 内容超过单页最大高度时按段落拆分为多页，按顺序返回每页的图片。
+func (r *Renderer) RenderPost(post *model.Post) ([][]byte, error) {
 	if !r.Available() {
 		return nil, fmt.Errorf("渲染器未初始化(字体缺失)")
 	}
 
-	// ── 1. 样式配置 ──
-	const (
-		CanvasWidth = 800.0
-		Padding     = 40.0
-		SizeText    = 32.0
-		SizeName    = 28.0
-		SizeMeta    = 22.0
-		AvatarSize  = 90.0
-		AvatarRight = 20.0
-		BubblePadH  = 30.0
-		BubblePadV  = 25.0
-		LineHeight  = 1.4
-		ImgGap      = 10.0
-		ImgSizeMax  = 220.0 // 九宫格单图最大尺寸
-	)
+	if post.Type == model.TypeChat {
+		if lines := model.ParseChatLog(post.Text); len(lines) > 0 {
+			return r.renderChat(post, lines)
+		}
+	}
+	if post.Type.Structured() {
+		return r.renderTyped(post)
+	}
+	if post.Type == model.TypePoll && post.Poll != nil {
+		return r.renderPoll(post)
+	}
+
+	l := r.newPostLayout(post)
+	pages := l.paginate(l.wrapText(post.Text), l.attachH() > 0)
+
+	out := make([][]byte, 0, len(pages))
+	for i, pg := range pages {
+		data, err := r.drawPage(post, l, pg, i, len(pages))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, data)
+	}
+	return out, nil
+}
+
+func (r *Renderer) newPostLayout(post *model.Post) *postLayout {
+	// ── 1. 样式配置 (以 1x 为基准，按 scale 放大) ──
+	k := r.scale()
+	px := func(v float64) float64 { return v * k }
+	l := &postLayout{
+		px:          px,
+		CanvasWidth: px(800),
+		Padding:     px(40),
+		SizeText:    px(32),
+		SizeName:    px(28),
+		SizeMeta:    px(22),
+		AvatarSize:  px(90),
+		AvatarRight: px(20),
+		BubblePadH:  px(30),
+		BubblePadV:  px(25),
+		LineHeight:  1.4,
+		ImgGap:      px(10),
+		ImgSizeMax:  px(220),
+		CardH:       px(124),
+		MaxHeight:   r.maxPageHeight(),
+	}
 
 	// ── 2. 计算布局 ──
-	hasAvatar := !post.Anon
-	contentMaxW := CanvasWidth - (Padding * 2)
-	if hasAvatar {
-		contentMaxW -= AvatarSize + AvatarRight
+	l.anonKey = r.anonKey(post)
+	l.hasAvatar = !post.Anon || l.anonKey != nil
+	l.contentMaxW = l.CanvasWidth - (l.Padding * 2)
+	if l.hasAvatar {
+		l.contentMaxW -= l.AvatarSize + l.AvatarRight
 	}
 
+	l.textFace = r.getFace(l.SizeText)
 	measureDc := gg.NewContext(1, 1)
-	textFace := r.getFace(SizeText)
-	measureDc.SetFontFace(textFace)
+	measureDc.SetFontFace(l.textFace)
+	l.lineH = measureDc.FontHeight() * l.LineHeight
+
+	l.imgCount = len(post.Images)
+	if l.imgCount == 1 {
+		// 单图模式
+		l.imgAreaH = px(500)
+	} else if l.imgCount > 1 {
+		// 九宫格模式
+		l.imgCols = 3
+		if l.imgCount == 2 || l.imgCount == 4 {
+			l.imgCols = 2
+		}
 
-	var lines []string
-	if post.Text != "" {
-		// 使用自定义的 WordWrap，传入 measureDc 以获取当前字体大小
-		lines = WordWrap(measureDc, post.Text, contentMaxW-(BubblePadH*2))
+		// 【关键修改】动态计算 size，防止超出右边界
+		// 公式：(内容总宽 - (列数-1)*间隙) / 列数
+		l.gridItemSize = (l.contentMaxW - float64(l.imgCols-1)*l.ImgGap) / float64(l.imgCols)
+
+		// 限制最大尺寸，避免匿名模式下图片过大
+		if l.gridItemSize > l.ImgSizeMax {
+			l.gridItemSize = l.ImgSizeMax
+		}
+
+		imgRows := int(math.Ceil(float64(l.imgCount) / float64(l.imgCols)))
+		l.imgAreaH = float64(imgRows)*l.gridItemSize + float64(imgRows-1)*l.ImgGap
 	}
+	l.cards = r.linkCards(post.Text)
+	return l
+}
 
-	fontH := measureDc.FontHeight()
-	bubbleH := 0.0
-	if len(lines) > 0 {
-		textBlockH := float64(len(lines)) * fontH * LineHeight
-		bubbleH = textBlockH + (BubblePadV * 2)
+// attachH 附件区高度: 链接卡片在上，图片在下
+func (l *postLayout) attachH() float64 {
+	h := l.imgAreaH
+	if n := len(l.cards); n > 0 {
+		h += float64(n)*l.CardH + float64(n-1)*l.ImgGap
+		if l.imgAreaH > 0 {
+			h += l.px(20)
+		}
 	}
+	return h
+}
 
-	imgAreaH := 0.0
-	imgCount := len(post.Images)
-	var imgCols, imgRows int
-	var gridItemSize float64 // 动态计算的图片大小
+// wrapText 按段落换行，每个元素为一个段落的行
+func (l *postLayout) wrapText(text string) [][]string {
+	if text == "" {
+		return nil
+	}
+	measureDc := gg.NewContext(1, 1)
+	measureDc.SetFontFace(l.textFace)
+	var paras [][]string
+	for _, p := range strings.Split(text, "\n") {
+		// 使用自定义的 WordWrap，传入 measureDc 以获取当前字体大小
+		paras = append(paras, WordWrap(measureDc, p, l.contentMaxW-(l.BubblePadH*2)))
+	}
+	return paras
+}
 
-	if imgCount > 0 {
-		if imgCount == 1 {
-			// 单图模式
-			imgAreaH = 500.0
-		} else {
-			// 九宫格模式
-			imgCols = 3
-			if imgCount == 2 || imgCount == 4 {
-				imgCols = 2
-			}
+func (l *postLayout) bubbleHeight(lines int) float64 {
+	if lines == 0 {
+		return 0
+	}
+	return float64(lines)*l.lineH + (l.BubblePadV * 2)
+}
 
-			// 【关键修改】动态计算 size，防止超出右边界
-			// 公式：(内容总宽 - (列数-1)*间隙) / 列数
-			gridItemSize = (contentMaxW - float64(imgCols-1)*ImgGap) / float64(imgCols)
+// pageHeight 计算单页画布高度
+func (l *postLayout) pageHeight(pg pageContent) float64 {
+	currentY := l.Padding + l.SizeName + l.px(15)
+	bubbleH := l.bubbleHeight(len(pg.lines))
+	currentY += bubbleH
+	if area := l.attachH(); pg.images && area > 0 {
+		if bubbleH > 0 {
+			currentY += l.px(20)
+		}
+		currentY += area
+	}
+	currentY += l.px(50)
 
-			// 限制最大尺寸，避免匿名模式下图片过大
-			if gridItemSize > ImgSizeMax {
-				gridItemSize = ImgSizeMax
-			}
+	minH := l.Padding + l.Padding
+	if l.hasAvatar {
+		minH = l.Padding + l.AvatarSize + l.Padding
+	}
+	return math.Max(currentY, minH)
+}
 
-			imgRows = int(math.Ceil(float64(imgCount) / float64(imgCols)))
-			imgAreaH = float64(imgRows)*gridItemSize + float64(imgRows-1)*ImgGap
-		}
+// paginate 按段落把文字分配到各页，尽量不在段落中间断开；附件区放在最后一页
+func (l *postLayout) paginate(paras [][]string, hasAttach bool) []pageContent {
+	var lines []string
+	for _, p := range paras {
+		lines = append(lines, p...)
+	}
+	whole := pageContent{lines: lines, images: hasAttach}
+	if l.MaxHeight <= 0 || l.pageHeight(whole) <= l.MaxHeight {
+		return []pageContent{whole}
 	}
 
-	currentY := Padding
-	currentY += SizeName + 15
-	contentStartY := currentY
+	// 单页可容纳的行数
+	perPage := int((l.MaxHeight - l.pageHeight(pageContent{}) - l.BubblePadV*2) / l.lineH)
+	if perPage < 1 {
+		perPage = 1
+	}
 
-	if bubbleH > 0 {
-		currentY += bubbleH
+	var pages []pageContent
+	var cur []string
+	flush := func() {
+		cur = trimBlankLines(cur)
+		if len(cur) > 0 {
+			pages = append(pages, pageContent{lines: cur})
+		}
+		cur = nil
 	}
-	if imgAreaH > 0 {
-		if bubbleH > 0 {
-			currentY += 20.0
+	for _, p := range paras {
+		if len(cur) > 0 && len(cur)+len(p) > perPage {
+			flush()
+		}
+		// 超长段落只能在段落内部断开
+		for len(p) > perPage {
+			pages = append(pages, pageContent{lines: p[:perPage]})
+			p = p[perPage:]
 		}
-		currentY += imgAreaH
+		cur = append(cur, p...)
 	}
-	currentY += 50.0
 
-	totalH := int(currentY)
-	minH := Padding + Padding
-	if hasAvatar {
-		minH = Padding + AvatarSize + Padding
+	cur = trimBlankLines(cur)
+	if hasAttach {
+		last := pageContent{lines: cur, images: true}
+		if len(cur) > 0 && l.pageHeight(last) > l.MaxHeight {
+			// 图片放不下时单独成页
+			flush()
+			last = pageContent{images: true}
+		}
+		pages = append(pages, last)
+	} else {
+		flush()
 	}
-	if totalH < int(minH) {
-		totalH = int(minH)
+	if len(pages) == 0 {
+		pages = append(pages, pageContent{})
 	}
+	return pages
+}
+
+// drawPage 绘制第 index 页 (共 total 页)
+func (r *Renderer) drawPage(post *model.Post, l *postLayout, pg pageContent, index, total int) ([]byte, error) {
+	px := l.px
+	CanvasWidth, Padding := l.CanvasWidth, l.Padding
+	AvatarSize, AvatarRight := l.AvatarSize, l.AvatarRight
+	BubblePadH, BubblePadV := l.BubblePadH, l.BubblePadV
+	contentMaxW := l.contentMaxW
+	textFace := l.textFace
+
+	totalH := int(l.pageHeight(pg))
+	contentStartY := Padding + l.SizeName + px(15)
+	bubbleH := l.bubbleHeight(len(pg.lines))
 
 	// ── 3. 开始绘制 ──
 	dc := gg.NewContext(int(CanvasWidth), totalH)
@@ -165,8 +371,13 @@ func (r *Renderer) RenderPost(post *model.Post) ([]byte, error) {
 
 	// 3.1 绘制头像
 	contentX := startX
-	if hasAvatar {
-		avatarImg := downloadAndCrop(post.QQAvatarURL(), int(AvatarSize))
+	if l.hasAvatar {
+		var avatarImg image.Image
+		if l.anonKey != nil {
+			avatarImg = identicon(l.anonKey, int(AvatarSize))
+		} else {
+			avatarImg = r.downloadAndCrop(post.QQAvatarURL(), int(AvatarSize))
+		}
 		dc.Push()
 		dc.DrawCircle(startX+AvatarSize/2, startY+AvatarSize/2, AvatarSize/2)
 		dc.Clip()
@@ -183,22 +394,22 @@ func (r *Renderer) RenderPost(post *model.Post) ([]byte, error) {
 	}
 
 	// 3.2 绘制昵称
-	dc.SetFontFace(r.getFace(SizeName))
+	dc.SetFontFace(r.getFace(l.SizeName))
 	dc.SetHexColor("#555555")
-	dc.DrawString(post.ShowName(), contentX, startY+SizeName-5)
+	dc.DrawString(r.showName(post), contentX, startY+l.SizeName-px(5))
 
 	currContentY := contentStartY
 
 	// 3.3 绘制文字气泡
 	if bubbleH > 0 {
 		dc.SetColor(color.White)
-		dc.DrawRoundedRectangle(contentX, currContentY, contentMaxW, bubbleH, 16)
+		dc.DrawRoundedRectangle(contentX, currContentY, contentMaxW, bubbleH, px(16))
 		dc.Fill()
 
 		// 小三角
-		dc.MoveTo(contentX, currContentY+25)
-		dc.LineTo(contentX-10, currContentY+35)
-		dc.LineTo(contentX, currContentY+45)
+		dc.MoveTo(contentX, currContentY+px(25))
+		dc.LineTo(contentX-px(10), currContentY+px(35))
+		dc.LineTo(contentX, currContentY+px(45))
 		dc.ClosePath()
 		dc.Fill()
 
@@ -210,28 +421,37 @@ func (r *Renderer) RenderPost(post *model.Post) ([]byte, error) {
 		ascent := float64(metrics.Ascent.Ceil())
 
 		textY := currContentY + BubblePadV + ascent
-		for i, line := range lines {
-			dc.DrawString(line, contentX+BubblePadH, textY+float64(i)*fontH*LineHeight)
+		for i, line := range pg.lines {
+			dc.DrawString(line, contentX+BubblePadH, textY+float64(i)*l.lineH)
+		}
+		currContentY += bubbleH + px(20)
+	}
+
+	// 3.4 绘制链接卡片
+	if pg.images && len(l.cards) > 0 {
+		for _, c := range l.cards {
+			r.drawLinkCard(dc, c, contentX, currContentY, contentMaxW, l.CardH)
+			currContentY += l.CardH + l.ImgGap
 		}
-		currContentY += bubbleH + 20.0
+		currContentY += px(20) - l.ImgGap
 	}
 
-	// 3.4 绘制图片
-	if imgCount > 0 {
-		if imgCount == 1 {
+	// 3.5 绘制图片
+	if pg.images && l.imgCount > 0 {
+		if l.imgCount == 1 {
 			// ── 单图模式 (Aspect Fit) ──
-			rawImg := downloadImage(post.Images[0])
+			rawImg, animated := r.loadImage(post.Images[0])
 			if rawImg != nil {
 				b := rawImg.Bounds()
 				origW, origH := float64(b.Dx()), float64(b.Dy())
 
-				const BaseMaxW = 400.0
+				BaseMaxW := px(400)
 				// 确保单图也不超出内容区域
 				maxW := BaseMaxW
 				if maxW > contentMaxW {
 					maxW = contentMaxW
 				}
-				const MaxH = 500.0
+				MaxH := px(500)
 
 				scale := math.Min(maxW/origW, MaxH/origH)
 				if scale > 1.0 {
@@ -242,68 +462,109 @@ func (r *Renderer) RenderPost(post *model.Post) ([]byte, error) {
 				targetH := int(origH * scale)
 
 				finalImg := resizeImage(rawImg, targetW, targetH)
+				cw := post.IsCWImage(0)
+				if cw {
+					finalImg = blurImage(finalImg)
+				}
 
 				dc.Push()
-				dc.DrawRoundedRectangle(contentX, currContentY, float64(targetW), float64(targetH), 12)
+				dc.DrawRoundedRectangle(contentX, currContentY, float64(targetW), float64(targetH), px(12))
 				dc.Clip()
 				dc.DrawImage(finalImg, int(contentX), int(currContentY))
+				if cw {
+					r.drawCWLabel(dc, post.CW, contentX, currContentY, float64(targetW), float64(targetH))
+				} else if animated {
+					r.drawGIFBadge(dc, contentX, currContentY, float64(targetH))
+				}
 				dc.Pop()
 				dc.ResetClip()
 			} else {
-				drawErrorPlaceholder(dc, contentX, currContentY, 200, 200)
+				drawErrorPlaceholder(dc, contentX, currContentY, px(200), px(200))
 			}
 		} else {
 			// ── 九宫格模式 (Aspect Fill) ──
-			for i, imgUrl := range post.Images {
+			gridItemSize := l.gridItemSize
+			for i := range post.Images {
 				if i >= 9 {
 					break
 				}
-				col := i % imgCols
-				row := i / imgCols
+				col := i % l.imgCols
+				row := i / l.imgCols
 
 				// 使用动态计算的 gridItemSize
-				ix := contentX + float64(col)*(gridItemSize+ImgGap)
-				iy := currContentY + float64(row)*(gridItemSize+ImgGap)
-
-				img := downloadAndCrop(imgUrl, int(gridItemSize))
-				if img != nil {
-					dc.Push()
-					dc.DrawRoundedRectangle(ix, iy, gridItemSize, gridItemSize, 8)
-					dc.Clip()
-					dc.DrawImage(img, int(ix), int(iy))
-					dc.Pop()
-					dc.ResetClip()
-				} else {
-					drawErrorPlaceholder(dc, ix, iy, gridItemSize, gridItemSize)
-				}
+				ix := contentX + float64(col)*(gridItemSize+l.ImgGap)
+				iy := currContentY + float64(row)*(gridItemSize+l.ImgGap)
+
+				r.drawThumb(dc, post, i, ix, iy, gridItemSize, px(8))
 			}
 		}
 	}
 
-	// 3.5 水印
-	wmFace := r.getFace(SizeMeta)
+	// 3.6 水印 + 页码
+	r.drawWatermark(dc, post, index, total)
+
+	return r.encode(r.brand(dc.Image(), index, total))
+}
+
+// drawWatermark 右下角绘制 "[自定义文字] #ID 投稿时间" 水印，多页时在左下角绘制页码
+func (r *Renderer) drawWatermark(dc *gg.Context, post *model.Post, index, total int) {
+	k := r.scale()
+	canvasW, totalH := float64(dc.Width()), float64(dc.Height())
+	padding := 40 * k
+
+	wmFace := r.getFace(22 * k)
 	dc.SetFontFace(wmFace)
 	dc.SetHexColor("#AAAAAA")
-	wmText := fmt.Sprintf("#%d  %s", post.ID, time.Now().Format("2006-01-02 15:04"))
+	t := time.Now()
+	if post.CreateTime > 0 {
+		t = time.Unix(post.CreateTime, 0)
+	}
+	wmText := fmt.Sprintf("#%d  %s", post.ID, t.In(r.loc).Format("2006-01-02 15:04"))
+	if r.cfg.Brand.Watermark != "" {
+		wmText = r.cfg.Brand.Watermark + "  " + wmText
+	}
 	wmW, _ := dc.MeasureString(wmText)
 	descent := float64(wmFace.Metrics().Descent.Ceil())
 
-	wmX := CanvasWidth - Padding - wmW
-	if wmX < Padding {
-		wmX = Padding
+	wmX := canvasW - padding - wmW
+	if wmX < padding {
+		wmX = padding
 	}
-	wmY := float64(totalH) - 8 - descent
+	wmY := totalH - 8*k - descent
 	dc.DrawString(wmText, wmX, wmY)
 
-	var buf bytes.Buffer
-	if err := jpeg.Encode(&buf, dc.Image(), &jpeg.Options{Quality: 90}); err != nil {
-		return nil, err
+	if total > 1 {
+		dc.DrawString(fmt.Sprintf("%d/%d", index+1, total), padding, wmY)
 	}
-	return buf.Bytes(), nil
 }
 
 // ─── 辅助函数 ───
 
+// drawThumb 在 (x, y) 绘制第 i 张稿件图片的方形缩略图，处理打码与动图角标，加载失败时绘制占位
+func (r *Renderer) drawThumb(dc *gg.Context, post *model.Post, i int, x, y, size, radius float64) {
+	img, animated := r.loadImage(post.Images[i])
+	if img == nil {
+		drawErrorPlaceholder(dc, x, y, size, size)
+		return
+	}
+	img = cropToSquare(img, int(size))
+	cw := post.IsCWImage(i)
+	if cw {
+		img = blurImage(img)
+	}
+	dc.Push()
+	dc.DrawRoundedRectangle(x, y, size, size, radius)
+	dc.Clip()
+	dc.DrawImage(img, int(x), int(y))
+	if cw {
+		r.drawCWLabel(dc, post.CW, x, y, size, size)
+	} else if animated {
+		r.drawGIFBadge(dc, x, y, size)
+	}
+	dc.Pop()
+	dc.ResetClip()
+}
+
 func drawErrorPlaceholder(dc *gg.Context, x, y, w, h float64) {
 	dc.Push()
 	dc.SetHexColor("#E0E0E0")
@@ -314,49 +575,13 @@ func drawErrorPlaceholder(dc *gg.Context, x, y, w, h float64) {
 	dc.Pop()
 }
 
-func downloadImage(url string) image.Image {
-	if url == "" {
-		return nil
-	}
-	if local := resolveLocalUploadPath(url); local != "" {
-		f, err := os.Open(local)
-		if err != nil {
-			log.Printf("加载本地图片失败: %v | path: %s", err, local)
-			return nil
-		}
-		defer func() { _ = f.Close() }()
-		img, _, err := image.Decode(f)
-		if err != nil {
-			return nil
-		}
-		return img
-	}
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil
-	}
-	req.Header.Set("User-Agent", "Mozilla/5.0")
-
-	client := &http.Client{Timeout: 8 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != 200 {
-		return nil
-	}
-	img, _, err := image.Decode(resp.Body)
-	if err != nil {
-		return nil
-	}
+func (r *Renderer) downloadImage(url string) image.Image {
+	img, _ := r.loadImage(url)
 	return img
 }
 
-func downloadAndCrop(url string, size int) image.Image {
-	src := downloadImage(url)
+func (r *Renderer) downloadAndCrop(url string, size int) image.Image {
+	src := r.downloadImage(url)
 	if src == nil {
 		return nil
 	}
@@ -395,6 +620,17 @@ func cropToSquare(src image.Image, size int) image.Image {
 	return dst
 }
 
+// trimBlankLines 去掉首尾空行，避免分页后页面以空行开头
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
 // WordWrap 自定义换行函数，支持中文
 func WordWrap(dc *gg.Context, text string, maxWidth float64) []string {
 	var lines []string
@@ -423,32 +659,3 @@ func WordWrap(dc *gg.Context, text string, maxWidth float64) []string {
 	}
 	return lines
 }
-
-func resolveLocalUploadPath(raw string) string {
-	// 1. 如果是 http/https 网络链接，直接返回空，交给后续的 http 下载逻辑处理
-	if strings.HasPrefix(raw, "http://") || strings.HasPrefix(raw, "https://") {
-		return ""
-	}
-
-	// 2. 清理路径格式 (处理 Windows/Linux 分隔符差异)
-	path := filepath.Clean(raw)
-
-	// 3. 核心逻辑：直接检查文件是否存在
-	// server.go 传递过来的是绝对路径，os.Stat 能直接找到它
-	info, err := os.Stat(path)
-	if err == nil && !info.IsDir() {
-		// 文件存在且不是文件夹，返回该路径供 os.Open 使用
-		return path
-	}
-
-	// 4. (保底逻辑) 如果传进来的是相对路径，尝试拼接当前运行目录下的 uploads
-	// 这一步通常用不到，因为 server.go 已经转成绝对路径了，但留着防守
-	wd, _ := os.Getwd()
-	absPath := filepath.Join(wd, path)
-	info, err = os.Stat(absPath)
-	if err == nil && !info.IsDir() {
-		return absPath
-	}
-
-	return ""
-}
@@ -1,69 +1,277 @@
 package render
 
 import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	"image/png"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/guohuiyuan/qzonewall-go/internal/config"
 	"github.com/guohuiyuan/qzonewall-go/internal/model"
 )
 
-// TestRenderPost 测试图文渲染功能
+// TestRenderPost 测试图文渲染功能 (离线夹具，不访问网络)
 // 运行方法: go test -v ./internal/render/ -run TestRenderPost
 func TestRenderPost(t *testing.T) {
 	// 1. 初始化渲染器
 	// 确保 internal/render/font.ttf 存在 (推荐使用 微软雅黑 msyh.ttc 改名而来)
-	r := NewRenderer()
-
+	r := NewRenderer(config.RenderConfig{})
 	if !r.Available() {
 		t.Fatal("❌ 渲染器不可用，请检查 font.ttf 是否正确嵌入")
 	}
+	r.SetImageFetcher(ImageFetcherFunc(fixtureFetcher))
 
 	// 2. 构造模拟投稿数据
-	// ★★★ 修改点：使用 QQ 头像作为图片源，保证下载成功 ★★★
-	stableImgURL := "https://q1.qlogo.cn/g?b=qq&nk=10001&s=640"
-
 	post := &model.Post{
-		ID:      10086,
-		UIN:     10001,
-		Name:    "测试用户(Test)",
-		GroupID: 123456,
-		// 测试 Emoji (注意：需使用微软雅黑等支持Emoji的字体，且显示为黑白)
-		Text: "这是一条测试内容。\nHello World! 👋\nEmoji测试：🚀 😄 🐛\n下面应该是两张一模一样的头像图片 👇",
-		Images: []string{
-			stableImgURL, // 图1：头像
-			stableImgURL, // 图2：头像
-		},
-		Anon:       false,
+		ID:         10086,
+		UIN:        10001,
+		Name:       "测试用户(Test)",
+		GroupID:    123456,
+		AvatarURL:  "fixture://avatar",
+		Text:       "这是一条测试内容。\nHello World! 👋\nEmoji测试：🚀 😄 🐛\n下面应该是两张图片 👇",
+		Images:     []string{"fixture://landscape", "fixture://grid0"},
 		Status:     model.StatusPending,
 		CreateTime: time.Now().Unix(),
 	}
 
-	t.Logf("开始渲染稿件 #%d...", post.ID)
-
 	// 3. 执行渲染
-	startTime := time.Now()
-	data, err := r.RenderPost(post)
-	duration := time.Since(startTime)
+	pages, err := r.RenderPost(post)
+	if err != nil {
+		t.Fatalf("❌ 渲染失败: %v", err)
+	}
+	if len(pages) != 1 || len(pages[0]) == 0 {
+		t.Fatalf("❌ 渲染结果异常: %d 页", len(pages))
+	}
+
+	// 4. 验证结果: 两张图片并排，画布宽度固定
+	img, _, err := image.Decode(bytes.NewReader(pages[0]))
+	if err != nil {
+		t.Fatalf("❌ 渲染结果解码失败: %v", err)
+	}
+	if w := img.Bounds().Dx(); w != 800 {
+		t.Fatalf("❌ 画布宽度应为 800, 实际 %d", w)
+	}
+
+	// 设置 RENDER_OUT 时保存结果以便人工检查
+	if dir := os.Getenv("RENDER_OUT"); dir != "" {
+		out := filepath.Join(dir, "test_render_result.jpg")
+		if err := os.WriteFile(out, pages[0], 0644); err != nil {
+			t.Fatalf("❌ 保存测试图片失败: %v", err)
+		}
+		t.Logf("📂 图片已保存为: %s", out)
+	}
+}
+
+// TestRenderPostPaginate 测试长文按段落分页
+func TestRenderPostPaginate(t *testing.T) {
+	r := NewRenderer(config.RenderConfig{MaxHeight: 800})
+	if !r.Available() {
+		t.Fatal("❌ 渲染器不可用，请检查 font.ttf 是否正确嵌入")
+	}
+
+	post := &model.Post{
+		ID:         1,
+		Text:       strings.Repeat("这是一段用于测试分页的长文字。\n", 60),
+		Anon:       true,
+		CreateTime: time.Now().Unix(),
+	}
+	pages, err := r.RenderPost(post)
+	if err != nil {
+		t.Fatalf("❌ 渲染失败: %v", err)
+	}
+	if len(pages) < 2 {
+		t.Fatalf("❌ 长文应被拆分为多页, 实际 %d 页", len(pages))
+	}
+	for i, data := range pages {
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("❌ 第 %d 页解码失败: %v", i+1, err)
+		}
+		if h := img.Bounds().Dy(); h > 800 {
+			t.Fatalf("❌ 第 %d 页高度 %d 超出上限 800", i+1, h)
+		}
+	}
+
+	short := &model.Post{ID: 2, Text: "短文", Anon: true}
+	if pages, _ := r.RenderPost(short); len(pages) != 1 {
+		t.Fatalf("❌ 短文应只有 1 页, 实际 %d 页", len(pages))
+	}
+}
+
+// TestRenderDigest 测试多稿件合集渲染
+func TestRenderDigest(t *testing.T) {
+	r := NewRenderer(config.RenderConfig{MaxHeight: 600})
+	if !r.Available() {
+		t.Fatal("❌ 渲染器不可用，请检查 font.ttf 是否正确嵌入")
+	}
+
+	var posts []*model.Post
+	for i := 1; i <= 6; i++ {
+		posts = append(posts, &model.Post{
+			ID:         int64(i),
+			Name:       "测试用户",
+			Text:       strings.Repeat("合集测试内容", i),
+			CreateTime: time.Now().Unix(),
+		})
+	}
+	pages, err := r.RenderDigest(posts)
+	if err != nil {
+		t.Fatalf("❌ 合集渲染失败: %v", err)
+	}
+	if len(pages) < 2 {
+		t.Fatalf("❌ 6 条稿件在 600px 限高下应分为多页, 实际 %d 页", len(pages))
+	}
+	if _, err := r.RenderDigest(nil); err == nil {
+		t.Fatal("❌ 空合集应返回错误")
+	}
+}
+
+// TestRenderChat 测试聊天记录渲染
+func TestRenderChat(t *testing.T) {
+	r := NewRenderer(config.RenderConfig{})
+	if !r.Available() {
+		t.Fatal("❌ 渲染器不可用，请检查 font.ttf 是否正确嵌入")
+	}
+
+	text := "小明: 在吗\n我：在的，怎么了\n小明: 明天一起去图书馆？\n还想借本书\n我: 好"
+	lines := model.ParseChatLog(text)
+	if len(lines) != 4 {
+		t.Fatalf("❌ 应解析出 4 条消息, 实际 %d 条", len(lines))
+	}
+	if lines[2].Text != "明天一起去图书馆？\n还想借本书" {
+		t.Fatalf("❌ 无前缀的行应并入上一条消息: %q", lines[2].Text)
+	}
 
-	// 4. 验证结果
+	names, right := chatSpeakers(lines, true)
+	if right != "我" || names["小明"] != "A" || names["我"] != "B" {
+		t.Fatalf("❌ 匿名说话人映射错误: %v, 右侧 %s", names, right)
+	}
+
+	post := &model.Post{ID: 3, Name: "测试用户", Text: text, Type: model.TypeChat, Anon: true}
+	pages, err := r.RenderPost(post)
 	if err != nil {
 		t.Fatalf("❌ 渲染失败: %v", err)
 	}
+	if len(pages) != 1 || len(pages[0]) == 0 {
+		t.Fatalf("❌ 聊天记录应渲染为 1 页, 实际 %d 页", len(pages))
+	}
+}
+
+// TestAnonIdentity 测试匿名代号与生成头像
+func TestAnonIdentity(t *testing.T) {
+	r := NewRenderer(config.RenderConfig{})
+	r.SetAnonIdentity("test-secret", false)
+
+	a1 := &model.Post{ID: 1, UIN: 10001, Anon: true}
+	a2 := &model.Post{ID: 2, UIN: 10001, Anon: true}
+	b := &model.Post{ID: 3, UIN: 10002, Anon: true}
+	if r.showName(a1) != r.showName(a2) {
+		t.Fatal("❌ 同一 QQ 号的匿名代号应一致")
+	}
+	if r.showName(a1) == r.showName(b) {
+		t.Fatal("❌ 不同 QQ 号的匿名代号应不同")
+	}
+	if !strings.HasPrefix(r.showName(a1), "匿名 #") {
+		t.Fatalf("❌ 匿名代号格式错误: %s", r.showName(a1))
+	}
 
-	if len(data) == 0 {
-		t.Fatal("❌ 渲染结果为空 (0 bytes)")
+	r.SetAnonIdentity("test-secret", true)
+	if r.showName(a1) == r.showName(a2) {
+		t.Fatal("❌ 按稿件派生时不同稿件的代号应不同")
 	}
 
-	// 5. 保存图片到本地
-	outputFile := "test_render_result.jpg"
-	err = os.WriteFile(outputFile, data, 0644)
+	r.SetAnonIdentity("", false)
+	if r.showName(a1) != "匿名用户" {
+		t.Fatal("❌ 未配置密钥时应显示匿名用户")
+	}
+
+	key := a1.AnonKey("test-secret", false)
+	img1, img2 := identicon(key, 90), identicon(key, 90)
+	if !bytes.Equal(img1.(*image.RGBA).Pix, img2.(*image.RGBA).Pix) {
+		t.Fatal("❌ 相同种子生成的头像应一致")
+	}
+}
+
+// TestRenderBrand 测试品牌页眉页脚与二维码
+func TestRenderBrand(t *testing.T) {
+	post := &model.Post{ID: 5, Text: "品牌测试", Anon: true, CreateTime: 1700000000}
+
+	plain, err := NewRenderer(config.RenderConfig{}).RenderPost(post)
 	if err != nil {
-		t.Fatalf("❌ 保存测试图片失败: %v", err)
+		t.Fatalf("❌ 渲染失败: %v", err)
 	}
+	branded, err := NewRenderer(config.RenderConfig{Brand: config.BrandConfig{
+		WallName:  "测试表白墙",
+		Footer:    "投稿请私聊墙墙",
+		SubmitURL: "https://example.com/wall/submit",
+		Timezone:  "Asia/Shanghai",
+	}}).RenderPost(post)
+	if err != nil {
+		t.Fatalf("❌ 渲染失败: %v", err)
+	}
+
+	p, _, _ := image.Decode(bytes.NewReader(plain[0]))
+	b, _, _ := image.Decode(bytes.NewReader(branded[0]))
+	if p == nil || b == nil {
+		t.Fatal("❌ 渲染结果解码失败")
+	}
+	if b.Bounds().Dy() <= p.Bounds().Dy() {
+		t.Fatalf("❌ 配置品牌后应增加页眉页脚, %d <= %d", b.Bounds().Dy(), p.Bounds().Dy())
+	}
+}
 
-	t.Logf("✅ 渲染成功！")
-	t.Logf("⏱️ 耗时: %v", duration)
-	t.Logf("📂 图片已保存为: %s/%s", "internal/render", outputFile)
-	t.Logf("👉 请务必使用「微软雅黑」作为 font.ttf 以支持 Emoji 显示。")
+// TestRenderCW 测试内容警告图片模糊
+func TestRenderCW(t *testing.T) {
+	cw, images, rest := model.ParseCW("CW2,3: 伤口\n正文")
+	if cw != "伤口" || len(images) != 2 || images[0] != 2 || rest != "正文" {
+		t.Fatalf("❌ 内容警告解析错误: %q %v %q", cw, images, rest)
+	}
+	if cw, _, rest := model.ParseCW("cw:\n正文"); cw != model.CWDefaultLabel || rest != "正文" {
+		t.Fatalf("❌ 未填理由时应使用默认标签: %q", cw)
+	}
+	if cw, _, _ := model.ParseCW("cwx 普通正文"); cw != "" {
+		t.Fatal("❌ 非 CW 标记不应被解析")
+	}
+
+	// 生成一张高对比度的本地图片
+	src := image.NewRGBA(image.Rect(0, 0, 200, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 200; x++ {
+			if (x/10+y/10)%2 == 0 {
+				src.Set(x, y, color.White)
+			} else {
+				src.Set(x, y, color.Black)
+			}
+		}
+	}
+	path := filepath.Join(t.TempDir(), "cw.png")
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, src)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRenderer(config.RenderConfig{Format: FormatPNG})
+	post := &model.Post{ID: 7, Anon: true, Images: []string{path}}
+	plain, err := r.RenderPost(post)
+	if err != nil {
+		t.Fatalf("❌ 渲染失败: %v", err)
+	}
+	post.CW = "伤口"
+	blurred, err := r.RenderPost(post)
+	if err != nil {
+		t.Fatalf("❌ 渲染失败: %v", err)
+	}
+	if bytes.Equal(plain[0], blurred[0]) {
+		t.Fatal("❌ 设置内容警告后图片应被模糊处理")
+	}
+	if got := r.OriginalImages(post, true, false); len(got) != 1 || !bytes.Equal(got[0], buf.Bytes()) {
+		t.Fatal("❌ 应能取回打码图片的原图")
+	}
 }
@@ -0,0 +1,304 @@
+package render
+
+import (
+	"math"
+	"strings"
+
+	"github.com/fogleman/gg"
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+	"golang.org/x/image/font"
+)
+
+// typedColors 分类稿件的主题色
+var typedColors = map[model.PostType]string{
+	model.TypeLost:   "#F97316",
+	model.TypeFound:  "#10B981",
+	model.TypeMarket: "#3B82F6",
+}
+
+// typedBlock 分类稿件卡片中的一个排版块，分页时整块移动
+type typedBlock struct {
+	gap  float64 // 与上一块的间距 (页首的块忽略)
+	h    float64
+	draw func(dc *gg.Context, x, y float64)
+}
+
+// typedLayout 分类稿件排版参数 (已按 scale 换算为像素)
+type typedLayout struct {
+	px func(float64) float64
+
+	CanvasWidth float64
+	Padding     float64
+	BannerH     float64
+	CardPad     float64
+	LabelW      float64
+	FooterH     float64
+
+	innerW    float64
+	titleFace font.Face
+	labelFace font.Face
+	textFace  font.Face
+}
+
+// newTypedLayout 分类卡片的默认排版参数
+func (r *Renderer) newTypedLayout() *typedLayout {
+	k := r.scale()
+	px := func(v float64) float64 { return v * k }
+	l := &typedLayout{
+		px:          px,
+		CanvasWidth: px(800),
+		Padding:     px(40),
+		BannerH:     px(96),
+		CardPad:     px(30),
+		LabelW:      px(130),
+		FooterH:     px(50),
+		titleFace:   r.getFace(px(36)),
+		labelFace:   r.getFace(px(24)),
+		textFace:    r.getFace(px(28)),
+	}
+	l.innerW = l.CanvasWidth - l.Padding*2 - l.CardPad*2
+	return l
+}
+
+// renderTyped 渲染寻物/招领/二手交易稿件: 彩色类型横幅 + 物品标题 + 字段表 + 补充说明 + 图片。
+func (r *Renderer) renderTyped(post *model.Post) ([][]byte, error) {
+	l := r.newTypedLayout()
+	color := typedColors[post.Type]
+	return r.renderCard(post, l, color, post.Type.Label(), r.typedBlocks(post, l, color))
+}
+
+// renderCard 绘制横幅 + 白色卡片样式的稿件。
+// 超出单页最大高度时按排版块分页，横幅只出现在首页。
+func (r *Renderer) renderCard(post *model.Post, l *typedLayout, color, label string, blocks []typedBlock) ([][]byte, error) {
+	px := l.px
+
+	// ── 分页 ──
+	MaxHeight := r.maxPageHeight()
+	pageH := func(first bool, bs []typedBlock) float64 {
+		h := l.Padding + l.CardPad*2 + l.FooterH
+		if first {
+			h += l.BannerH + px(16)
+		}
+		for i, b := range bs {
+			if i > 0 {
+				h += b.gap
+			}
+			h += b.h
+		}
+		return h
+	}
+	var pages [][]typedBlock
+	var cur []typedBlock
+	for _, b := range blocks {
+		if len(cur) > 0 && MaxHeight > 0 && pageH(len(pages) == 0, append(cur, b)) > MaxHeight {
+			pages = append(pages, cur)
+			cur = nil
+		}
+		cur = append(cur, b)
+	}
+	pages = append(pages, cur)
+
+	// ── 绘制 ──
+	out := make([][]byte, 0, len(pages))
+	for pi, bs := range pages {
+		first := pi == 0
+		dc := gg.NewContext(int(l.CanvasWidth), int(pageH(first, bs)))
+		dc.SetHexColor("#F5F5F5")
+		dc.Clear()
+
+		y := l.Padding
+		if first {
+			l.drawBanner(dc, r, post, color, label, y)
+			y += l.BannerH + px(16)
+		}
+
+		cardH := pageH(first, bs) - y - l.FooterH
+		dc.SetHexColor("#FFFFFF")
+		dc.DrawRoundedRectangle(l.Padding, y, l.CanvasWidth-l.Padding*2, cardH, px(16))
+		dc.Fill()
+
+		y += l.CardPad
+		for i, b := range bs {
+			if i > 0 {
+				y += b.gap
+			}
+			b.draw(dc, l.Padding+l.CardPad, y)
+			y += b.h
+		}
+
+		r.drawWatermark(dc, post, pi, len(pages))
+		data, err := r.encode(r.brand(dc.Image(), pi, len(pages)))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, data)
+	}
+	return out, nil
+}
+
+// typedBlocks 依次生成标题、字段、补充说明和图片行的排版块
+func (r *Renderer) typedBlocks(post *model.Post, l *typedLayout, color string) []typedBlock {
+	px := l.px
+	fields := post.Fields
+	if fields == nil {
+		fields = &model.PostFields{}
+	}
+	measureDc := gg.NewContext(1, 1)
+	var blocks []typedBlock
+
+	// 物品标题
+	item := fields.Item
+	if item == "" {
+		item = "(未填写物品)"
+	}
+	blocks = append(blocks, l.titleBlock(item))
+
+	// 字段表: 标签 | 值
+	measureDc.SetFontFace(l.textFace)
+	lineH := measureDc.FontHeight() * 1.4
+	for i, f := range fields.Lines(post.Type) {
+		value := f.Value
+		valueColor := "#222222"
+		if f.Label == "价格" {
+			valueColor = "#EF4444"
+			if !strings.ContainsAny(value, "¥￥元") {
+				value = "¥" + value
+			}
+		}
+		lines := WordWrap(measureDc, value, l.innerW-l.LabelW)
+		label := f.Label
+		gap := px(14)
+		if i == 0 {
+			gap = px(20)
+		}
+		blocks = append(blocks, typedBlock{
+			gap: gap,
+			h:   float64(len(lines)) * lineH,
+			draw: func(dc *gg.Context, x, y float64) {
+				dc.SetFontFace(l.labelFace)
+				dc.SetHexColor("#888888")
+				dc.DrawStringAnchored(label, x, y+lineH/2, 0, 0.35)
+				dc.SetFontFace(l.textFace)
+				dc.SetHexColor(valueColor)
+				for j, line := range lines {
+					dc.DrawStringAnchored(line, x+l.LabelW, y+lineH*(float64(j)+0.5), 0, 0.35)
+				}
+			},
+		})
+	}
+
+	// 补充说明: 分隔线后逐行成块，便于分页
+	if text := strings.TrimSpace(post.Text); text != "" {
+		blocks = append(blocks, typedBlock{
+			gap: px(20),
+			h:   px(2),
+			draw: func(dc *gg.Context, x, y float64) {
+				dc.SetHexColor(color)
+				dc.SetLineWidth(px(2))
+				dc.DrawLine(x, y+px(1), x+px(60), y+px(1))
+				dc.Stroke()
+			},
+		})
+		blocks = append(blocks, l.textBlocks(text, px(16))...)
+	}
+
+	blocks = append(blocks, r.imageBlocks(post, l)...)
+	return blocks
+}
+
+// titleBlock 卡片标题 (物品名/投票问题)，可换行
+func (l *typedLayout) titleBlock(title string) typedBlock {
+	measureDc := gg.NewContext(1, 1)
+	measureDc.SetFontFace(l.titleFace)
+	titleLineH := measureDc.FontHeight() * 1.35
+	titleLines := WordWrap(measureDc, title, l.innerW)
+	return typedBlock{
+		h: float64(len(titleLines)) * titleLineH,
+		draw: func(dc *gg.Context, x, y float64) {
+			dc.SetFontFace(l.titleFace)
+			dc.SetHexColor("#111111")
+			for i, line := range titleLines {
+				dc.DrawStringAnchored(line, x, y+titleLineH*(float64(i)+0.5), 0, 0.35)
+			}
+		},
+	}
+}
+
+// textBlocks 正文逐行成块，便于分页；gap 为首行与上一块的间距
+func (l *typedLayout) textBlocks(text string, gap float64) []typedBlock {
+	measureDc := gg.NewContext(1, 1)
+	measureDc.SetFontFace(l.textFace)
+	lineH := measureDc.FontHeight() * 1.4
+	var blocks []typedBlock
+	for i, line := range WordWrap(measureDc, text, l.innerW) {
+		g := 0.0
+		if i == 0 {
+			g = gap
+		}
+		blocks = append(blocks, typedBlock{
+			gap: g,
+			h:   lineH,
+			draw: func(dc *gg.Context, x, y float64) {
+				dc.SetFontFace(l.textFace)
+				dc.SetHexColor("#333333")
+				dc.DrawStringAnchored(line, x, y+lineH/2, 0, 0.35)
+			},
+		})
+	}
+	return blocks
+}
+
+// imageBlocks 图片按九宫格规则排列，每行一块
+func (r *Renderer) imageBlocks(post *model.Post, l *typedLayout) []typedBlock {
+	px := l.px
+	var blocks []typedBlock
+	n := min(len(post.Images), 9)
+	if n > 0 {
+		cols := 3
+		switch n {
+		case 1:
+			cols = 1
+		case 2, 4:
+			cols = 2
+		}
+		gap := px(10)
+		size := (l.innerW - float64(cols-1)*gap) / float64(cols)
+		if cols == 1 {
+			size = math.Min(size, px(420))
+		}
+		rows := (n + cols - 1) / cols
+		for row := 0; row < rows; row++ {
+			start := row * cols
+			end := min(start+cols, n)
+			blockGap := gap
+			if row == 0 {
+				blockGap = px(24)
+			}
+			blocks = append(blocks, typedBlock{
+				gap: blockGap,
+				h:   size,
+				draw: func(dc *gg.Context, x, y float64) {
+					for i := start; i < end; i++ {
+						r.drawThumb(dc, post, i, x+float64(i-start)*(size+gap), y, size, px(8))
+					}
+				},
+			})
+		}
+	}
+	return blocks
+}
+
+// drawBanner 绘制首页顶部的类型横幅: 左侧类型名，右侧投稿人
+func (l *typedLayout) drawBanner(dc *gg.Context, r *Renderer, post *model.Post, color, label string, y float64) {
+	px := l.px
+	x, w := l.Padding, l.CanvasWidth-l.Padding*2
+	dc.SetHexColor(color)
+	dc.DrawRoundedRectangle(x, y, w, l.BannerH, px(16))
+	dc.Fill()
+
+	dc.SetHexColor("#FFFFFF")
+	dc.SetFontFace(r.getFace(px(38)))
+	dc.DrawStringAnchored(label, x+px(30), y+l.BannerH/2, 0, 0.35)
+	dc.SetFontFace(r.getFace(px(22)))
+	dc.DrawStringAnchored(r.showName(post), x+w-px(30), y+l.BannerH/2, 1, 0.35)
+}
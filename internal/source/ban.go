@@ -0,0 +1,217 @@
+package source
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+
+	zero "github.com/wdvxdr1123/ZeroBot"
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+const banUsage = `用法: /拉黑 <对象> [时长] [理由]
+对象: QQ号 / @用户 / #稿件编号 (拉黑作者，不显示其QQ) / 群 群号 / ip 地址
+时长: 30m / 12h / 7d / 永久 (默认永久)
+/解封 <对象> 解除封禁`
+
+// banFilter 作为 ZeroBot 中间处理器在命令匹配后执行: 被封禁的QQ号或群发送的消息不做处理，
+// 命令回复封禁提示。观察员及以上不受限制。
+func (b *QQBot) banFilter(ctx *zero.Ctx) bool {
+	ban := b.activeBan(ctx.Event.UserID, ctx.Event.GroupID)
+	if ban == nil || b.roleRule(model.RoleViewer)(ctx) {
+		return true
+	}
+	if isCommand(ctx) {
+		ctx.Send(message.Text(banNotice(ban)))
+	}
+	return false
+}
+
+// activeBan 发送者QQ号或所在群当前生效的封禁，未封禁时返回 nil
+func (b *QQBot) activeBan(uin, groupID int64) *model.Ban {
+	targets := [][2]string{{model.BanQQ, strconv.FormatInt(uin, 10)}}
+	if groupID > 0 {
+		targets = append(targets, [2]string{model.BanGroup, strconv.FormatInt(groupID, 10)})
+	}
+	for _, t := range targets {
+		ban, err := b.store.ActiveBan(t[0], t[1])
+		if err != nil {
+			log.Printf("[QQBot] 查询封禁失败: %v", err)
+			continue
+		}
+		if ban != nil {
+			return ban
+		}
+	}
+	return nil
+}
+
+// banNotice 被封禁时的提示
+func banNotice(ban *model.Ban) string {
+	text := "🚫 你已被禁止使用表白墙"
+	if ban.Kind == model.BanGroup {
+		text = "🚫 本群已被禁止使用表白墙"
+	}
+	if ban.Reason != "" {
+		text += "\n理由: " + ban.Reason
+	}
+	return text + "\n期限: " + ban.ExpireLabel()
+}
+
+// parseBanTarget 解析 /拉黑 /解封 的封禁对象，返回对象类型与值 (按稿件时为 postID) 及剩余参数
+func parseBanTarget(ctx *zero.Ctx) (kind, target string, postID int64, rest []string, ok bool) {
+	args := strings.Fields(getArgs(ctx))
+	for _, seg := range ctx.Event.Message {
+		if seg.Type == "at" && seg.Data["qq"] != "" {
+			return model.BanQQ, seg.Data["qq"], 0, args, true
+		}
+	}
+	if len(args) == 0 {
+		return "", "", 0, nil, false
+	}
+
+	switch first := strings.ToLower(args[0]); {
+	case strings.HasPrefix(first, "#"):
+		id, err := strconv.ParseInt(first[1:], 10, 64)
+		return "", "", id, args[1:], err == nil && id > 0
+	case first == "群" && len(args) >= 2:
+		_, err := strconv.ParseInt(args[1], 10, 64)
+		return model.BanGroup, args[1], 0, args[2:], err == nil
+	case first == "ip" && len(args) >= 2:
+		return model.BanIP, args[1], 0, args[2:], net.ParseIP(args[1]) != nil
+	default:
+		_, err := strconv.ParseInt(args[0], 10, 64)
+		return model.BanQQ, args[0], 0, args[1:], err == nil
+	}
+}
+
+// handleBan 拉黑: /拉黑 <对象> [时长] [理由]；不带参数时列出生效中的封禁
+func (b *QQBot) handleBan(ctx *zero.Ctx) {
+	if getArgs(ctx) == "" && !hasAt(ctx) {
+		b.listBans(ctx)
+		return
+	}
+	kind, target, postID, rest, ok := parseBanTarget(ctx)
+	if !ok {
+		ctx.Send(message.Text(banUsage))
+		return
+	}
+
+	if postID > 0 {
+		post, err := b.store.GetPost(postID)
+		if err != nil || post == nil {
+			ctx.Send(message.Text(fmt.Sprintf("❌ 稿件 #%d 不存在", postID)))
+			return
+		}
+		switch {
+		case post.IP != "":
+			kind, target = model.BanIP, post.IP
+		case post.UIN > 0:
+			kind, target = model.BanQQ, strconv.FormatInt(post.UIN, 10)
+		default:
+			ctx.Send(message.Text(fmt.Sprintf("❌ 稿件 #%d 没有可封禁的作者信息", postID)))
+			return
+		}
+	}
+	if kind == model.BanQQ && target == strconv.FormatInt(ctx.Event.UserID, 10) {
+		ctx.Send(message.Text("❌ 不能拉黑自己"))
+		return
+	}
+
+	var expire int64
+	if len(rest) > 0 {
+		if d, ok := model.ParseBanDuration(rest[0]); ok {
+			if d > 0 {
+				expire = time.Now().Add(d).Unix()
+			}
+			rest = rest[1:]
+		}
+	}
+	ban := &model.Ban{
+		Kind:       kind,
+		Target:     target,
+		PostID:     postID,
+		Reason:     strings.Join(rest, " "),
+		Operator:   fmt.Sprintf("qq:%d", ctx.Event.UserID),
+		ExpireTime: expire,
+		CreateTime: time.Now().Unix(),
+	}
+	if err := b.store.SaveBan(ban); err != nil {
+		ctx.Send(message.Text("❌ 拉黑失败: " + err.Error()))
+		return
+	}
+	log.Printf("[QQBot] QQ %d 拉黑 %s %s (稿件 #%d), %s", ctx.Event.UserID, kind, target, postID, ban.ExpireLabel())
+
+	text := fmt.Sprintf("🚫 已拉黑%s (封禁 #%d)\n期限: %s", ban.TargetLabel(), ban.ID, ban.ExpireLabel())
+	if ban.Reason != "" {
+		text += "\n理由: " + ban.Reason
+	}
+	ctx.Send(message.Text(text))
+}
+
+// handleUnban 解封: /解封 <对象>
+func (b *QQBot) handleUnban(ctx *zero.Ctx) {
+	kind, target, postID, _, ok := parseBanTarget(ctx)
+	if !ok {
+		ctx.Send(message.Text("用法: /解封 QQ号 / @用户 / #稿件编号 / 群 群号 / ip 地址"))
+		return
+	}
+	var ban *model.Ban
+	var err error
+	if postID > 0 {
+		ban, err = b.store.FindPostBan(postID)
+	} else {
+		ban, err = b.store.FindBan(kind, target)
+	}
+	if err != nil {
+		ctx.Send(message.Text("❌ 查询失败: " + err.Error()))
+		return
+	}
+	if ban == nil {
+		ctx.Send(message.Text("❌ 未找到对应的封禁"))
+		return
+	}
+	if err := b.store.DeleteBan(ban.ID); err != nil {
+		ctx.Send(message.Text("❌ 解封失败: " + err.Error()))
+		return
+	}
+	log.Printf("[QQBot] QQ %d 解封 %s %s (封禁 #%d)", ctx.Event.UserID, ban.Kind, ban.Target, ban.ID)
+	ctx.Send(message.Text(fmt.Sprintf("✅ 已解封%s", ban.TargetLabel())))
+}
+
+func (b *QQBot) listBans(ctx *zero.Ctx) {
+	bans, err := b.store.ListBans()
+	if err != nil {
+		ctx.Send(message.Text("❌ 查询失败: " + err.Error()))
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString(banUsage + "\n")
+	if len(bans) == 0 {
+		sb.WriteString("\n暂无封禁")
+	} else {
+		sb.WriteString("\n生效中的封禁:")
+		for _, ban := range bans {
+			fmt.Fprintf(&sb, "\n#%d %s · %s", ban.ID, ban.TargetLabel(), ban.ExpireLabel())
+			if ban.Reason != "" {
+				sb.WriteString(" · " + ban.Reason)
+			}
+		}
+	}
+	ctx.Send(message.Text(sb.String()))
+}
+
+// hasAt 消息中是否 @ 了用户
+func hasAt(ctx *zero.Ctx) bool {
+	for _, seg := range ctx.Event.Message {
+		if seg.Type == "at" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package source
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+	"github.com/tidwall/gjson"
+
+	zero "github.com/wdvxdr1123/ZeroBot"
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+// extractForwardID 取出消息中合并转发段的 ID
+func extractForwardID(ctx *zero.Ctx) string {
+	for _, seg := range ctx.Event.Message {
+		if seg.Type == "forward" && seg.Data["id"] != "" {
+			return seg.Data["id"]
+		}
+	}
+	return ""
+}
+
+// forwardToChatLog 拉取合并转发消息并转换为聊天记录文本。
+// 消息中的图片按出现顺序收集，正文里以 [图片N] 占位。
+func forwardToChatLog(ctx *zero.Ctx, id string) (string, []string) {
+	data := ctx.GetForwardMessage(id)
+	nodes := data.Get("messages")
+	if !nodes.Exists() {
+		nodes = data.Get("message")
+	}
+
+	var lines []model.ChatLine
+	var images []string
+	nodes.ForEach(func(_, node gjson.Result) bool {
+		speaker := node.Get("sender.nickname").String()
+		if speaker == "" {
+			speaker = node.Get("data.nickname").String()
+		}
+		content := node.Get("message")
+		if !content.Exists() {
+			content = node.Get("content")
+		}
+		if !content.Exists() {
+			content = node.Get("data.content")
+		}
+
+		var text strings.Builder
+		for _, seg := range message.ParseMessage([]byte(content.Raw)) {
+			switch seg.Type {
+			case "text":
+				text.WriteString(seg.Data["text"])
+			case "image":
+				if img := imageRef(seg); img != "" {
+					images = append(images, img)
+					lines = appendChatLine(lines, speaker, text.String())
+					text.Reset()
+					lines = append(lines, model.ChatLine{Speaker: speaker, Text: fmt.Sprintf("[图片%d]", len(images))})
+				}
+			case "face":
+				text.WriteString("[表情]")
+			}
+		}
+		lines = appendChatLine(lines, speaker, text.String())
+		return true
+	})
+	return model.FormatChatLog(lines), images
+}
+
+func appendChatLine(lines []model.ChatLine, speaker, text string) []model.ChatLine {
+	text = strings.TrimSpace(text)
+	if speaker == "" || text == "" {
+		return lines
+	}
+	return append(lines, model.ChatLine{Speaker: speaker, Text: text})
+}
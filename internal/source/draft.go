@@ -0,0 +1,239 @@
+package source
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+	"github.com/guohuiyuan/qzonewall-go/internal/wall"
+
+	zero "github.com/wdvxdr1123/ZeroBot"
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+// draftSweepInterval 清理超时草稿的间隔
+const draftSweepInterval = time.Minute
+
+// openDraft 不带内容的 /投稿 开启草稿，之后该用户在同一会话发送的消息都追加到草稿，完成后投往 target
+func (b *QQBot) openDraft(ctx *zero.Ctx, anon bool, target *wall.Wall) {
+	if !b.checkGroupEnabled(ctx) {
+		return
+	}
+	uin, groupID := ctx.Event.UserID, ctx.Event.GroupID
+	now := time.Now().Unix()
+	draft, err := b.store.GetDraft(uin, groupID)
+	if err != nil {
+		ctx.Send(message.Text("❌ 读取草稿失败: " + err.Error()))
+		return
+	}
+	if draft != nil && !draft.Expired(time.Now(), b.cfg.Wall.DraftTimeout.Duration) {
+		draft.UpdateTime = now
+		if err := b.store.SaveDraft(draft); err != nil {
+			ctx.Send(message.Text("❌ 保存草稿失败: " + err.Error()))
+			return
+		}
+		ctx.Send(message.Text(fmt.Sprintf("📝 你已有一份草稿 (%s)，继续发送内容即可\n/预览 查看效果，/完成 提交，/取消 放弃", draftStat(draft))))
+		return
+	}
+
+	draft = &model.Draft{
+		UIN:        uin,
+		GroupID:    groupID,
+		Name:       ctx.Event.Sender.NickName,
+		Anon:       anon,
+		WallID:     target.ID,
+		CreateTime: now,
+		UpdateTime: now,
+	}
+	if err := b.store.SaveDraft(draft); err != nil {
+		ctx.Send(message.Text("❌ 保存草稿失败: " + err.Error()))
+		return
+	}
+	kind := "投稿"
+	if anon {
+		kind = "匿名投稿"
+	}
+	ctx.Send(message.Text(fmt.Sprintf("📝 已开始%s草稿，请直接发送文字或图片，可分多条发送\n/预览 查看效果，/完成 提交，/取消 放弃\n超过 %s 未发送内容将自动丢弃",
+		kind, b.cfg.Wall.DraftTimeout.Duration)))
+}
+
+// draftRule 消息来自有草稿的用户且不是命令时匹配，草稿存入 ctx.State["draft"]。
+// 已超时的草稿在此处顺带丢弃。
+func (b *QQBot) draftRule(ctx *zero.Ctx) bool {
+	if ctx.Event.UserID == 0 || len(ctx.Event.Message) == 0 {
+		return false
+	}
+	if isCommand(ctx) {
+		return false
+	}
+	draft, err := b.store.GetDraft(ctx.Event.UserID, ctx.Event.GroupID)
+	if err != nil || draft == nil {
+		return false
+	}
+	if draft.Expired(time.Now(), b.cfg.Wall.DraftTimeout.Duration) {
+		b.discardExpiredDraft(draft)
+		return false
+	}
+	ctx.State["draft"] = draft
+	return true
+}
+
+// handleDraftAppend 追加消息到草稿
+func (b *QQBot) handleDraftAppend(ctx *zero.Ctx) {
+	draft, ok := ctx.State["draft"].(*model.Draft)
+	if !ok {
+		return
+	}
+	text := strings.TrimSpace(ctx.ExtractPlainText())
+	images := extractImages(ctx)
+	if text == "" && len(images) == 0 {
+		return
+	}
+
+	if n := len([]rune(draft.Text)) + len([]rune(text)); text != "" && b.cfg.Wall.MaxTextLen > 0 && n > b.cfg.Wall.MaxTextLen {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 文字超出限制 (%d/%d)，本条未添加", n, b.cfg.Wall.MaxTextLen)))
+		return
+	}
+	if n := len(draft.Images) + len(images); len(images) > 0 && b.cfg.Wall.MaxImages > 0 && n > b.cfg.Wall.MaxImages {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 图片超出限制 (%d/%d)，本条未添加", n, b.cfg.Wall.MaxImages)))
+		return
+	}
+
+	draft.Append(text, images)
+	draft.UpdateTime = time.Now().Unix()
+	if err := b.store.SaveDraft(draft); err != nil {
+		ctx.Send(message.Text("❌ 保存草稿失败: " + err.Error()))
+		return
+	}
+	ctx.Send(message.Text(fmt.Sprintf("📝 已添加 (%s)，/预览 查看，/完成 提交", draftStat(draft))))
+}
+
+// currentDraft 读取当前会话的有效草稿，没有时回复提示并返回 nil
+func (b *QQBot) currentDraft(ctx *zero.Ctx) *model.Draft {
+	draft, err := b.store.GetDraft(ctx.Event.UserID, ctx.Event.GroupID)
+	if err != nil {
+		ctx.Send(message.Text("❌ 读取草稿失败: " + err.Error()))
+		return nil
+	}
+	if draft != nil && draft.Expired(time.Now(), b.cfg.Wall.DraftTimeout.Duration) {
+		b.discardExpiredDraft(draft)
+		draft = nil
+	}
+	if draft == nil {
+		ctx.Send(message.Text("❌ 当前没有草稿，发送不带内容的 /投稿 开始"))
+		return nil
+	}
+	return draft
+}
+
+// handleDraftPreview 渲染草稿截图
+func (b *QQBot) handleDraftPreview(ctx *zero.Ctx) {
+	draft := b.currentDraft(ctx)
+	if draft == nil {
+		return
+	}
+	post := draft.Post()
+	if post.Text == "" && len(post.Images) == 0 {
+		ctx.Send(message.Text("📝 草稿还是空的，请先发送文字或图片"))
+		return
+	}
+	target := b.walls.Get(draft.WallID)
+	if target == nil || !target.Renderer.Available() {
+		ctx.Send(message.Text(fmt.Sprintf("📝 草稿预览 (%s)\n%s", draftStat(draft), post.Text)))
+		return
+	}
+	// 草稿内容随时变化，不写入渲染缓存
+	pages, err := target.Renderer.RenderPost(resolvePostImages(post))
+	if err != nil {
+		ctx.Send(message.Text("❌ 渲染失败: " + err.Error()))
+		return
+	}
+	var segs message.Message
+	for _, imgData := range pages {
+		segs = append(segs, message.Image("base64://"+base64.StdEncoding.EncodeToString(imgData)))
+	}
+	ctx.Send(segs)
+}
+
+// handleDraftFinish 提交草稿，提交成功后删除草稿；校验失败时保留草稿以便修改
+func (b *QQBot) handleDraftFinish(ctx *zero.Ctx) {
+	draft := b.currentDraft(ctx)
+	if draft == nil {
+		return
+	}
+	if !b.submitPost(ctx, draft.Post()) {
+		return
+	}
+	if err := b.store.DeleteDraft(draft.UIN, draft.GroupID); err != nil {
+		log.Printf("[QQBot] 删除草稿失败 (QQ %d): %v", draft.UIN, err)
+	}
+}
+
+// handleDraftCancel 放弃草稿
+func (b *QQBot) handleDraftCancel(ctx *zero.Ctx) {
+	// "/取消打码" 等命令也会以 "取消" 开头，带参数时交给对应命令处理
+	if getArgs(ctx) != "" {
+		return
+	}
+	draft := b.currentDraft(ctx)
+	if draft == nil {
+		return
+	}
+	if err := b.store.DeleteDraft(draft.UIN, draft.GroupID); err != nil {
+		ctx.Send(message.Text("❌ 删除草稿失败: " + err.Error()))
+		return
+	}
+	ctx.Send(message.Text("🗑️ 草稿已丢弃"))
+}
+
+// runDraftJanitor 定期丢弃超时的草稿
+func (b *QQBot) runDraftJanitor() {
+	ticker := time.NewTicker(draftSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+			before := time.Now().Add(-b.cfg.Wall.DraftTimeout.Duration).Unix()
+			drafts, err := b.store.ListDraftsBefore(before)
+			if err != nil {
+				log.Printf("[QQBot] 查询超时草稿失败: %v", err)
+				continue
+			}
+			for _, d := range drafts {
+				b.discardExpiredDraft(d)
+			}
+		}
+	}
+}
+
+// discardExpiredDraft 删除超时草稿并通知投稿人
+func (b *QQBot) discardExpiredDraft(d *model.Draft) {
+	if err := b.store.DeleteDraft(d.UIN, d.GroupID); err != nil {
+		log.Printf("[QQBot] 删除超时草稿失败 (QQ %d): %v", d.UIN, err)
+		return
+	}
+	log.Printf("[QQBot] QQ %d 的草稿超时已丢弃", d.UIN)
+	if d.Text == "" && len(d.Images) == 0 {
+		return
+	}
+	notice := fmt.Sprintf("⌛ 草稿超过 %s 未更新，已自动丢弃", b.cfg.Wall.DraftTimeout.Duration)
+	zero.RangeBot(func(_ int64, ctx *zero.Ctx) bool {
+		if d.GroupID > 0 {
+			ctx.SendGroupMessage(d.GroupID, message.Message{message.At(d.UIN), message.Text(" " + notice)})
+		} else {
+			ctx.SendPrivateMessage(d.UIN, message.Text(notice))
+		}
+		return false
+	})
+}
+
+// draftStat 草稿内容统计，如 "文字 12 字, 图片 2 张"
+func draftStat(d *model.Draft) string {
+	return fmt.Sprintf("文字 %d 字, 图片 %d 张", len([]rune(d.Text)), len(d.Images))
+}
@@ -0,0 +1,84 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+	"github.com/guohuiyuan/qzonewall-go/internal/store"
+
+	zero "github.com/wdvxdr1123/ZeroBot"
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+// handleEditPost 改稿: /改稿 <编号> <新内容>，投稿人可在审核前修改自己的稿件，
+// 修改记入修订记录并将差异通知管理群
+func (b *QQBot) handleEditPost(ctx *zero.Ctx) {
+	args := getArgs(ctx)
+	idStr, text := args, ""
+	if i := strings.IndexFunc(args, unicode.IsSpace); i > 0 {
+		idStr, text = args[:i], strings.TrimSpace(args[i:])
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || text == "" {
+		ctx.Send(message.Text("用法: /改稿 <编号> <新内容>"))
+		return
+	}
+
+	post, err := b.store.GetPost(id)
+	if err != nil || post == nil || post.UIN != ctx.Event.UserID {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 稿件 #%d 不存在", id)))
+		return
+	}
+	if post.Status != model.StatusPending {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 稿件 #%d 已%s，无法修改", id, post.Status.Label())))
+		return
+	}
+	if post.Type.Structured() || post.Poll != nil {
+		// 分类稿件的字段和投票选项在正文之外，只改正文会与预览不一致
+		ctx.Send(message.Text(fmt.Sprintf("❌ 稿件 #%d 是%s稿件，不支持修改，请撤稿后重新投稿", id, post.Type.Label())))
+		return
+	}
+	if text == post.Text {
+		ctx.Send(message.Text("内容没有变化"))
+		return
+	}
+	if b.cfg.Wall.MaxTextLen > 0 && len([]rune(text)) > b.cfg.Wall.MaxTextLen {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 文字超出限制 (%d/%d)", len([]rune(text)), b.cfg.Wall.MaxTextLen)))
+		return
+	}
+	if hit, word := store.CheckCensor(text, b.censorWords); len(b.censorWords) > 0 && hit {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 投稿包含违禁词: %s", word)))
+		return
+	}
+
+	rev, err := b.store.EditPostText(id, text, fmt.Sprintf("qq:%d", ctx.Event.UserID))
+	if errors.Is(err, store.ErrNotPending) {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 稿件 #%d 已被审核，无法修改", id)))
+		return
+	}
+	if err != nil {
+		ctx.Send(message.Text("❌ 修改失败: " + err.Error()))
+		return
+	}
+	ctx.Send(message.Text(fmt.Sprintf("✅ 稿件 #%d 已修改，仍在原位置等待审核", id)))
+
+	if fresh, err := b.store.GetPost(id); err == nil && fresh != nil {
+		post = fresh
+	}
+	diff := model.FormatDiff(model.DiffLines(rev.OldText, rev.NewText))
+	b.notifyManageGroup(ctx, post, fmt.Sprintf("✏️ 投稿人修改了稿件 #%d\n%s", post.ID, diff))
+}
+
+// revisionNote 稿件修改记录摘要: 修改次数及原文到当前内容的差异，未修改过时返回空
+func (b *QQBot) revisionNote(post *model.Post) string {
+	revs, err := b.store.ListRevisions(post.ID)
+	if err != nil || len(revs) == 0 {
+		return ""
+	}
+	diff := model.FormatDiff(model.DiffLines(revs[0].OldText, post.Text))
+	return fmt.Sprintf("✏️ 稿件 #%d 已修改 %d 次，与原稿的差异:\n%s", post.ID, len(revs), diff)
+}
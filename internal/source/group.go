@@ -0,0 +1,251 @@
+package source
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+	"github.com/guohuiyuan/qzonewall-go/internal/wall"
+
+	zero "github.com/wdvxdr1123/ZeroBot"
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+const groupSettingUsage = `用法: /本群设置 匿名 跟随|匿名|实名
+      /本群设置 通知 群内|私聊|不通知`
+
+const groupWallUsage = `
+      /本群设置 墙 <墙名>`
+
+// groupSetting 获取群设置，未登记或查询失败时返回 nil
+func (b *QQBot) groupSetting(groupID int64) *model.GroupSetting {
+	if groupID <= 0 {
+		return nil
+	}
+	g, err := b.store.GetGroupSetting(groupID)
+	if err != nil {
+		log.Printf("[QQBot] 查询群 %d 设置失败: %v", groupID, err)
+		return nil
+	}
+	return g
+}
+
+// groupEnabled 群是否允许投稿: 已登记的群按开关，未登记的群在开启白名单时不允许。私聊始终允许。
+func (b *QQBot) groupEnabled(groupID int64) bool {
+	if groupID <= 0 {
+		return true
+	}
+	if g := b.groupSetting(groupID); g != nil {
+		return g.Enabled
+	}
+	return !b.cfg.Bot.GroupAllowlist
+}
+
+// checkGroupEnabled 本群未开启投稿时回复提示并返回 false
+func (b *QQBot) checkGroupEnabled(ctx *zero.Ctx) bool {
+	if b.groupEnabled(ctx.Event.GroupID) {
+		return true
+	}
+	ctx.Send(message.Text("❌ 本群未开启投稿，群主可发送 /本群开启投稿，也可以私聊机器人投稿"))
+	return false
+}
+
+// defaultAnon 不带 "匿名" 的投稿命令是否默认匿名: 按群设置，未设置时跟随全局配置
+func (b *QQBot) defaultAnon(ctx *zero.Ctx) bool {
+	if g := b.groupSetting(ctx.Event.GroupID); g != nil {
+		return g.AnonDefault(b.cfg.Wall.AnonDefault)
+	}
+	return b.cfg.Wall.AnonDefault
+}
+
+// notifySubmitter 按来源群的通知设置告知投稿人审核结果
+func (b *QQBot) notifySubmitter(ctx *zero.Ctx, p *model.Post, text string) {
+	if p.UIN <= 0 {
+		return
+	}
+	if p.GroupID <= 0 {
+		ctx.SendPrivateMessage(p.UIN, message.Text(text))
+		return
+	}
+	notify := model.GroupNotifyGroup
+	if g := b.groupSetting(p.GroupID); g != nil {
+		notify = g.Notify
+	}
+	switch notify {
+	case model.GroupNotifyPrivate:
+		ctx.SendPrivateMessage(p.UIN, message.Text(text))
+	case model.GroupNotifyOff:
+	default:
+		ctx.SendGroupMessage(p.GroupID, message.Text(text))
+	}
+}
+
+// groupWall 群投稿默认进入的墙，规则见 wall.Registry.ForGroup
+func (b *QQBot) groupWall(groupID int64) *wall.Wall {
+	return b.walls.ForGroup(b.groupSetting(groupID), groupID)
+}
+
+// pickWall 确定投稿的目标墙并返回去掉墙标记的正文: 多墙时正文开头 "墙: 名称" 优先，其次按所在群。
+// 墙名或本群设置的墙不存在时回复提示并返回 ok=false
+func (b *QQBot) pickWall(ctx *zero.Ctx, text string) (target *wall.Wall, rest string, ok bool) {
+	if b.walls.Multi() {
+		if name, rest := model.ParseWallLine(text); name != "" {
+			if target = b.walls.Find(name); target == nil {
+				ctx.Send(message.Text(fmt.Sprintf("❌ 墙 %s 不存在，可选: %s", name, b.walls.Names())))
+				return nil, text, false
+			}
+			return target, rest, true
+		}
+	}
+	if target = b.groupWall(ctx.Event.GroupID); target == nil {
+		ctx.Send(message.Text("❌ 本群设置的墙已不存在，请联系管理员重新设置"))
+		return nil, text, false
+	}
+	return target, text, true
+}
+
+// groupOwnerRule 群主或机器人所有者
+func (b *QQBot) groupOwnerRule(ctx *zero.Ctx) bool {
+	return zero.OwnerPermission(ctx) || b.roleRule(model.RoleOwner)(ctx)
+}
+
+// allowlistRule 开启群白名单 (bot.group_allowlist) 时，能否投稿及投到哪面墙由机器人所有者决定，
+// 群主只能关闭投稿；未开启时不限制
+func (b *QQBot) allowlistRule(ctx *zero.Ctx) bool {
+	return !b.cfg.Bot.GroupAllowlist || b.roleRule(model.RoleOwner)(ctx)
+}
+
+// newGroupSetting 未登记群的新设置，墙取当前的默认路由。
+// 设置一经保存就按 WallID 路由，不能留 0，否则附加墙的审核群改任何设置后都会改投到默认墙
+func newGroupSetting(walls *wall.Registry, groupID int64, enabled bool) *model.GroupSetting {
+	return &model.GroupSetting{GroupID: groupID, Enabled: enabled, WallID: walls.ForGroup(nil, groupID).ID}
+}
+
+// loadGroupSetting 读取本群设置，未登记时按当前默认值新建 (不保存)
+func (b *QQBot) loadGroupSetting(ctx *zero.Ctx) (*model.GroupSetting, error) {
+	g, err := b.store.GetGroupSetting(ctx.Event.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	if g == nil {
+		g = newGroupSetting(b.walls, ctx.Event.GroupID, !b.cfg.Bot.GroupAllowlist)
+	}
+	if info := ctx.GetThisGroupInfo(false); info.Name != "" {
+		g.Name = info.Name
+	}
+	g.UpdatedBy = ctx.Event.UserID
+	g.UpdateTime = time.Now().Unix()
+	return g, nil
+}
+
+// handleGroupToggle 本群开启投稿 / 本群关闭投稿
+func (b *QQBot) handleGroupToggle(ctx *zero.Ctx, enabled bool) {
+	if enabled && !b.allowlistRule(ctx) {
+		ctx.Send(message.Text("❌ 已开启群白名单，需由机器人所有者开启本群投稿"))
+		return
+	}
+	g, err := b.loadGroupSetting(ctx)
+	if err != nil {
+		ctx.Send(message.Text("❌ 读取群设置失败: " + err.Error()))
+		return
+	}
+	g.Enabled = enabled
+	if err := b.store.SaveGroupSetting(g); err != nil {
+		ctx.Send(message.Text("❌ 保存失败: " + err.Error()))
+		return
+	}
+	log.Printf("[QQBot] QQ %d 将群 %d 投稿设为 %v", ctx.Event.UserID, g.GroupID, enabled)
+	if enabled {
+		ctx.Send(message.Text("✅ 本群已开启投稿，发送 /帮助 查看用法"))
+		return
+	}
+	ctx.Send(message.Text("🔒 本群已关闭投稿，群成员仍可私聊机器人投稿"))
+}
+
+// handleGroupSetting 本群设置: 不带参数时显示当前设置
+func (b *QQBot) handleGroupSetting(ctx *zero.Ctx) {
+	args := strings.Fields(getArgs(ctx))
+	g, err := b.loadGroupSetting(ctx)
+	if err != nil {
+		ctx.Send(message.Text("❌ 读取群设置失败: " + err.Error()))
+		return
+	}
+
+	if len(args) == 0 {
+		status := "已关闭"
+		if g.Enabled {
+			status = "已开启"
+		}
+		anon := model.GroupAnonLabel(g.Anon)
+		if g.Anon == model.GroupAnonFollow && b.cfg.Wall.AnonDefault {
+			anon += " (当前匿名)"
+		} else if g.Anon == model.GroupAnonFollow {
+			anon += " (当前实名)"
+		}
+		text := fmt.Sprintf("⚙️ 本群设置\n投稿: %s\n默认匿名: %s\n审核结果通知: %s", status, anon, model.GroupNotifyLabel(g.Notify))
+		if b.walls.Multi() {
+			text += "\n投稿墙: " + b.walls.Name(g.WallID)
+		}
+		ctx.Send(message.Text(text + "\n\n" + b.groupSettingUsage()))
+		return
+	}
+	if !b.groupOwnerRule(ctx) {
+		ctx.Send(message.Text("❌ 只有群主可以修改本群设置"))
+		return
+	}
+	if len(args) != 2 {
+		ctx.Send(message.Text(b.groupSettingUsage()))
+		return
+	}
+
+	switch args[0] {
+	case "匿名":
+		anon, ok := model.ParseGroupAnon(args[1])
+		if !ok {
+			ctx.Send(message.Text(b.groupSettingUsage()))
+			return
+		}
+		g.Anon = anon
+	case "通知":
+		notify, ok := model.ParseGroupNotify(args[1])
+		if !ok {
+			ctx.Send(message.Text(b.groupSettingUsage()))
+			return
+		}
+		g.Notify = notify
+	case "墙":
+		if !b.allowlistRule(ctx) {
+			ctx.Send(message.Text("❌ 已开启群白名单，需由机器人所有者设置本群的墙"))
+			return
+		}
+		target := b.walls.Find(args[1])
+		if !b.walls.Multi() || target == nil {
+			ctx.Send(message.Text(fmt.Sprintf("❌ 墙 %s 不存在，可选: %s", args[1], b.walls.Names())))
+			return
+		}
+		g.WallID = target.ID
+	default:
+		ctx.Send(message.Text(b.groupSettingUsage()))
+		return
+	}
+	if err := b.store.SaveGroupSetting(g); err != nil {
+		ctx.Send(message.Text("❌ 保存失败: " + err.Error()))
+		return
+	}
+	log.Printf("[QQBot] QQ %d 修改群 %d 设置: %s %s", ctx.Event.UserID, g.GroupID, args[0], args[1])
+	text := fmt.Sprintf("✅ 已保存\n默认匿名: %s\n审核结果通知: %s", model.GroupAnonLabel(g.Anon), model.GroupNotifyLabel(g.Notify))
+	if b.walls.Multi() {
+		text += "\n投稿墙: " + b.walls.Name(g.WallID)
+	}
+	ctx.Send(message.Text(text))
+}
+
+// groupSettingUsage /本群设置 用法，配置了附加墙时包含选择墙
+func (b *QQBot) groupSettingUsage() string {
+	if b.walls.Multi() {
+		return groupSettingUsage + groupWallUsage
+	}
+	return groupSettingUsage
+}
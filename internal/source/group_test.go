@@ -0,0 +1,37 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/config"
+	"github.com/guohuiyuan/qzonewall-go/internal/wall"
+)
+
+// TestNewGroupSettingKeepsWall 测试新建群设置保留原路由: 附加墙审核群改设置后仍投到该墙
+func TestNewGroupSettingKeepsWall(t *testing.T) {
+	cfg := &config.Config{
+		Bot:   config.BotConfig{ManageGroup: 100},
+		Walls: []config.WallEntry{{ID: 3, Name: "南区", ManageGroup: 300}},
+	}
+	walls, err := wall.NewRegistry(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		groupID int64
+		want    int64
+	}{
+		{"附加墙审核群", 300, 3},
+		{"默认墙审核群", 100, wall.DefaultID},
+		{"普通群", 500, wall.DefaultID},
+	}
+	for _, tt := range tests {
+		before := walls.ForGroup(nil, tt.groupID)
+		g := newGroupSetting(walls, tt.groupID, true)
+		after := walls.ForGroup(g, tt.groupID)
+		if before.ID != tt.want || after == nil || after.ID != tt.want {
+			t.Errorf("%s: 新建设置前墙 #%d, 新建后 %v, 期望 #%d", tt.name, before.ID, after, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package source
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+	"github.com/guohuiyuan/qzonewall-go/internal/store"
+
+	zero "github.com/wdvxdr1123/ZeroBot"
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+// minePageSize /我的投稿 每页条数
+const minePageSize = 5
+
+// handleMyPosts 我的投稿: /我的投稿 [页码]，列出自己的稿件状态、拒绝理由和说说链接。
+// 仅限私聊，群内回复会暴露匿名稿件的作者
+func (b *QQBot) handleMyPosts(ctx *zero.Ctx) {
+	page := 1
+	if args := getArgs(ctx); args != "" {
+		n, err := strconv.Atoi(args)
+		if err != nil || n < 1 {
+			ctx.Send(message.Text("用法: /我的投稿 [页码]"))
+			return
+		}
+		page = n
+	}
+
+	filter := store.PostFilter{UIN: ctx.Event.UserID}
+	total, err := b.store.CountPosts(filter)
+	if err != nil {
+		ctx.Send(message.Text("❌ 查询失败: " + err.Error()))
+		return
+	}
+	if total == 0 {
+		ctx.Send(message.Text("📭 你还没有投过稿"))
+		return
+	}
+	pages := (total + minePageSize - 1) / minePageSize
+	if page > pages {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 共 %d 页", pages)))
+		return
+	}
+	filter.Limit, filter.Offset = minePageSize, (page-1)*minePageSize
+	posts, err := b.store.ListPosts(filter)
+	if err != nil {
+		ctx.Send(message.Text("❌ 查询失败: " + err.Error()))
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "📋 我的投稿 (共 %d 条, 第 %d/%d 页)", total, page, pages)
+	for _, p := range posts {
+		fmt.Fprintf(&sb, "\n\n#%d [%s] %s", p.ID, p.Status.Label(), time.Unix(p.CreateTime, 0).Format("01-02 15:04"))
+		if preview := postPreview(p, 20); preview != "" {
+			sb.WriteString("\n" + preview)
+		}
+		b.writePostOutcome(&sb, p)
+	}
+	if page < pages {
+		fmt.Fprintf(&sb, "\n\n发送 /我的投稿 %d 查看下一页", page+1)
+	}
+	sb.WriteString("\n/查稿 <编号> 查看详情")
+	ctx.Send(message.Text(sb.String()))
+}
+
+// handleLookupPost 查稿: /查稿 <编号>，只能查看自己的稿件 (审核员可查看全部)
+func (b *QQBot) handleLookupPost(ctx *zero.Ctx) {
+	id, err := strconv.ParseInt(getArgs(ctx), 10, 64)
+	if err != nil {
+		ctx.Send(message.Text("用法: /查稿 <编号>"))
+		return
+	}
+	post, err := b.store.GetPost(id)
+	if err != nil || post == nil || (post.UIN != ctx.Event.UserID && !b.roleRule(model.RoleModerator)(ctx)) {
+		// 他人的稿件同样提示不存在，避免泄露稿件归属
+		ctx.Send(message.Text(fmt.Sprintf("❌ 稿件 #%d 不存在", id)))
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "📄 稿件 #%d\n状态: %s\n投稿时间: %s", post.ID, post.Status.Label(),
+		time.Unix(post.CreateTime, 0).Format("2006-01-02 15:04"))
+	if post.Anon {
+		sb.WriteString("\n匿名: 是")
+	}
+	if post.Type != model.TypeNormal {
+		sb.WriteString("\n类型: " + post.Type.Label())
+	}
+	if preview := postPreview(post, 100); preview != "" {
+		sb.WriteString("\n内容: " + preview)
+	}
+	if len(post.Images) > 0 {
+		fmt.Fprintf(&sb, "\n图片: %d 张", len(post.Images))
+	}
+	b.writePostOutcome(&sb, post)
+	if post.Status == model.StatusPending {
+		fmt.Fprintf(&sb, "\n\n可发送 /撤稿 %d 撤回", post.ID)
+	}
+	ctx.Send(message.Text(sb.String()))
+}
+
+// writePostOutcome 追加拒绝理由或已发布说说的链接
+func (b *QQBot) writePostOutcome(sb *strings.Builder, p *model.Post) {
+	if p.Status == model.StatusRejected && p.Reason != "" {
+		sb.WriteString("\n理由: " + p.Reason)
+	}
+	if target := b.walls.Get(p.WallID); p.Status == model.StatusPublished && target != nil && target.Client != nil {
+		if url := p.QzoneURL(target.Client.UIN()); url != "" {
+			sb.WriteString("\n链接: " + url)
+		}
+	}
+}
+
+// postPreview 稿件正文的单行预览，超过 n 个字符截断
+func postPreview(p *model.Post, n int) string {
+	text := p.Text
+	if p.Type.Structured() && p.Fields != nil {
+		text = strings.TrimSpace(p.Fields.Item + " " + text)
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	if r := []rune(text); len(r) > n {
+		text = string(r[:n]) + "..."
+	}
+	return text
+}
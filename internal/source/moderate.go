@@ -0,0 +1,130 @@
+package source
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+
+	zero "github.com/wdvxdr1123/ZeroBot"
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+// notifyManageGroup 向管理群发送稿件通知 (head 为通知正文，后附截图预览)，并记录通知消息与稿件的对应关系，
+// 管理员回复该消息 "过"、"拒 理由"、"删" 即可审核。通知发往稿件所属墙的审核群
+func (b *QQBot) notifyManageGroup(ctx *zero.Ctx, post *model.Post, head string) {
+	target := b.walls.Get(post.WallID)
+	if target == nil {
+		log.Printf("[QQBot] 稿件 #%d 所属的墙 #%d 不存在，不发送通知", post.ID, post.WallID)
+		return
+	}
+	manageGroup := target.ManageGroup()
+	if manageGroup <= 0 {
+		return
+	}
+	if b.walls.Multi() {
+		head = "[" + target.Name + "] " + head
+	}
+	segs := message.Message{message.Text(head + "\n回复本消息: 过 / 拒 理由 / 删")}
+	if target.Renderer.Available() {
+		// 待审稿件仍可能被修改或拒绝，预览不写入渲染缓存
+		if pages, err := target.Renderer.RenderPost(resolvePostImages(post)); err == nil {
+			for _, imgData := range pages {
+				segs = append(segs, message.Image("base64://"+base64.StdEncoding.EncodeToString(imgData)))
+			}
+		} else {
+			log.Printf("[QQBot] 稿件 #%d 通知预览渲染失败: %v", post.ID, err)
+		}
+	}
+
+	msgID := ctx.SendGroupMessage(manageGroup, segs)
+	if msgID == 0 {
+		return
+	}
+	if err := b.store.SaveNoticeMsg(msgID, post.ID); err != nil {
+		log.Printf("[QQBot] 记录稿件 #%d 通知消息失败: %v", post.ID, err)
+	}
+}
+
+// moderatable 发送者能否在当前会话审核稿件，不能时回复原因。
+// 稿件所属的墙已从配置删除时不能审核；多墙时只能在稿件所属墙的审核群中审核，私聊审核仅限所有者
+func (b *QQBot) moderatable(ctx *zero.Ctx, post *model.Post) bool {
+	target := b.walls.Get(post.WallID)
+	if target == nil {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 稿件 #%d 所属的墙 #%d 已不存在，无法审核", post.ID, post.WallID)))
+		return false
+	}
+	if !b.walls.Multi() {
+		return true
+	}
+	if ctx.Event.GroupID == 0 {
+		if b.roleRule(model.RoleOwner)(ctx) {
+			return true
+		}
+	} else if target.ManageGroup() == ctx.Event.GroupID {
+		return true
+	}
+	ctx.Send(message.Text(fmt.Sprintf("❌ 稿件 #%d 属于 %s，请在该墙的审核群中处理", post.ID, target.Name)))
+	return false
+}
+
+// noticeActions 回复通知消息可用的审核操作
+var noticeActions = map[string]bool{"过": true, "拒": true, "删": true}
+
+// noticeReplyRule 匹配管理群中回复新投稿通知的审核消息，
+// 操作存入 ctx.State["notice_action"]，稿件ID存入 ctx.State["notice_post"]，其余文字存入 ctx.State["args"]
+func (b *QQBot) noticeReplyRule(ctx *zero.Ctx) bool {
+	if len(b.walls.ManagedBy(ctx.Event.GroupID)) == 0 {
+		return false
+	}
+	msg := ctx.Event.Message
+	if len(msg) == 0 || msg[0].Type != "reply" {
+		return false
+	}
+	i := leadingText(msg)
+	if i < 0 {
+		return false
+	}
+	fields := strings.Fields(msg[i:].ExtractPlainText())
+	if len(fields) == 0 {
+		return false
+	}
+	action, rest := fields[0], strings.Join(fields[1:], " ")
+	if !noticeActions[action] {
+		return false
+	}
+
+	replyID, err := strconv.ParseInt(msg[0].Data["id"], 10, 64)
+	if err != nil {
+		return false
+	}
+	postID, err := b.store.GetNoticePost(replyID)
+	if err != nil || postID == 0 {
+		return false
+	}
+	ctx.State["notice_action"] = action
+	ctx.State["notice_post"] = postID
+	ctx.State["args"] = rest
+	return true
+}
+
+// handleNoticeReply 按回复内容对通知对应的稿件执行过稿 / 拒稿 / 删除，复用对应命令的处理逻辑
+func (b *QQBot) handleNoticeReply(ctx *zero.Ctx) {
+	action, _ := ctx.State["notice_action"].(string)
+	postID, _ := ctx.State["notice_post"].(int64)
+	id := strconv.FormatInt(postID, 10)
+	switch action {
+	case "过":
+		ctx.State["args"] = id
+		b.handleApprove(ctx)
+	case "拒":
+		ctx.State["args"] = strings.TrimSpace(id + " " + getArgs(ctx))
+		b.handleReject(ctx)
+	case "删":
+		ctx.State["args"] = id
+		b.handleRecall(ctx)
+	}
+}
@@ -3,6 +3,7 @@ package source
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -12,10 +13,11 @@ import (
 	"time"
 
 	qzone "github.com/guohuiyuan/qzone-go"
+	"github.com/guohuiyuan/qzonewall-go/internal/caption"
 	"github.com/guohuiyuan/qzonewall-go/internal/config"
 	"github.com/guohuiyuan/qzonewall-go/internal/model"
-	"github.com/guohuiyuan/qzonewall-go/internal/render"
 	"github.com/guohuiyuan/qzonewall-go/internal/store"
+	"github.com/guohuiyuan/qzonewall-go/internal/wall"
 
 	zero "github.com/wdvxdr1123/ZeroBot"
 	"github.com/wdvxdr1123/ZeroBot/driver"
@@ -26,39 +28,40 @@ import (
 type QQBot struct {
 	cfg         *config.Config
 	store       *store.Store
-	renderer    *render.Renderer
-	qzClient    *qzone.Client
+	walls       *wall.Registry
+	captions    *caption.Captions
 	censorWords []string
 	engine      *zero.Engine
+
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // NewQQBot 创建 QQ 机器人
 func NewQQBot(
 	cfg *config.Config,
 	st *store.Store,
-	renderer *render.Renderer,
-	qzClient *qzone.Client,
+	walls *wall.Registry,
+	captions *caption.Captions,
 	censorWords []string,
 ) *QQBot {
 	return &QQBot{
 		cfg:         cfg,
 		store:       st,
-		renderer:    renderer,
-		qzClient:    qzClient,
+		walls:       walls,
+		captions:    captions,
 		censorWords: censorWords,
 	}
 }
 
-// SetClient 设置/替换 QQ空间客户端 (用于延迟初始化)
-func (b *QQBot) SetClient(client *qzone.Client) {
-	b.qzClient = client
-}
-
 // Start 启动 ZeroBot 并注册命令
 func (b *QQBot) Start() error {
 	b.engine = zero.New()
 	b.registerCommands()
 
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	go b.runDraftJanitor()
+
 	drivers := make([]zero.Driver, 0, len(b.cfg.Bot.WS))
 	for _, ws := range b.cfg.Bot.WS {
 		drivers = append(drivers, driver.NewWebSocketClient(ws.Url, ws.AccessToken))
@@ -83,6 +86,9 @@ func (b *QQBot) Start() error {
 
 // Stop 停止
 func (b *QQBot) Stop() {
+	if b.cancel != nil {
+		b.cancel()
+	}
 	log.Println("[QQBot] 停止")
 }
 
@@ -91,39 +97,142 @@ func (b *QQBot) Stop() {
 // ──────────────────────────────────────────
 
 func (b *QQBot) registerCommands() {
+	// 被封禁的QQ号与群: 所有命令和草稿消息都在匹配后拦截
+	b.engine.UseMidHandler(b.banFilter)
+
 	// ── 用户命令 ──
 	b.engine.OnCommand("投稿").Handle(func(ctx *zero.Ctx) {
-		b.handleContribute(ctx, false)
+		b.handleContribute(ctx, b.defaultAnon(ctx))
 	})
 	b.engine.OnCommand("匿名投稿").Handle(func(ctx *zero.Ctx) {
 		b.handleContribute(ctx, true)
 	})
+	b.engine.OnMessage(replyCommandRule("投稿")).Handle(func(ctx *zero.Ctx) {
+		b.handleReplyContribute(ctx, b.defaultAnon(ctx))
+	})
+	b.engine.OnMessage(replyCommandRule("匿名投稿")).Handle(func(ctx *zero.Ctx) {
+		b.handleReplyContribute(ctx, true)
+	})
+	b.engine.OnCommand("聊天投稿").Handle(func(ctx *zero.Ctx) {
+		b.handleChatContribute(ctx, b.defaultAnon(ctx))
+	})
+	b.engine.OnCommand("匿名聊天投稿").Handle(func(ctx *zero.Ctx) {
+		b.handleChatContribute(ctx, true)
+	})
+	b.engine.OnCommand("寻物").Handle(func(ctx *zero.Ctx) {
+		b.handleTypedContribute(ctx, model.TypeLost)
+	})
+	b.engine.OnCommand("招领").Handle(func(ctx *zero.Ctx) {
+		b.handleTypedContribute(ctx, model.TypeFound)
+	})
+	b.engine.OnCommand("二手").Handle(func(ctx *zero.Ctx) {
+		b.handleTypedContribute(ctx, model.TypeMarket)
+	})
+	b.engine.OnCommand("发起投票").Handle(func(ctx *zero.Ctx) {
+		b.handlePollContribute(ctx)
+	})
+	b.engine.OnCommand("投票").Handle(func(ctx *zero.Ctx) {
+		b.handleVote(ctx)
+	})
 	b.engine.OnCommand("撤稿").Handle(func(ctx *zero.Ctx) {
 		b.handleRecall(ctx)
 	})
+	b.engine.OnCommand("改稿").Handle(func(ctx *zero.Ctx) {
+		b.handleEditPost(ctx)
+	})
+	b.engine.OnCommand("我的投稿", zero.OnlyPrivate).Handle(func(ctx *zero.Ctx) {
+		b.handleMyPosts(ctx)
+	})
+	b.engine.OnCommand("查稿", zero.OnlyPrivate).Handle(func(ctx *zero.Ctx) {
+		b.handleLookupPost(ctx)
+	})
+	b.engine.OnCommand("回复", zero.OnlyPrivate).Handle(func(ctx *zero.Ctx) {
+		b.handleRelay(ctx)
+	})
+	b.engine.OnMessage(replyCommandRule("回复"), zero.OnlyPrivate).Handle(func(ctx *zero.Ctx) {
+		b.handleRelay(ctx)
+	})
+	b.engine.OnCommand("屏蔽", zero.OnlyPrivate).Handle(func(ctx *zero.Ctx) {
+		b.handleRelayBlock(ctx, true)
+	})
+	b.engine.OnCommand("解除屏蔽", zero.OnlyPrivate).Handle(func(ctx *zero.Ctx) {
+		b.handleRelayBlock(ctx, false)
+	})
+	b.engine.OnCommand("关闭回复", zero.OnlyPrivate).Handle(func(ctx *zero.Ctx) {
+		b.handleRelayOptOut(ctx, true)
+	})
+	b.engine.OnCommand("开启回复", zero.OnlyPrivate).Handle(func(ctx *zero.Ctx) {
+		b.handleRelayOptOut(ctx, false)
+	})
+	b.engine.OnCommand("预览").Handle(func(ctx *zero.Ctx) {
+		b.handleDraftPreview(ctx)
+	})
+	b.engine.OnCommand("完成").Handle(func(ctx *zero.Ctx) {
+		b.handleDraftFinish(ctx)
+	})
+	b.engine.OnCommand("取消").Handle(func(ctx *zero.Ctx) {
+		b.handleDraftCancel(ctx)
+	})
+	// 草稿追加匹配任意非命令消息，放在最后，回复通知审核等拦截型处理优先
+	b.engine.OnMessage(b.draftRule).SetPriority(10).Handle(func(ctx *zero.Ctx) {
+		b.handleDraftAppend(ctx)
+	})
 
-	// ── 管理员命令 ──
-	b.engine.OnCommand("看稿", zero.SuperUserPermission).SetBlock(true).Handle(func(ctx *zero.Ctx) {
+	// ── 管理命令 (按角色授权，超级用户为所有者) ──
+	b.engine.OnCommand("看稿", b.roleRule(model.RoleModerator)).SetBlock(true).Handle(func(ctx *zero.Ctx) {
 		b.handleViewPost(ctx)
 	})
-	b.engine.OnCommand("过稿", zero.SuperUserPermission).SetBlock(true).Handle(func(ctx *zero.Ctx) {
+	b.engine.OnCommand("过稿", b.roleRule(model.RoleModerator)).SetBlock(true).Handle(func(ctx *zero.Ctx) {
 		b.handleApprove(ctx)
 	})
-	b.engine.OnCommand("拒稿", zero.SuperUserPermission).SetBlock(true).Handle(func(ctx *zero.Ctx) {
+	b.engine.OnCommand("拒稿", b.roleRule(model.RoleModerator)).SetBlock(true).Handle(func(ctx *zero.Ctx) {
 		b.handleReject(ctx)
 	})
-	b.engine.OnCommand("待审核", zero.SuperUserPermission).SetBlock(true).Handle(func(ctx *zero.Ctx) {
+	b.engine.OnCommand("打码", b.roleRule(model.RoleModerator)).SetBlock(true).Handle(func(ctx *zero.Ctx) {
+		b.handleMarkCW(ctx)
+	})
+	b.engine.OnCommand("取消打码", b.roleRule(model.RoleModerator)).SetBlock(true).Handle(func(ctx *zero.Ctx) {
+		b.handleClearCW(ctx)
+	})
+	b.engine.OnCommand("结束投票", b.roleRule(model.RoleModerator)).SetBlock(true).Handle(func(ctx *zero.Ctx) {
+		b.handleClosePoll(ctx)
+	})
+	b.engine.OnCommand("拉黑", b.roleRule(model.RoleModerator)).SetBlock(true).Handle(func(ctx *zero.Ctx) {
+		b.handleBan(ctx)
+	})
+	b.engine.OnCommand("解封", b.roleRule(model.RoleModerator)).SetBlock(true).Handle(func(ctx *zero.Ctx) {
+		b.handleUnban(ctx)
+	})
+	b.engine.OnCommand("转达记录", zero.OnlyPrivate, b.roleRule(model.RoleOwner)).SetBlock(true).Handle(func(ctx *zero.Ctx) {
+		b.handleRelayLog(ctx)
+	})
+	b.engine.OnMessage(b.noticeReplyRule, b.roleRule(model.RoleModerator)).SetBlock(true).Handle(func(ctx *zero.Ctx) {
+		b.handleNoticeReply(ctx)
+	})
+	b.engine.OnCommand("待审核", b.roleRule(model.RoleViewer)).SetBlock(true).Handle(func(ctx *zero.Ctx) {
 		b.handleListPending(ctx)
 	})
-	b.engine.OnCommand("发说说", zero.SuperUserPermission).SetBlock(true).Handle(func(ctx *zero.Ctx) {
+	b.engine.OnCommand("发说说", b.roleRule(model.RoleOwner)).SetBlock(true).Handle(func(ctx *zero.Ctx) {
 		b.handleDirectPublish(ctx)
 	})
-	b.engine.OnCommand("扫码", zero.SuperUserPermission).SetBlock(true).Handle(func(ctx *zero.Ctx) {
+	b.engine.OnCommand("扫码", b.roleRule(model.RoleOwner)).SetBlock(true).Handle(func(ctx *zero.Ctx) {
 		b.handleScanQR(ctx)
 	})
-	b.engine.OnCommand("刷新cookie", zero.SuperUserPermission).SetBlock(true).Handle(func(ctx *zero.Ctx) {
+	b.engine.OnCommand("刷新cookie", b.roleRule(model.RoleOwner)).SetBlock(true).Handle(func(ctx *zero.Ctx) {
 		b.handleRefreshCookie(ctx)
 	})
+	b.engine.OnCommand("授权", b.roleRule(model.RoleOwner)).SetBlock(true).Handle(func(ctx *zero.Ctx) {
+		b.handleGrant(ctx)
+	})
+	b.engine.OnCommand("本群开启投稿", zero.OnlyGroup, b.groupOwnerRule).SetBlock(true).Handle(func(ctx *zero.Ctx) {
+		b.handleGroupToggle(ctx, true)
+	})
+	b.engine.OnCommand("本群关闭投稿", zero.OnlyGroup, b.groupOwnerRule).SetBlock(true).Handle(func(ctx *zero.Ctx) {
+		b.handleGroupToggle(ctx, false)
+	})
+	b.engine.OnCommand("本群设置", zero.OnlyGroup).SetBlock(true).Handle(func(ctx *zero.Ctx) {
+		b.handleGroupSetting(ctx)
+	})
 	b.engine.OnCommandGroup([]string{"帮助", "help"}).SetBlock(true).Handle(func(ctx *zero.Ctx) {
 		b.handleHelp(ctx)
 	})
@@ -133,53 +242,258 @@ func (b *QQBot) registerCommands() {
 // 命令处理逻辑
 // ──────────────────────────────────────────
 
-// handleContribute 投稿 / 匿名投稿
+// handleContribute 投稿 / 匿名投稿，不带任何内容时开启多条消息草稿
 func (b *QQBot) handleContribute(ctx *zero.Ctx, anon bool) {
-	rawText := getArgs(ctx)
-	text := strings.TrimSpace(rawText)
-	images := extractImages(ctx)
+	target, args, ok := b.pickWall(ctx, getArgs(ctx))
+	if !ok {
+		return
+	}
+	if args == "" && len(extractImages(ctx)) == 0 {
+		b.openDraft(ctx, anon, target)
+		return
+	}
+	cw, cwImages, text := model.ParseCW(args)
+	b.submitPost(ctx, &model.Post{
+		Text:     strings.TrimSpace(text),
+		Images:   extractImages(ctx),
+		Anon:     anon,
+		CW:       cw,
+		CWImages: cwImages,
+		WallID:   target.ID,
+	})
+}
 
-	if text == "" && len(images) == 0 {
-		ctx.Send(message.Text("❌ 投稿内容不能为空，请发送文字或图片"))
+// handleChatContribute 聊天记录投稿: 参数为 "A: 内容" 格式的多行文本，或附带合并转发消息
+func (b *QQBot) handleChatContribute(ctx *zero.Ctx, anon bool) {
+	target, args, ok := b.pickWall(ctx, getArgs(ctx))
+	if !ok {
+		return
+	}
+	var text string
+	var images []string
+	if id := extractForwardID(ctx); id != "" {
+		text, images = forwardToChatLog(ctx, id)
+		if text == "" {
+			ctx.Send(message.Text("❌ 无法读取合并转发的聊天记录"))
+			return
+		}
+	} else {
+		lines := model.ParseChatLog(args)
+		if len(lines) == 0 {
+			ctx.Send(message.Text("用法: /聊天投稿 后接合并转发消息，或每行一条 \"昵称: 内容\""))
+			return
+		}
+		text = model.FormatChatLog(lines)
+		images = extractImages(ctx)
+	}
+	b.submitPost(ctx, &model.Post{
+		Text:   text,
+		Images: images,
+		Anon:   anon,
+		Type:   model.TypeChat,
+		WallID: target.ID,
+	})
+}
+
+// handleTypedContribute 寻物/招领/二手投稿: 参数为 "物品: xxx" 形式的表单，缺少物品时回复填写模板
+func (b *QQBot) handleTypedContribute(ctx *zero.Ctx, t model.PostType) {
+	target, args, ok := b.pickWall(ctx, getArgs(ctx))
+	if !ok {
+		return
+	}
+	fields, text := model.ParseFields(args)
+	if fields.Item == "" {
+		cmd := map[model.PostType]string{model.TypeLost: "寻物", model.TypeFound: "招领", model.TypeMarket: "二手"}[t]
+		ctx.Send(message.Text(fmt.Sprintf("📝 %s投稿请按以下格式发送 (可附带图片):\n/%s\n%s", t.Label(), cmd, model.FieldsForm(t))))
 		return
 	}
+	b.submitPost(ctx, &model.Post{
+		Text:   text,
+		Images: extractImages(ctx),
+		Type:   t,
+		Fields: &fields,
+		WallID: target.ID,
+	})
+}
+
+// handlePollContribute 发起投票: 首行为问题，其后每行一个选项
+func (b *QQBot) handlePollContribute(ctx *zero.Ctx) {
+	target, args, ok := b.pickWall(ctx, getArgs(ctx))
+	if !ok {
+		return
+	}
+	question, poll, err := model.ParsePoll(args, time.Now())
+	if err != nil {
+		ctx.Send(message.Text(fmt.Sprintf("❌ %v\n用法:\n/发起投票 问题\n选项1\n选项2\n截止: 24h (可选)", err)))
+		return
+	}
+	b.submitPost(ctx, &model.Post{
+		Text:   question,
+		Images: extractImages(ctx),
+		Type:   model.TypePoll,
+		Poll:   poll,
+		WallID: target.ID,
+	})
+}
+
+// handleVote 投票: /投票 <编号> <选项>，选项可写序号或字母。
+// 被封禁的QQ号或群同其他命令一样由 banFilter 拦截
+func (b *QQBot) handleVote(ctx *zero.Ctx) {
+	parts := strings.Fields(getArgs(ctx))
+	if len(parts) != 2 {
+		ctx.Send(message.Text("用法: /投票 <编号> <选项序号>"))
+		return
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(parts[0], "#"), 10, 64)
+	if err != nil {
+		ctx.Send(message.Text("❌ 编号格式不正确"))
+		return
+	}
+	post, err := b.store.GetPost(id)
+	if err != nil || post == nil || post.Poll == nil || post.Status != model.StatusPublished {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 投票 #%d 不存在或尚未发布", id)))
+		return
+	}
+	if !post.Poll.Open(time.Now()) {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 投票 #%d 已结束", id)))
+		return
+	}
+	opt, ok := parsePollOption(parts[1], len(post.Poll.Options))
+	if !ok {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 选项无效，请输入 1~%d", len(post.Poll.Options))))
+		return
+	}
+
+	var sb strings.Builder
+	err = b.store.CastVote(post.ID, fmt.Sprintf("qq:%d", ctx.Event.UserID), opt)
+	switch {
+	case errors.Is(err, store.ErrAlreadyVoted):
+		sb.WriteString("❌ 你已经投过票了")
+	case err != nil:
+		ctx.Send(message.Text("❌ 投票失败: " + err.Error()))
+		return
+	default:
+		fmt.Fprintf(&sb, "✅ 已投给 %d. %s", opt+1, post.Poll.Options[opt])
+	}
+	b.writePollCounts(ctx, &sb, post)
+	ctx.Send(message.Text(sb.String()))
+}
+
+// writePollCounts 追加当前票数。同网页投票，票数只给已投票的本人看，
+// 群聊中不公布以免影响其他人投票
+func (b *QQBot) writePollCounts(ctx *zero.Ctx, sb *strings.Builder, post *model.Post) {
+	if ctx.Event.GroupID != 0 {
+		fmt.Fprintf(sb, "\n私聊机器人发送 /投票 %d <选项> 可查看当前票数", post.ID)
+		return
+	}
+	counts, err := b.store.PollCounts(post.ID, len(post.Poll.Options))
+	if err != nil {
+		return
+	}
+	sb.WriteString("\n当前票数:")
+	for i, c := range counts {
+		fmt.Fprintf(sb, "\n%d. %s — %d 票", i+1, post.Poll.Options[i], c)
+	}
+}
+
+// handleClosePoll 提前结束投票，结果由 Worker 在下一轮发布
+func (b *QQBot) handleClosePoll(ctx *zero.Ctx) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(getArgs(ctx), "#"), 10, 64)
+	if err != nil {
+		ctx.Send(message.Text("用法: /结束投票 <编号>"))
+		return
+	}
+	post, err := b.store.GetPost(id)
+	if err != nil || post == nil || post.Poll == nil {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 投票 #%d 不存在", id)))
+		return
+	}
+	if post.Status != model.StatusPublished {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 投票 #%d 尚未发布，不能结束", id)))
+		return
+	}
+	if !b.moderatable(ctx, post) {
+		return
+	}
+	now := time.Now()
+	if !post.Poll.Open(now) {
+		ctx.Send(message.Text(fmt.Sprintf("投票 #%d 已结束", id)))
+		return
+	}
+	post.Poll.CloseTime = now.Unix()
+	if err := b.store.SavePoll(post.ID, post.Poll); err != nil {
+		ctx.Send(message.Text("❌ 保存失败: " + err.Error()))
+		return
+	}
+	ctx.Send(message.Text(fmt.Sprintf("✅ 投票 #%d 已结束，结果将稍后发布到空间", id)))
+}
+
+// parsePollOption 解析从1开始的序号或字母选项，返回从0开始的下标
+func parsePollOption(s string, n int) (int, bool) {
+	if i, err := strconv.Atoi(s); err == nil {
+		return i - 1, i >= 1 && i <= n
+	}
+	if len(s) == 1 {
+		c := s[0] | 0x20 // 转小写
+		if i := int(c - 'a'); c >= 'a' && i < n {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// submitPost 校验并保存投稿，回复投稿人并通知管理群；返回是否已保存
+func (b *QQBot) submitPost(ctx *zero.Ctx, post *model.Post) bool {
+	text, images := post.Text, post.Images
+	if text == "" && len(images) == 0 && post.Fields == nil {
+		ctx.Send(message.Text("❌ 投稿内容不能为空，请发送文字或图片"))
+		return false
+	}
+	if !b.checkGroupEnabled(ctx) {
+		return false
+	}
+	if b.walls.Get(post.WallID) == nil {
+		// 草稿开始后墙被删除
+		ctx.Send(message.Text("❌ 投稿的墙已不存在，请重新投稿"))
+		return false
+	}
 	if b.cfg.Wall.MaxTextLen > 0 && len([]rune(text)) > b.cfg.Wall.MaxTextLen {
 		ctx.Send(message.Text(fmt.Sprintf("❌ 文字超出限制 (%d/%d)", len([]rune(text)), b.cfg.Wall.MaxTextLen)))
-		return
+		return false
 	}
 	if b.cfg.Wall.MaxImages > 0 && len(images) > b.cfg.Wall.MaxImages {
 		ctx.Send(message.Text(fmt.Sprintf("❌ 图片超出限制 (%d/%d)", len(images), b.cfg.Wall.MaxImages)))
-		return
+		return false
 	}
 
 	if len(b.censorWords) > 0 {
-		if hit, word := store.CheckCensor(text, b.censorWords); hit {
+		censorText := text
+		if post.Fields != nil {
+			censorText += "\n" + post.Fields.String()
+		}
+		if post.Poll != nil {
+			censorText += "\n" + strings.Join(post.Poll.Options, "\n")
+		}
+		if hit, word := store.CheckCensor(censorText, b.censorWords); hit {
 			ctx.Send(message.Text(fmt.Sprintf("❌ 投稿包含违禁词: %s", word)))
-			return
+			return false
 		}
 	}
 
-	post := &model.Post{
-		UIN:        ctx.Event.UserID,
-		Name:       ctx.Event.Sender.NickName,
-		GroupID:    ctx.Event.GroupID,
-		Text:       text,
-		Images:     images,
-		Anon:       anon,
-		Status:     model.StatusPending,
-		CreateTime: time.Now().Unix(),
-	}
+	post.UIN = ctx.Event.UserID
+	post.Name = ctx.Event.Sender.NickName
+	post.GroupID = ctx.Event.GroupID
+	post.Status = model.StatusPending
+	post.CreateTime = time.Now().Unix()
 	if err := b.store.SavePost(post); err != nil {
 		ctx.Send(message.Text("❌ 保存失败: " + err.Error()))
-		return
+		return false
 	}
 
 	ctx.Send(message.Text(fmt.Sprintf("✅ 投稿成功！编号 #%d，等待审核...", post.ID)))
 
-	if b.cfg.Bot.ManageGroup > 0 {
-		notifyMsg := fmt.Sprintf("📬 收到新投稿 #%d\n%s", post.ID, post.Summary())
-		ctx.SendGroupMessage(b.cfg.Bot.ManageGroup, message.Text(notifyMsg))
-	}
+	b.notifyManageGroup(ctx, post, fmt.Sprintf("📬 收到新投稿 #%d\n%s", post.ID, post.Summary()))
+	return true
 }
 
 // handleRecall 撤稿
@@ -199,9 +513,14 @@ func (b *QQBot) handleRecall(ctx *zero.Ctx) {
 		ctx.Send(message.Text(fmt.Sprintf("❌ 稿件 #%d 不存在", id)))
 		return
 	}
-	if post.UIN != ctx.Event.UserID && !zero.SuperUserPermission(ctx) {
-		ctx.Send(message.Text("❌ 你只能撤回自己的稿件"))
-		return
+	if post.UIN != ctx.Event.UserID {
+		if !b.roleRule(model.RoleModerator)(ctx) {
+			ctx.Send(message.Text("❌ 你只能撤回自己的稿件"))
+			return
+		}
+		if !b.moderatable(ctx, post) {
+			return
+		}
 	}
 	if post.Status == model.StatusPublished {
 		ctx.Send(message.Text("❌ 已发布的稿件无法撤回"))
@@ -232,13 +551,22 @@ func (b *QQBot) handleViewPost(ctx *zero.Ctx) {
 		ctx.Send(message.Text(fmt.Sprintf("❌ 稿件 #%d 不存在", id)))
 		return
 	}
+	if !b.moderatable(ctx, post) {
+		return
+	}
+	if note := b.revisionNote(post); note != "" {
+		ctx.Send(message.Text(note))
+	}
 
-	if b.renderer.Available() {
-		// 解析图片地址后再渲染
-		renderPost := resolvePostImages(post)
-		if imgData, err := b.renderer.RenderPost(renderPost); err == nil {
-			b64 := base64.StdEncoding.EncodeToString(imgData)
-			ctx.Send(message.Image("base64://" + b64))
+	if renderer := b.walls.Get(post.WallID).Renderer; renderer.Available() {
+		// 解析图片地址后再渲染 (命中缓存时跳过解析)
+		if pages, err := renderer.RenderPostCached(post, resolvePostImages); err == nil {
+			var segs message.Message
+			for _, imgData := range pages {
+				b64 := base64.StdEncoding.EncodeToString(imgData)
+				segs = append(segs, message.Image("base64://"+b64))
+			}
+			ctx.Send(segs)
 			return
 		} else {
 			ctx.Send(message.Text("❌ 渲染失败: " + err.Error()))
@@ -270,11 +598,21 @@ func (b *QQBot) handleApprove(ctx *zero.Ctx) {
 		return
 	}
 
+	// 先按状态条件标记为已发布，同时被修改或审核的稿件跳过；
+	// 标记后稿件不能再修改，按重新读取的内容渲染发布
 	var validPosts []*model.Post
 	for _, p := range posts {
-		if p.Status == model.StatusPending {
-			validPosts = append(validPosts, p)
+		if p.Status != model.StatusPending || !b.moderatable(ctx, p) {
+			continue
 		}
+		claimed, err := b.store.ClaimPost(p.ID, model.StatusPending, model.StatusPublished)
+		if err != nil {
+			if !errors.Is(err, store.ErrStatusChanged) {
+				log.Printf("标记稿件 #%d 失败: %v", p.ID, err)
+			}
+			continue
+		}
+		validPosts = append(validPosts, claimed)
 	}
 
 	if len(validPosts) == 0 {
@@ -284,49 +622,64 @@ func (b *QQBot) handleApprove(ctx *zero.Ctx) {
 
 	ctx.Send(message.Text(fmt.Sprintf("⏳ 正在处理 %d 条稿件，合并发布中...", len(validPosts))))
 
-	var summaryBuilder strings.Builder
-	fmt.Fprintf(&summaryBuilder, "【表白墙更新】 %s\n", time.Now().Format("01/02"))
-	summaryBuilder.WriteString("----------------\n")
+	// 不同墙的稿件分别合并发布到各自的QQ空间
+	for _, posts := range wall.GroupPosts(validPosts) {
+		b.publishBatch(ctx, b.walls.Get(posts[0].WallID), posts)
+	}
+}
+
+// publishBatch 将同一面墙的稿件合并为一条说说发布到该墙的QQ空间
+func (b *QQBot) publishBatch(ctx *zero.Ctx, target *wall.Wall, validPosts []*model.Post) {
+	// 同一批次内附原图时复用渲染时已下载的图片
+	renderer := target.Renderer.Session()
 
 	// 收集图片数据
 	var imagesData [][]byte
+	var published []*model.Post
+
+	// 合集模式: 多条稿件合并渲染为长图，减少说说中的图片数量
+	digest := b.cfg.Wall.BatchDigest && len(validPosts) > 1
+	if digest {
+		resolved := make([]*model.Post, len(validPosts))
+		for i, p := range validPosts {
+			resolved[i] = resolvePostImages(p)
+		}
+		pages, err := renderer.RenderDigest(resolved)
+		if err != nil {
+			ctx.Send(message.Text("❌ 合集渲染失败: " + err.Error()))
+			b.releasePosts(validPosts...)
+			return
+		}
+		imagesData = pages
+	}
 
 	for _, post := range validPosts {
 		// A. 渲染图片
-		var imgData []byte
-		var renderErr error
-
-		if b.renderer.Available() {
-			// 解析图片地址后再渲染
-			renderPost := resolvePostImages(post)
-			imgData, renderErr = b.renderer.RenderPost(renderPost)
-		}
-
-		if renderErr != nil || imgData == nil {
-			log.Printf("渲染失败 #%d: %v", post.ID, renderErr)
-			ctx.Send(message.Text(fmt.Sprintf("❌ 稿件 #%d 渲染失败，跳过", post.ID)))
-			continue
-		}
+		if !digest {
+			var pages [][]byte
+			var renderErr error
 
-		imagesData = append(imagesData, imgData)
+			if renderer.Available() {
+				// 复用 /看稿 时的渲染结果，未命中时解析图片地址后再渲染
+				pages, renderErr = renderer.RenderPostCached(post, resolvePostImages)
+			}
 
-		// B. 拼接摘要
-		content := []rune(post.Text)
-		if len(content) > 20 {
-			fmt.Fprintf(&summaryBuilder, "#%d: %s...\n", post.ID, string(content[:20]))
-		} else {
-			if post.Text == "" {
-				fmt.Fprintf(&summaryBuilder, "#%d: [图片]\n", post.ID)
-			} else {
-				fmt.Fprintf(&summaryBuilder, "#%d: %s\n", post.ID, post.Text)
+			if renderErr != nil || len(pages) == 0 {
+				log.Printf("渲染失败 #%d: %v", post.ID, renderErr)
+				ctx.Send(message.Text(fmt.Sprintf("❌ 稿件 #%d 渲染失败，跳过", post.ID)))
+				b.releasePosts(post)
+				continue
 			}
-		}
 
-		// C. 标记为已发布
-		post.Status = model.StatusPublished
-		if err := b.store.SavePost(post); err != nil {
-			log.Printf("保存稿件状态失败 #%d: %v", post.ID, err)
+			// 长稿件分页后按顺序全部上传
+			imagesData = append(imagesData, pages...)
+		}
+		if wc := b.cfg.Wall; (wc.CWOriginal && post.CW != "") || (wc.GIFOriginal && len(post.Images) > 0) {
+			imagesData = append(imagesData, renderer.OriginalImages(resolvePostImages(post), wc.CWOriginal, wc.GIFOriginal)...)
 		}
+
+		// B. 记录参与摘要的稿件 (已在 handleApprove 中标记为已发布)
+		published = append(published, post)
 	}
 
 	if len(imagesData) == 0 {
@@ -334,36 +687,40 @@ func (b *QQBot) handleApprove(ctx *zero.Ctx) {
 		return
 	}
 
-	summaryBuilder.WriteString("----------------\n")
-	summaryBuilder.WriteString("详情见图 👇")
-	finalText := summaryBuilder.String()
+	finalText, err := b.captions.Batch(published)
+	if err != nil {
+		log.Printf("生成说说文案失败: %v", err)
+		ctx.Send(message.Text("❌ 说说文案模板错误: " + err.Error()))
+		b.releasePosts(published...)
+		return
+	}
 
 	go func() {
-		// 修正：直接使用 ImageBytes 字段，让 qzone 库处理上传逻辑
-		opts := &qzone.PublishOption{
-			ImageBytes: imagesData,
-		}
-
-		// 调用发布接口
-		_, publishErr := b.qzClient.Publish(context.Background(), finalText, opts)
+		// 图片超过单条说说上限时拆成多条发布
+		_, parts, publishErr := target.Publish(context.Background(), finalText, imagesData)
 
-		if publishErr != nil {
+		if parts == 0 {
 			log.Printf("发布说说失败: %v", publishErr)
 			ctx.Send(message.Text("❌ 发布到空间失败: " + publishErr.Error()))
 
 			// 失败回滚
-			for _, p := range validPosts {
-				p.Status = model.StatusPending
-				if err := b.store.SavePost(p); err != nil {
-					log.Printf("回滚稿件状态失败 #%d: %v", p.ID, err)
-				}
-			}
+			b.releasePosts(published...)
 			return
 		}
 
 		// 发布成功：群内反馈
 		var msgSegments message.Message
-		msgSegments = append(msgSegments, message.Text("✅ 批量过稿成功！已发布到空间：\n"+finalText))
+		head := "✅ 批量过稿成功！已发布到空间：\n"
+		if b.walls.Multi() {
+			head = fmt.Sprintf("✅ 批量过稿成功！已发布到 %s 的空间：\n", target.Name)
+		}
+		msgSegments = append(msgSegments, message.Text(head+finalText))
+		if publishErr != nil {
+			log.Printf("续发说说失败 (已发布 %d 条): %v", parts, publishErr)
+			msgSegments = append(msgSegments, message.Text(fmt.Sprintf("\n⚠️ 图片共 %d 张，超出每条说说 %d 张的上限，仅发布了前 %d 条说说: %v", len(imagesData), wall.MaxImages, parts, publishErr)))
+		} else if parts > 1 {
+			msgSegments = append(msgSegments, message.Text(fmt.Sprintf("\n📎 图片共 %d 张，超出每条说说 %d 张的上限，已拆分为 %d 条说说", len(imagesData), wall.MaxImages, parts)))
+		}
 
 		for _, img := range imagesData {
 			b64 := base64.StdEncoding.EncodeToString(img)
@@ -372,20 +729,24 @@ func (b *QQBot) handleApprove(ctx *zero.Ctx) {
 		ctx.Send(msgSegments)
 
 		// 通知投稿者
-		for _, p := range validPosts {
+		for _, p := range published {
 			if p.UIN > 0 {
-				notifyMsg := fmt.Sprintf("🎉 您的投稿 #%d 已发布！", p.ID)
 				time.Sleep(500 * time.Millisecond)
-				if p.GroupID > 0 {
-					ctx.SendGroupMessage(p.GroupID, message.Text(notifyMsg))
-				} else {
-					ctx.SendPrivateMessage(p.UIN, message.Text(notifyMsg))
-				}
+				b.notifySubmitter(ctx, p, fmt.Sprintf("🎉 您的投稿 #%d 已发布！", p.ID))
 			}
 		}
 	}()
 }
 
+// releasePosts 发布失败时将已标记发布的稿件恢复为待审核
+func (b *QQBot) releasePosts(posts ...*model.Post) {
+	for _, p := range posts {
+		if err := b.store.UpdatePostStatus(p.ID, model.StatusPublished, model.StatusPending, "", p.Reason); err != nil {
+			log.Printf("回滚稿件状态失败 #%d: %v", p.ID, err)
+		}
+	}
+}
+
 // handleReject 拒稿
 func (b *QQBot) handleReject(ctx *zero.Ctx) {
 	argsStr := getArgs(ctx)
@@ -408,18 +769,24 @@ func (b *QQBot) handleReject(ctx *zero.Ctx) {
 		ctx.Send(message.Text(fmt.Sprintf("稿件 #%d 已发布，无法拒绝", id)))
 		return
 	}
+	if !b.moderatable(ctx, post) {
+		return
+	}
 
 	reason := ""
 	if len(args) > 1 {
 		reason = strings.Join(args[1:], " ")
 	}
 
-	post.Status = model.StatusRejected
-	post.Reason = reason
-	if err := b.store.SavePost(post); err != nil {
+	if err := b.store.UpdatePostStatus(post.ID, post.Status, model.StatusRejected, post.TID, reason); err != nil {
+		if errors.Is(err, store.ErrStatusChanged) {
+			ctx.Send(message.Text(fmt.Sprintf("❌ 稿件 #%d 状态已变化，请重新查看", id)))
+			return
+		}
 		ctx.Send(message.Text("❌ 更新稿件状态失败: " + err.Error()))
 		return
 	}
+	post.Status, post.Reason = model.StatusRejected, reason
 
 	msg := fmt.Sprintf("❌ 稿件 #%d 已拒绝", id)
 	if reason != "" {
@@ -427,26 +794,121 @@ func (b *QQBot) handleReject(ctx *zero.Ctx) {
 	}
 	ctx.Send(message.Text(msg))
 
-	if post.UIN > 0 {
-		notifyMsg := fmt.Sprintf("😔 您的投稿 #%d 未通过审核", post.ID)
-		if reason != "" {
-			notifyMsg += "\n理由: " + reason
+	notifyMsg := fmt.Sprintf("😔 您的投稿 #%d 未通过审核", post.ID)
+	if reason != "" {
+		notifyMsg += "\n理由: " + reason
+	}
+	b.notifySubmitter(ctx, post, notifyMsg)
+}
+
+// handleMarkCW 为稿件图片设置内容警告: /打码 <编号> [图片序号,...] [理由]
+func (b *QQBot) handleMarkCW(ctx *zero.Ctx) {
+	args := strings.Fields(getArgs(ctx))
+	if len(args) < 1 {
+		ctx.Send(message.Text("用法: /打码 <编号> [图片序号,...] [理由]"))
+		return
+	}
+	post := b.getEditablePost(ctx, args[0])
+	if post == nil {
+		return
+	}
+	if len(post.Images) == 0 {
+		ctx.Send(message.Text(fmt.Sprintf("稿件 #%d 没有图片", post.ID)))
+		return
+	}
+
+	args = args[1:]
+	var images []int
+	if len(args) > 0 {
+		if list, err := model.ParseIndexList(args[0]); err == nil {
+			images, args = list, args[1:]
 		}
-		if post.GroupID > 0 {
-			ctx.SendGroupMessage(post.GroupID, message.Text(notifyMsg))
-		} else {
-			ctx.SendPrivateMessage(post.UIN, message.Text(notifyMsg))
+	}
+	for _, n := range images {
+		if n > len(post.Images) {
+			ctx.Send(message.Text(fmt.Sprintf("❌ 稿件 #%d 只有 %d 张图片", post.ID, len(post.Images))))
+			return
+		}
+	}
+	post.CW = strings.Join(args, " ")
+	if post.CW == "" {
+		post.CW = model.CWDefaultLabel
+	}
+	post.CWImages = images
+	if !b.saveCW(ctx, post) {
+		return
+	}
+	ctx.Send(message.Text(fmt.Sprintf("🙈 稿件 #%d 已打码 (%s)", post.ID, post.CW)))
+}
+
+// handleClearCW 取消稿件的内容警告
+func (b *QQBot) handleClearCW(ctx *zero.Ctx) {
+	post := b.getEditablePost(ctx, getArgs(ctx))
+	if post == nil {
+		return
+	}
+	post.CW, post.CWImages = "", nil
+	if !b.saveCW(ctx, post) {
+		return
+	}
+	ctx.Send(message.Text(fmt.Sprintf("稿件 #%d 已取消打码", post.ID)))
+}
+
+// saveCW 只写入内容警告字段，稿件在读取后已被发布时不写入，失败时回复原因
+func (b *QQBot) saveCW(ctx *zero.Ctx, post *model.Post) bool {
+	if err := b.store.UpdatePostCW(post.ID, post.CW, post.CWImages); err != nil {
+		if errors.Is(err, store.ErrStatusChanged) {
+			ctx.Send(message.Text(fmt.Sprintf("❌ 稿件 #%d 状态已变化，请重新查看", post.ID)))
+			return false
 		}
+		ctx.Send(message.Text("❌ 更新稿件失败: " + err.Error()))
+		return false
 	}
+	return true
 }
 
-// handleListPending 待审核列表
+// getEditablePost 按编号读取未发布的稿件，失败时回复原因并返回 nil
+func (b *QQBot) getEditablePost(ctx *zero.Ctx, arg string) *model.Post {
+	id, err := strconv.ParseInt(strings.TrimSpace(arg), 10, 64)
+	if err != nil {
+		ctx.Send(message.Text("❌ 编号格式不正确"))
+		return nil
+	}
+	post, err := b.store.GetPost(id)
+	if err != nil || post == nil {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 稿件 #%d 不存在", id)))
+		return nil
+	}
+	if post.Status == model.StatusPublished {
+		ctx.Send(message.Text(fmt.Sprintf("稿件 #%d 已发布，无法修改", id)))
+		return nil
+	}
+	if !b.moderatable(ctx, post) {
+		return nil
+	}
+	return post
+}
+
+// handleListPending 待审核列表，在附加墙的审核群中只列出该群负责的墙
 func (b *QQBot) handleListPending(ctx *zero.Ctx) {
 	posts, err := b.store.ListByStatus(model.StatusPending)
 	if err != nil {
 		ctx.Send(message.Text("❌ 查询失败: " + err.Error()))
 		return
 	}
+	if managed := b.walls.ManagedBy(ctx.Event.GroupID); len(managed) > 0 && len(managed) < len(b.walls.All()) {
+		ids := make(map[int64]bool, len(managed))
+		for _, w := range managed {
+			ids[w.ID] = true
+		}
+		kept := posts[:0]
+		for _, p := range posts {
+			if ids[p.WallID] {
+				kept = append(kept, p)
+			}
+		}
+		posts = kept
+	}
 	if len(posts) == 0 {
 		ctx.Send(message.Text("📭 暂无待审核稿件"))
 		return
@@ -454,15 +916,21 @@ func (b *QQBot) handleListPending(ctx *zero.Ctx) {
 	var sb strings.Builder
 	fmt.Fprintf(&sb, "📋 待审核稿件 (%d 件):\n\n", len(posts))
 	for _, p := range posts {
+		if b.walls.Multi() {
+			sb.WriteString("[" + b.walls.Name(p.WallID) + "] ")
+		}
 		sb.WriteString(p.Summary())
 		sb.WriteString("---\n")
 	}
 	ctx.Send(message.Text(sb.String()))
 }
 
-// handleDirectPublish 管理员直接发说说
+// handleDirectPublish 管理员直接发说说，多墙时首行 "墙: 名称" 指定发到哪面墙
 func (b *QQBot) handleDirectPublish(ctx *zero.Ctx) {
-	text := getArgs(ctx)
+	target, text, ok := b.pickWall(ctx, getArgs(ctx))
+	if !ok {
+		return
+	}
 	images := extractImages(ctx) // 1. 提取图片 URL
 
 	if text == "" && len(images) == 0 {
@@ -497,26 +965,30 @@ func (b *QQBot) handleDirectPublish(ctx *zero.Ctx) {
 			}
 		}
 
-		// 3. 构建发布选项
-		var opts *qzone.PublishOption
-		if len(imagesData) > 0 {
-			opts = &qzone.PublishOption{
-				ImageBytes: imagesData,
-			}
-		}
-
-		// 4. 调用发布 (传入 opts)
-		_, err := b.qzClient.Publish(context.Background(), text, opts)
-		if err != nil {
+		// 3. 调用发布，图片超过单条说说上限时拆成多条
+		_, parts, err := target.Publish(context.Background(), text, imagesData)
+		switch {
+		case parts == 0:
 			ctx.Send(message.Text("❌ 发布失败: " + err.Error()))
-		} else {
+		case err != nil:
+			ctx.Send(message.Text(fmt.Sprintf("⚠️ 已发布 %d 条说说，其余图片发布失败: %v", parts, err)))
+		case parts > 1:
+			ctx.Send(message.Text(fmt.Sprintf("✅ 说说已发布 (图片超过 %d 张，拆分为 %d 条)", wall.MaxImages, parts)))
+		default:
 			ctx.Send(message.Text("✅ 说说已发布"))
 		}
 	}()
 }
 
-// handleScanQR 扫码登录QQ空间
+// handleScanQR 扫码登录QQ空间: /扫码 [墙名]，不带墙名时登录默认墙
 func (b *QQBot) handleScanQR(ctx *zero.Ctx) {
+	target := b.walls.Default()
+	if arg := getArgs(ctx); arg != "" {
+		if target = b.walls.Find(arg); target == nil {
+			ctx.Send(message.Text(fmt.Sprintf("❌ 墙 %s 不存在，可选: %s", arg, b.walls.Names())))
+			return
+		}
+	}
 	ctx.Send(message.Text("🔄 正在获取二维码..."))
 
 	qr, err := qzone.GetQRCode()
@@ -527,7 +999,11 @@ func (b *QQBot) handleScanQR(ctx *zero.Ctx) {
 
 	b64 := base64.StdEncoding.EncodeToString(qr.Image)
 	ctx.Send(message.Image("base64://" + b64))
-	ctx.Send(message.Text("📱 请用QQ扫描上方二维码登录QQ空间\n（二维码有效期约2分钟）"))
+	hint := "📱 请用QQ扫描上方二维码登录QQ空间\n（二维码有效期约2分钟）"
+	if b.walls.Multi() {
+		hint = fmt.Sprintf("📱 请用 %s 的QQ号扫描上方二维码登录QQ空间\n（二维码有效期约2分钟）", target.Name)
+	}
+	ctx.Send(message.Text(hint))
 
 	go func() {
 		for i := 0; i < 60; i++ {
@@ -537,11 +1013,12 @@ func (b *QQBot) handleScanQR(ctx *zero.Ctx) {
 				continue
 			}
 			if state == qzone.LoginSuccess {
-				if updateErr := b.qzClient.UpdateCookie(cookie); updateErr != nil {
+				if updateErr := target.UpdateCookie(cookie); updateErr != nil {
 					ctx.Send(message.Text("❌ Cookie更新失败: " + updateErr.Error()))
 					return
 				}
-				ctx.Send(message.Text(fmt.Sprintf("✅ QQ空间登录成功！UIN=%d", b.qzClient.UIN())))
+				log.Printf("[QQBot] %s 扫码登录成功, UIN=%d", target.Name, target.Client.UIN())
+				ctx.Send(message.Text(fmt.Sprintf("✅ QQ空间登录成功！UIN=%d", target.Client.UIN())))
 				return
 			}
 			if state == qzone.LoginExpired {
@@ -565,16 +1042,58 @@ func (b *QQBot) handleHelp(ctx *zero.Ctx) {
 【投稿命令】
 /投稿 <内容>       - 投稿（可附带图片）
 /匿名投稿 <内容>   - 匿名投稿
+  首行写 "CW: 理由" 可将图片模糊处理，"CW2,3: 理由" 只模糊第2、3张
+  只发 /投稿 可开始草稿，之后分多条发送文字和图片
+  回复一条消息或合并转发发送 /投稿，可直接投稿该消息
+/预览 /完成 /取消  - 预览、提交、放弃草稿
+/聊天投稿          - 投稿聊天记录（附合并转发，或每行 "昵称: 内容"）
+/匿名聊天投稿      - 匿名投稿聊天记录（说话人显示为 A/B/C）
+/寻物 /招领 /二手  - 寻物启事、失物招领、二手交易（发送命令获取填写模板）
+/发起投票          - 首行问题，之后每行一个选项，可加 "截止: 24h"
+/投票 <编号> <序号> - 参与已发布的投票
 /撤稿 <编号>       - 撤回自己的稿件
-
-【管理命令】（仅管理员）
+/我的投稿 [页码]   - 查看自己投稿的审核状态（私聊）
+/查稿 <编号>       - 查看自己某条投稿的详情（私聊）
+/改稿 <编号> <内容> - 审核前修改自己稿件的文字
+/回复 <编号> <内容> - 匿名回复已发布的匿名稿件，双方互不可见QQ号（私聊）
+  收到回复后发送 /回复 R<会话> <内容>，或回复该消息发送 /回复 <内容>
+/屏蔽 R<会话>       - 不再接收该会话对方的消息 (/解除屏蔽 恢复)
+/关闭回复 /开启回复 - 拒收或恢复接收匿名回复
+
+【群设置】（群主）
+/本群开启投稿       - 允许本群成员投稿（开启群白名单时仅所有者）
+/本群关闭投稿       - 禁止本群投稿（私聊投稿不受影响）
+/本群设置           - 查看或修改本群默认匿名、审核结果通知方式
+
+【管理命令】（观察员及以上）
 /待审核             - 查看待审核稿件
+
+【审核命令】（审核员及以上）
 /看稿 <编号>        - 查看稿件详情（截图）
 /过稿 <编号>        - 通过并发布
 /过稿 1-4           - 批量通过 #1~#4
+  在管理群回复新投稿通知 "过"、"拒 理由"、"删" 也可直接审核
 /拒稿 <编号> [理由]  - 拒绝稿件
+/打码 <编号> [图片序号] [理由] - 模糊稿件图片
+/取消打码 <编号>    - 取消图片模糊
+/结束投票 <编号>    - 提前结束投票并发布结果
+/拉黑 <对象> [时长] [理由] - 封禁 QQ号/@用户/#稿件作者/群 群号/ip 地址
+/解封 <对象>        - 解除封禁，不带参数的 /拉黑 列出封禁
+
+【所有者命令】
 /发说说 <内容>      - 直接发布到空间
-/扫码               - 扫码登录QQ空间`
+/扫码               - 扫码登录QQ空间
+/授权 @用户 角色    - 授予 moderator/viewer/owner，user 收回
+/转达记录 <编号>    - 查看稿件的匿名回复记录（含QQ号，仅私聊）`
+	if b.walls.Multi() {
+		help += fmt.Sprintf(`
+
+【多墙】可选: %s
+  投稿首行写 "墙: 名称" 投到指定的墙，否则按本群设置，私聊投到 %s
+  审核命令只能在稿件所属墙的审核群中使用（所有者也可私聊）
+/本群设置 墙 <名称> - 设置本群投稿默认进入的墙（群主，开启群白名单时仅所有者）
+/扫码 <名称>        - 登录指定墙的QQ空间（所有者）`, b.walls.Names(), b.walls.Default().Name)
+	}
 	ctx.Send(message.Text(help))
 }
 
@@ -593,23 +1112,28 @@ func extractImages(ctx *zero.Ctx) []string {
 	var images []string
 	for _, seg := range ctx.Event.Message {
 		if seg.Type == "image" {
-			u := seg.Data["url"]
-			f := seg.Data["file"]
-			// 优先处理 NTQQ 的临时链接，改为存储 file ID
-			if strings.HasPrefix(u, "https://multimedia.nt.qq.com.cn/download") && f != "" {
-				images = append(images, f)
-				continue
-			}
-			if u != "" {
-				images = append(images, u)
-			} else if f != "" {
-				images = append(images, f)
+			if img := imageRef(seg); img != "" {
+				images = append(images, img)
 			}
 		}
 	}
 	return images
 }
 
+// imageRef 从图片消息段中取出要保存的图片引用 (URL 或 file ID)
+func imageRef(seg message.Segment) string {
+	u := seg.Data["url"]
+	f := seg.Data["file"]
+	// 优先处理 NTQQ 的临时链接，改为存储 file ID
+	if strings.HasPrefix(u, "https://multimedia.nt.qq.com.cn/download") && f != "" {
+		return f
+	}
+	if u != "" {
+		return u
+	}
+	return f
+}
+
 func parseIDs(s string) ([]int64, error) {
 	s = strings.TrimSpace(s)
 	if s == "" {
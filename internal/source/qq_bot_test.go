@@ -0,0 +1,31 @@
+package source
+
+import "testing"
+
+// TestParsePollOption 测试投票选项解析: 序号从1开始，字母不区分大小写
+func TestParsePollOption(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want int
+		ok   bool
+	}{
+		{"1", 3, 0, true},
+		{"3", 3, 2, true},
+		{"0", 3, 0, false},
+		{"4", 3, 0, false},
+		{"-1", 3, 0, false},
+		{"a", 3, 0, true},
+		{"C", 3, 2, true},
+		{"d", 3, 0, false},
+		{"@", 3, 0, false},
+		{"ab", 3, 0, false},
+		{"", 3, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parsePollOption(tt.in, tt.n)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("parsePollOption(%q, %d) = %d, %v; 期望 %d, %v", tt.in, tt.n, got, ok, tt.want, tt.ok)
+		}
+	}
+}
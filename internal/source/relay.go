@@ -0,0 +1,234 @@
+package source
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+	"github.com/guohuiyuan/qzonewall-go/internal/store"
+
+	zero "github.com/wdvxdr1123/ZeroBot"
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+const (
+	// relayMaxLen 单条匿名回复的最大字数
+	relayMaxLen = 500
+	// relayLogLimit /转达记录 最多显示的条数
+	relayLogLimit = 20
+)
+
+// handleRelay 匿名回复: /回复 <稿件编号> <内容> 经机器人私聊转达给匿名投稿人，双方都看不到对方QQ号。
+// 收到转达后发送 /回复 R<会话编号> <内容>，或回复转达消息发送 /回复 <内容> 回应对方。
+func (b *QQBot) handleRelay(ctx *zero.Ctx) {
+	if b.cfg.Wall.RelayLimit < 0 {
+		ctx.Send(message.Text("❌ 匿名回复未开启"))
+		return
+	}
+	args := getArgs(ctx)
+
+	// 回复转达消息: 整段参数都是内容
+	if replyID, ok := ctx.State["reply"].(string); ok {
+		msgID, _ := strconv.ParseInt(replyID, 10, 64)
+		relay, err := b.store.GetRelayByMsg(msgID)
+		if err != nil || relay == nil || relay.ToUIN != ctx.Event.UserID {
+			ctx.Send(message.Text("❌ 被回复的不是发给你的匿名回复"))
+			return
+		}
+		b.answerRelay(ctx, relay, args)
+		return
+	}
+
+	target, text := args, ""
+	if i := strings.IndexFunc(args, unicode.IsSpace); i > 0 {
+		target, text = args[:i], strings.TrimSpace(args[i:])
+	}
+	if id, ok := model.ParseRelayCode(target); ok {
+		relay, err := b.store.GetRelay(id)
+		if err != nil || relay == nil || relay.ToUIN != ctx.Event.UserID {
+			ctx.Send(message.Text(fmt.Sprintf("❌ 会话 %s 不存在", target)))
+			return
+		}
+		b.answerRelay(ctx, relay, text)
+		return
+	}
+
+	postID, err := strconv.ParseInt(target, 10, 64)
+	if err != nil || text == "" {
+		ctx.Send(message.Text("用法: /回复 <稿件编号> <内容>\n收到回复后: /回复 R<会话编号> <内容>"))
+		return
+	}
+	post, err := b.store.GetPost(postID)
+	if err != nil || post == nil || !post.Anon || post.Status != model.StatusPublished {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 稿件 #%d 不存在或不是已发布的匿名稿件", postID)))
+		return
+	}
+	if post.UIN == ctx.Event.UserID {
+		ctx.Send(message.Text("❌ 不能回复自己的稿件"))
+		return
+	}
+	b.deliverRelay(ctx, post, post.UIN, text)
+}
+
+// answerRelay 回应收到的转达，发回给该转达的发送人
+func (b *QQBot) answerRelay(ctx *zero.Ctx, relay *model.Relay, text string) {
+	post, err := b.store.GetPost(relay.PostID)
+	if err != nil || post == nil {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 稿件 #%d 已删除，会话已结束", relay.PostID)))
+		return
+	}
+	b.deliverRelay(ctx, post, relay.FromUIN, text)
+}
+
+// deliverRelay 校验并记录一条转达，再私聊发送给接收人。
+// 接收人拒收或屏蔽了发送人时统一提示暂不接收，不暴露屏蔽关系。
+func (b *QQBot) deliverRelay(ctx *zero.Ctx, post *model.Post, to int64, text string) {
+	from := ctx.Event.UserID
+	if text == "" {
+		ctx.Send(message.Text("❌ 回复内容不能为空"))
+		return
+	}
+	if n := len([]rune(text)); n > relayMaxLen {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 回复超出限制 (%d/%d)", n, relayMaxLen)))
+		return
+	}
+	if hit, word := store.CheckCensor(text, b.censorWords); len(b.censorWords) > 0 && hit {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 回复包含违禁词: %s", word)))
+		return
+	}
+
+	now := time.Now()
+	count, err := b.store.CountRelaysSince(from, now.Add(-time.Hour).Unix())
+	if err != nil {
+		ctx.Send(message.Text("❌ 查询失败: " + err.Error()))
+		return
+	}
+	if count >= b.cfg.Wall.RelayLimit {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 回复过于频繁，每小时最多 %d 条", b.cfg.Wall.RelayLimit)))
+		return
+	}
+	optOut, err := b.store.RelayOptedOut(to)
+	if err != nil {
+		ctx.Send(message.Text("❌ 查询失败: " + err.Error()))
+		return
+	}
+	blocked, err := b.store.IsRelayBlocked(to, from)
+	if err != nil {
+		ctx.Send(message.Text("❌ 查询失败: " + err.Error()))
+		return
+	}
+	if optOut || blocked {
+		ctx.Send(message.Text("❌ 对方暂不接收匿名回复"))
+		return
+	}
+
+	// 先记录再发送，发送失败的转达同样留档
+	relay := &model.Relay{PostID: post.ID, FromUIN: from, ToUIN: to, Text: text, CreateTime: now.Unix()}
+	if err := b.store.SaveRelay(relay); err != nil {
+		ctx.Send(message.Text("❌ 保存失败: " + err.Error()))
+		return
+	}
+	log.Printf("[QQBot] 匿名回复 %s: QQ %d -> QQ %d (稿件 #%d)", relay.Code(), from, to, post.ID)
+
+	head := fmt.Sprintf("💌 稿件 #%d 的投稿人回复了你", post.ID)
+	if to == post.UIN {
+		head = fmt.Sprintf("💌 有人匿名回复了你的稿件 #%d", post.ID)
+	}
+	code := relay.Code()
+	body := fmt.Sprintf("%s (会话 %s)\n%s\n\n回复本消息发送 /回复 <内容>，或发送 /回复 %s <内容>\n/屏蔽 %s 不再接收对方消息，/关闭回复 拒收所有匿名回复",
+		head, code, text, code, code)
+	msgID := ctx.SendPrivateMessage(to, message.Text(body))
+	if msgID == 0 {
+		log.Printf("[QQBot] 匿名回复 %s 发送失败", code)
+		ctx.Send(message.Text("❌ 发送失败，对方可能未添加机器人为好友"))
+		return
+	}
+	if err := b.store.SetRelayMsgID(relay.ID, msgID); err != nil {
+		log.Printf("[QQBot] 记录匿名回复 %s 消息ID失败: %v", code, err)
+	}
+	ctx.Send(message.Text(fmt.Sprintf("✅ 已匿名转达 (会话 %s)", code)))
+}
+
+// handleRelayBlock 屏蔽 / 解除屏蔽: /屏蔽 R<会话编号>，之后不再接收该会话对方的转达
+func (b *QQBot) handleRelayBlock(ctx *zero.Ctx, blocked bool) {
+	usage := "用法: /屏蔽 R<会话编号>"
+	if !blocked {
+		usage = "用法: /解除屏蔽 R<会话编号>"
+	}
+	id, ok := model.ParseRelayCode(getArgs(ctx))
+	if !ok {
+		ctx.Send(message.Text(usage))
+		return
+	}
+	relay, err := b.store.GetRelay(id)
+	if err != nil || relay == nil || relay.ToUIN != ctx.Event.UserID {
+		ctx.Send(message.Text(fmt.Sprintf("❌ 会话 R%d 不存在", id)))
+		return
+	}
+	if err := b.store.SetRelayBlock(ctx.Event.UserID, relay.FromUIN, blocked); err != nil {
+		ctx.Send(message.Text("❌ 操作失败: " + err.Error()))
+		return
+	}
+	log.Printf("[QQBot] QQ %d 屏蔽 QQ %d: %v (会话 %s)", ctx.Event.UserID, relay.FromUIN, blocked, relay.Code())
+	if blocked {
+		ctx.Send(message.Text(fmt.Sprintf("🚫 已屏蔽会话 %s 的对方，发送 /解除屏蔽 %s 可恢复", relay.Code(), relay.Code())))
+		return
+	}
+	ctx.Send(message.Text(fmt.Sprintf("✅ 已解除屏蔽会话 %s 的对方", relay.Code())))
+}
+
+// handleRelayOptOut 关闭 / 开启接收匿名回复
+func (b *QQBot) handleRelayOptOut(ctx *zero.Ctx, optOut bool) {
+	if err := b.store.SetRelayOptOut(ctx.Event.UserID, optOut); err != nil {
+		ctx.Send(message.Text("❌ 操作失败: " + err.Error()))
+		return
+	}
+	if optOut {
+		ctx.Send(message.Text("🔕 已关闭匿名回复，你的稿件将不再收到他人回复，发送 /开启回复 可恢复"))
+		return
+	}
+	ctx.Send(message.Text("🔔 已开启匿名回复"))
+}
+
+// handleRelayLog 转达记录: /转达记录 <稿件编号>，列出稿件的匿名回复及双方QQ号，供处理滥用。
+// 仅限所有者私聊使用
+func (b *QQBot) handleRelayLog(ctx *zero.Ctx) {
+	postID, err := strconv.ParseInt(getArgs(ctx), 10, 64)
+	if err != nil {
+		ctx.Send(message.Text("用法: /转达记录 <稿件编号>"))
+		return
+	}
+	relays, err := b.store.ListRelays(postID)
+	if err != nil {
+		ctx.Send(message.Text("❌ 查询失败: " + err.Error()))
+		return
+	}
+	if len(relays) == 0 {
+		ctx.Send(message.Text(fmt.Sprintf("📭 稿件 #%d 没有匿名回复", postID)))
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "📋 稿件 #%d 的匿名回复 (共 %d 条)", postID, len(relays))
+	if len(relays) > relayLogLimit {
+		fmt.Fprintf(&sb, "，仅显示最近 %d 条", relayLogLimit)
+		relays = relays[len(relays)-relayLogLimit:]
+	}
+	for _, r := range relays {
+		text := []rune(strings.Join(strings.Fields(r.Text), " "))
+		if len(text) > 30 {
+			text = append(text[:30], []rune("...")...)
+		}
+		status := ""
+		if r.MsgID == 0 {
+			status = " [未送达]"
+		}
+		fmt.Fprintf(&sb, "\n\n%s %s%s\n%d → %d\n%s", r.Code(), time.Unix(r.CreateTime, 0).Format("01-02 15:04"),
+			status, r.FromUIN, r.ToUIN, string(text))
+	}
+	ctx.Send(message.Text(sb.String()))
+}
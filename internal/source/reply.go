@@ -0,0 +1,140 @@
+package source
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+
+	zero "github.com/wdvxdr1123/ZeroBot"
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+// leadingText 跳过消息开头的回复段和 @ 段 (回复群消息时 QQ 会自动 @ 原发送人)，
+// 返回第一段文字在消息中的下标，没有时返回 -1
+func leadingText(msg message.Message) int {
+	for i, seg := range msg {
+		switch seg.Type {
+		case "reply", "at":
+			continue
+		case "text":
+			if strings.TrimSpace(seg.Data["text"]) == "" {
+				continue
+			}
+			return i
+		}
+		return -1
+	}
+	return -1
+}
+
+// isCommand 消息 (含回复消息) 是否以命令前缀开头
+func isCommand(ctx *zero.Ctx) bool {
+	i := leadingText(ctx.Event.Message)
+	return i >= 0 && strings.HasPrefix(strings.TrimSpace(ctx.Event.Message[i].Data["text"]), zero.BotConfig.CommandPrefix)
+}
+
+// replyCommandRule 匹配回复某条消息时发送的命令，如 [回复] /投稿。
+// ZeroBot 的 CommandRule 要求消息首段为文字，回复消息需单独匹配。
+// 被回复的消息ID存入 ctx.State["reply"]，命令参数存入 ctx.State["args"]。
+func replyCommandRule(command string) zero.Rule {
+	return func(ctx *zero.Ctx) bool {
+		msg := ctx.Event.Message
+		if len(msg) == 0 || msg[0].Type != "reply" || msg[0].Data["id"] == "" {
+			return false
+		}
+		i := leadingText(msg)
+		if i < 0 {
+			return false
+		}
+		text := strings.TrimSpace(msg[i].Data["text"])
+		cmd, ok := strings.CutPrefix(text, zero.BotConfig.CommandPrefix+command)
+		if !ok {
+			return false
+		}
+		// 命令后须为空白或结束，避免 "/投稿" 误匹配以其开头的其他命令
+		if cmd != "" && !strings.HasPrefix(cmd, " ") && !strings.HasPrefix(cmd, "\n") {
+			return false
+		}
+		args := strings.TrimSpace(cmd)
+		if i+1 < len(msg) {
+			args += msg[i+1:].ExtractPlainText()
+		}
+		ctx.State["reply"] = msg[0].Data["id"]
+		ctx.State["args"] = args
+		return true
+	}
+}
+
+// handleReplyContribute 回复一条消息发送 /投稿：被回复消息的文字和图片作为投稿内容，
+// 命令后附带的文字放在最前。被回复的是合并转发时按聊天记录投稿。
+func (b *QQBot) handleReplyContribute(ctx *zero.Ctx, anon bool) {
+	replyID, _ := ctx.State["reply"].(string)
+	var msgID interface{} = replyID
+	if n, err := strconv.ParseInt(replyID, 10, 64); err == nil {
+		msgID = n
+	}
+	target, args, ok := b.pickWall(ctx, getArgs(ctx))
+	if !ok {
+		return
+	}
+	quoted := ctx.GetMessage(msgID)
+	if len(quoted.Elements) == 0 {
+		ctx.Send(message.Text("❌ 无法读取被回复的消息，可能已撤回或过期"))
+		return
+	}
+
+	for _, seg := range quoted.Elements {
+		if seg.Type != "forward" || seg.Data["id"] == "" {
+			continue
+		}
+		text, images := forwardToChatLog(ctx, seg.Data["id"])
+		if text == "" {
+			ctx.Send(message.Text("❌ 无法读取合并转发的聊天记录"))
+			return
+		}
+		b.submitPost(ctx, &model.Post{
+			Text:      text,
+			Images:    images,
+			Anon:      anon,
+			Type:      model.TypeChat,
+			SourceMsg: "forward:" + seg.Data["id"],
+			WallID:    target.ID,
+		})
+		return
+	}
+
+	cw, cwImages, prefix := model.ParseCW(args)
+	text, images := quotedContent(quoted.Elements)
+	if prefix = strings.TrimSpace(prefix); prefix != "" {
+		text = strings.TrimSpace(prefix + "\n" + text)
+	}
+	b.submitPost(ctx, &model.Post{
+		Text:      text,
+		Images:    append(extractImages(ctx), images...),
+		Anon:      anon,
+		CW:        cw,
+		CWImages:  cwImages,
+		SourceMsg: "msg:" + replyID,
+		WallID:    target.ID,
+	})
+}
+
+// quotedContent 取出消息中的文字和图片，表情以 [表情] 占位
+func quotedContent(msg message.Message) (string, []string) {
+	var text strings.Builder
+	var images []string
+	for _, seg := range msg {
+		switch seg.Type {
+		case "text":
+			text.WriteString(seg.Data["text"])
+		case "face":
+			text.WriteString("[表情]")
+		case "image":
+			if img := imageRef(seg); img != "" {
+				images = append(images, img)
+			}
+		}
+	}
+	return strings.TrimSpace(text.String()), images
+}
@@ -0,0 +1,99 @@
+package source
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+
+	zero "github.com/wdvxdr1123/ZeroBot"
+	"github.com/wdvxdr1123/ZeroBot/message"
+)
+
+// qqRole 发送者的有效角色: 配置中的超级用户为所有者，
+// 其余取 /授权 授予的角色与绑定该QQ的网页账号角色中较高者
+func (b *QQBot) qqRole(ctx *zero.Ctx) string {
+	if zero.SuperUserPermission(ctx) {
+		return model.RoleOwner
+	}
+	uin := ctx.Event.UserID
+	role, err := b.store.GetQQRole(uin)
+	if err != nil {
+		log.Printf("[QQBot] 查询 QQ %d 角色失败: %v", uin, err)
+		role = model.RoleUser
+	}
+	if account, err := b.store.GetAccountByQQ(uin); err == nil && account != nil &&
+		model.RoleLevel(account.Role) > model.RoleLevel(role) {
+		role = account.Role
+	}
+	return role
+}
+
+// roleRule 发送者角色不低于 role 时匹配，用于管理命令的权限控制
+func (b *QQBot) roleRule(role string) zero.Rule {
+	return func(ctx *zero.Ctx) bool {
+		return model.RoleLevel(b.qqRole(ctx)) >= model.RoleLevel(role)
+	}
+}
+
+// handleGrant 授权: /授权 @用户 角色 或 /授权 QQ号 角色；不带参数时列出已授权的QQ
+func (b *QQBot) handleGrant(ctx *zero.Ctx) {
+	var uin int64
+	for _, seg := range ctx.Event.Message {
+		if seg.Type == "at" {
+			uin, _ = strconv.ParseInt(seg.Data["qq"], 10, 64)
+			break
+		}
+	}
+	args := strings.Fields(getArgs(ctx))
+	if uin == 0 && len(args) >= 2 {
+		if n, err := strconv.ParseInt(args[0], 10, 64); err == nil {
+			uin, args = n, args[1:]
+		}
+	}
+
+	if uin == 0 || len(args) != 1 {
+		b.listGrants(ctx)
+		return
+	}
+	role, ok := model.ParseRole(args[0])
+	if !ok {
+		ctx.Send(message.Text("❌ 未知角色: " + args[0] + "\n可选: owner 所有者 / moderator 审核员 / viewer 观察员 / user 收回"))
+		return
+	}
+	if uin == ctx.Event.UserID {
+		ctx.Send(message.Text("❌ 不能修改自己的角色"))
+		return
+	}
+	if err := b.store.SetQQRole(uin, role, ctx.Event.UserID); err != nil {
+		ctx.Send(message.Text("❌ 授权失败: " + err.Error()))
+		return
+	}
+	log.Printf("[QQBot] QQ %d 将 QQ %d 设为 %s", ctx.Event.UserID, uin, role)
+	if role == model.RoleUser {
+		ctx.Send(message.Text(fmt.Sprintf("✅ 已收回 %d 的管理权限", uin)))
+		return
+	}
+	ctx.Send(message.Text(fmt.Sprintf("✅ 已将 %d 设为%s", uin, model.RoleLabel(role))))
+}
+
+func (b *QQBot) listGrants(ctx *zero.Ctx) {
+	roles, err := b.store.ListQQRoles()
+	if err != nil {
+		ctx.Send(message.Text("❌ 查询失败: " + err.Error()))
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString("用法: /授权 @用户 角色 或 /授权 QQ号 角色\n角色: owner 所有者 / moderator 审核员 / viewer 观察员 / user 收回\n")
+	if len(roles) == 0 {
+		sb.WriteString("\n暂无授权")
+	} else {
+		sb.WriteString("\n当前授权:")
+		for _, r := range roles {
+			fmt.Fprintf(&sb, "\n%d  %s", r.UIN, model.RoleLabel(r.Role))
+		}
+	}
+	ctx.Send(message.Text(sb.String()))
+}
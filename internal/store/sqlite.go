@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -48,14 +49,115 @@ func (s *Store) migrate() error {
 			text        TEXT    NOT NULL DEFAULT '',
 			images      TEXT    NOT NULL DEFAULT '[]',
 			anon        INTEGER NOT NULL DEFAULT 0,
+			type        TEXT    NOT NULL DEFAULT '',
+			fields      TEXT    NOT NULL DEFAULT '',
+			poll        TEXT    NOT NULL DEFAULT '',
+			cw          TEXT    NOT NULL DEFAULT '',
+			cw_images   TEXT    NOT NULL DEFAULT '[]',
 			status      TEXT    NOT NULL DEFAULT 'pending',
 			reason      TEXT    NOT NULL DEFAULT '',
 			tid         TEXT    NOT NULL DEFAULT '',
 			avatar_url  TEXT    NOT NULL DEFAULT '',
+			source_msg  TEXT    NOT NULL DEFAULT '',
+			ip          TEXT    NOT NULL DEFAULT '',
+			wall_id     INTEGER NOT NULL DEFAULT 0,
 			create_time INTEGER NOT NULL DEFAULT 0,
 			update_time INTEGER NOT NULL DEFAULT 0
 		);
 		CREATE INDEX IF NOT EXISTS idx_posts_status ON posts(status);
+		CREATE INDEX IF NOT EXISTS idx_posts_type ON posts(type);
+		CREATE INDEX IF NOT EXISTS idx_posts_uin ON posts(uin);
+
+		CREATE TABLE IF NOT EXISTS poll_votes (
+			post_id     INTEGER NOT NULL,
+			voter       TEXT    NOT NULL,
+			option      INTEGER NOT NULL,
+			create_time INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (post_id, voter)
+		);
+
+		CREATE TABLE IF NOT EXISTS post_revisions (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			post_id     INTEGER NOT NULL,
+			old_text    TEXT    NOT NULL DEFAULT '',
+			new_text    TEXT    NOT NULL DEFAULT '',
+			editor      TEXT    NOT NULL DEFAULT '',
+			create_time INTEGER NOT NULL DEFAULT 0
+		);
+		CREATE INDEX IF NOT EXISTS idx_post_revisions_post ON post_revisions(post_id);
+
+		CREATE TABLE IF NOT EXISTS drafts (
+			uin         INTEGER NOT NULL,
+			group_id    INTEGER NOT NULL DEFAULT 0,
+			name        TEXT    NOT NULL DEFAULT '',
+			anon        INTEGER NOT NULL DEFAULT 0,
+			text        TEXT    NOT NULL DEFAULT '',
+			images      TEXT    NOT NULL DEFAULT '[]',
+			wall_id     INTEGER NOT NULL DEFAULT 0,
+			create_time INTEGER NOT NULL DEFAULT 0,
+			update_time INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (uin, group_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS notice_msgs (
+			msg_id      INTEGER PRIMARY KEY,
+			post_id     INTEGER NOT NULL,
+			create_time INTEGER NOT NULL DEFAULT 0
+		);
+
+		CREATE TABLE IF NOT EXISTS relay_msgs (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			post_id     INTEGER NOT NULL,
+			from_uin    INTEGER NOT NULL,
+			to_uin      INTEGER NOT NULL,
+			text        TEXT    NOT NULL DEFAULT '',
+			msg_id      INTEGER NOT NULL DEFAULT 0,
+			create_time INTEGER NOT NULL DEFAULT 0
+		);
+		CREATE INDEX IF NOT EXISTS idx_relay_msgs_from ON relay_msgs(from_uin, create_time);
+		CREATE INDEX IF NOT EXISTS idx_relay_msgs_post ON relay_msgs(post_id);
+		CREATE INDEX IF NOT EXISTS idx_relay_msgs_msg ON relay_msgs(msg_id);
+
+		CREATE TABLE IF NOT EXISTS relay_blocks (
+			uin         INTEGER NOT NULL,
+			blocked_uin INTEGER NOT NULL,
+			create_time INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (uin, blocked_uin)
+		);
+
+		CREATE TABLE IF NOT EXISTS relay_optouts (
+			uin         INTEGER PRIMARY KEY,
+			create_time INTEGER NOT NULL DEFAULT 0
+		);
+
+		CREATE TABLE IF NOT EXISTS bans (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			kind        TEXT    NOT NULL,
+			target      TEXT    NOT NULL,
+			post_id     INTEGER NOT NULL DEFAULT 0,
+			reason      TEXT    NOT NULL DEFAULT '',
+			operator    TEXT    NOT NULL DEFAULT '',
+			expire_time INTEGER NOT NULL DEFAULT 0,
+			create_time INTEGER NOT NULL DEFAULT 0,
+			UNIQUE (kind, target)
+		);
+
+		CREATE TABLE IF NOT EXISTS group_settings (
+			group_id    INTEGER PRIMARY KEY,
+			name        TEXT    NOT NULL DEFAULT '',
+			enabled     INTEGER NOT NULL DEFAULT 0,
+			anon        TEXT    NOT NULL DEFAULT '',
+			notify      TEXT    NOT NULL DEFAULT '',
+			wall_id     INTEGER NOT NULL DEFAULT 0,
+			updated_by  INTEGER NOT NULL DEFAULT 0,
+			update_time INTEGER NOT NULL DEFAULT 0
+		);
+
+		CREATE TABLE IF NOT EXISTS wall_cookies (
+			wall_id     INTEGER PRIMARY KEY,
+			cookie      TEXT    NOT NULL DEFAULT '',
+			update_time INTEGER NOT NULL DEFAULT 0
+		);
 
 		CREATE TABLE IF NOT EXISTS accounts (
 			id            INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -63,18 +165,95 @@ func (s *Store) migrate() error {
 			password_hash TEXT NOT NULL,
 			salt          TEXT NOT NULL,
 			role          TEXT NOT NULL DEFAULT 'user',
+			qq            INTEGER NOT NULL DEFAULT 0,
 			create_time   INTEGER NOT NULL DEFAULT 0
 		);
 
+		CREATE TABLE IF NOT EXISTS qq_roles (
+			uin         INTEGER PRIMARY KEY,
+			role        TEXT    NOT NULL,
+			granted_by  INTEGER NOT NULL DEFAULT 0,
+			create_time INTEGER NOT NULL DEFAULT 0
+		);
+
 		CREATE TABLE IF NOT EXISTS sessions (
 			token      TEXT PRIMARY KEY,
 			account_id INTEGER NOT NULL,
 			expire_time INTEGER NOT NULL
 		);
 	`)
+	if err != nil {
+		return err
+	}
+
+	// 旧库补充新增列
+	if err := s.addColumns("accounts", map[string]string{
+		"qq": "INTEGER NOT NULL DEFAULT 0",
+	}); err != nil {
+		return err
+	}
+	// 旧版 admin 角色统一为 owner
+	if _, err := s.db.Exec("UPDATE accounts SET role=? WHERE role='admin'", model.RoleOwner); err != nil {
+		return err
+	}
+	if err := s.addColumns("drafts", map[string]string{
+		"wall_id": "INTEGER NOT NULL DEFAULT 0",
+	}); err != nil {
+		return err
+	}
+	if err := s.addColumns("group_settings", map[string]string{
+		"wall_id": "INTEGER NOT NULL DEFAULT 0",
+	}); err != nil {
+		return err
+	}
+	if err := s.addColumns("posts", map[string]string{
+		"type":       "TEXT NOT NULL DEFAULT ''",
+		"fields":     "TEXT NOT NULL DEFAULT ''",
+		"poll":       "TEXT NOT NULL DEFAULT ''",
+		"cw":         "TEXT NOT NULL DEFAULT ''",
+		"cw_images":  "TEXT NOT NULL DEFAULT '[]'",
+		"source_msg": "TEXT NOT NULL DEFAULT ''",
+		"ip":         "TEXT NOT NULL DEFAULT ''",
+		"wall_id":    "INTEGER NOT NULL DEFAULT 0",
+	}); err != nil {
+		return err
+	}
+	_, err = s.db.Exec("CREATE INDEX IF NOT EXISTS idx_posts_wall ON posts(wall_id, status)")
 	return err
 }
 
+// addColumns 为已存在的表补充缺失的列 (CREATE TABLE IF NOT EXISTS 不会修改旧表)
+func (s *Store) addColumns(table string, cols map[string]string) error {
+	rows, err := s.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, typ        string
+			dflt             sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	_ = rows.Close()
+
+	for name, def := range cols {
+		if existing[name] {
+			continue
+		}
+		if _, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, name, def)); err != nil {
+			return fmt.Errorf("add column %s.%s: %w", table, name, err)
+		}
+	}
+	return nil
+}
+
 // ──────────────────────────────────────────
 // Post CRUD
 // ──────────────────────────────────────────
@@ -82,6 +261,17 @@ func (s *Store) migrate() error {
 // SavePost 保存投稿, 若 ID==0 则插入并回填 ID, 否则更新
 func (s *Store) SavePost(p *model.Post) error {
 	imagesJSON, _ := json.Marshal(p.Images)
+	cwImagesJSON, _ := json.Marshal(p.CWImages)
+	fieldsJSON := ""
+	if p.Fields != nil {
+		raw, _ := json.Marshal(p.Fields)
+		fieldsJSON = string(raw)
+	}
+	pollJSON := ""
+	if p.Poll != nil {
+		raw, _ := json.Marshal(p.Poll)
+		pollJSON = string(raw)
+	}
 	now := time.Now().Unix()
 
 	if p.ID == 0 {
@@ -89,10 +279,10 @@ func (s *Store) SavePost(p *model.Post) error {
 			p.CreateTime = now
 		}
 		res, err := s.db.Exec(
-			`INSERT INTO posts (uin,name,group_id,text,images,anon,status,reason,tid,avatar_url,create_time,update_time)
-			 VALUES (?,?,?,?,?,?,?,?,?,?,?,?)`,
+			`INSERT INTO posts (uin,name,group_id,text,images,anon,type,fields,poll,cw,cw_images,status,reason,tid,avatar_url,source_msg,ip,wall_id,create_time,update_time)
+			 VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`,
 			p.UIN, p.Name, p.GroupID, p.Text, string(imagesJSON),
-			b2i(p.Anon), string(p.Status), p.Reason, p.TID, p.AvatarURL,
+			b2i(p.Anon), string(p.Type), fieldsJSON, pollJSON, p.CW, string(cwImagesJSON), string(p.Status), p.Reason, p.TID, p.AvatarURL, p.SourceMsg, p.IP, p.WallID,
 			p.CreateTime, now,
 		)
 		if err != nil {
@@ -101,10 +291,10 @@ func (s *Store) SavePost(p *model.Post) error {
 		p.ID, _ = res.LastInsertId()
 	} else {
 		_, err := s.db.Exec(
-			`UPDATE posts SET uin=?,name=?,group_id=?,text=?,images=?,anon=?,status=?,reason=?,tid=?,avatar_url=?,update_time=?
+			`UPDATE posts SET uin=?,name=?,group_id=?,text=?,images=?,anon=?,type=?,fields=?,poll=?,cw=?,cw_images=?,status=?,reason=?,tid=?,avatar_url=?,source_msg=?,ip=?,wall_id=?,update_time=?
 			 WHERE id=?`,
 			p.UIN, p.Name, p.GroupID, p.Text, string(imagesJSON),
-			b2i(p.Anon), string(p.Status), p.Reason, p.TID, p.AvatarURL,
+			b2i(p.Anon), string(p.Type), fieldsJSON, pollJSON, p.CW, string(cwImagesJSON), string(p.Status), p.Reason, p.TID, p.AvatarURL, p.SourceMsg, p.IP, p.WallID,
 			now, p.ID,
 		)
 		if err != nil {
@@ -114,6 +304,51 @@ func (s *Store) SavePost(p *model.Post) error {
 	return nil
 }
 
+// ErrStatusChanged 稿件状态已被其他操作改变
+var ErrStatusChanged = errors.New("post status changed")
+
+// UpdatePostStatus 仅当稿件仍为 from 状态时改为 to 并写入 tid 与理由，不覆盖正文等其他字段；
+// 状态已变化时返回 ErrStatusChanged
+func (s *Store) UpdatePostStatus(id int64, from, to model.PostStatus, tid, reason string) error {
+	res, err := s.db.Exec(
+		"UPDATE posts SET status=?, tid=?, reason=?, update_time=? WHERE id=? AND status=?",
+		string(to), tid, reason, time.Now().Unix(), id, string(from),
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrStatusChanged
+	}
+	return nil
+}
+
+// ClaimPost 将仍为 from 状态的稿件改为 to，并返回改后的最新内容。
+// 用于过稿: 先改状态再渲染，之后的修改会因稿件不再待审核而失败
+func (s *Store) ClaimPost(id int64, from, to model.PostStatus) (*model.Post, error) {
+	if err := s.UpdatePostStatus(id, from, to, "", ""); err != nil {
+		return nil, err
+	}
+	return s.GetPost(id)
+}
+
+// UpdatePostCW 仅当稿件仍待审核或已通过 (未发布) 时修改内容警告，不覆盖状态等其他字段；
+// 稿件已发布或被处理时返回 ErrStatusChanged
+func (s *Store) UpdatePostCW(id int64, cw string, images []int) error {
+	cwImagesJSON, _ := json.Marshal(images)
+	res, err := s.db.Exec(
+		"UPDATE posts SET cw=?, cw_images=?, update_time=? WHERE id=? AND status IN (?,?)",
+		cw, string(cwImagesJSON), time.Now().Unix(), id, string(model.StatusPending), string(model.StatusApproved),
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrStatusChanged
+	}
+	return nil
+}
+
 // GetPost 获取单条投稿
 func (s *Store) GetPost(id int64) (*model.Post, error) {
 	row := s.db.QueryRow(postCols("WHERE id=?"), id)
@@ -182,10 +417,10 @@ func (s *Store) GetPostsByIDs(ids []int64) ([]*model.Post, error) {
 	return scanPosts(rows)
 }
 
-// GetApprovedPosts 获取已通过但还未发布(tid=”)的投稿
-func (s *Store) GetApprovedPosts(limit int) ([]*model.Post, error) {
+// GetApprovedPosts 获取某面墙已通过但还未发布(tid=”)的投稿
+func (s *Store) GetApprovedPosts(wallID int64, limit int) ([]*model.Post, error) {
 	rows, err := s.db.Query(
-		postCols("WHERE status='approved' AND tid='' ORDER BY id ASC LIMIT ?"), limit,
+		postCols("WHERE status='approved' AND tid='' AND wall_id=? ORDER BY id ASC LIMIT ?"), wallID, limit,
 	)
 	if err != nil {
 		return nil, err
@@ -210,6 +445,75 @@ func (s *Store) ListAll(limit, offset int) ([]*model.Post, error) {
 	return scanPosts(rows)
 }
 
+// PostFilter 投稿查询条件，零值字段不参与过滤
+type PostFilter struct {
+	UIN    int64 // 投稿者QQ
+	Status model.PostStatus
+	Types  []model.PostType // 为空表示不限类型
+	Walls  []int64          // 所属墙，为空表示不限
+	Limit  int              // <=0 表示不限条数
+	Offset int
+}
+
+func (f PostFilter) where() (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if f.UIN != 0 {
+		conds = append(conds, "uin=?")
+		args = append(args, f.UIN)
+	}
+	if f.Status != "" {
+		conds = append(conds, "status=?")
+		args = append(args, string(f.Status))
+	}
+	if len(f.Types) > 0 {
+		ph := make([]string, len(f.Types))
+		for i, t := range f.Types {
+			ph[i] = "?"
+			args = append(args, string(t))
+		}
+		conds = append(conds, fmt.Sprintf("type IN (%s)", strings.Join(ph, ",")))
+	}
+	if len(f.Walls) > 0 {
+		ph := make([]string, len(f.Walls))
+		for i, id := range f.Walls {
+			ph[i] = "?"
+			args = append(args, id)
+		}
+		conds = append(conds, fmt.Sprintf("wall_id IN (%s)", strings.Join(ph, ",")))
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conds, " AND ") + " ", args
+}
+
+// ListPosts 按条件列出投稿（最新在前）
+func (s *Store) ListPosts(f PostFilter) ([]*model.Post, error) {
+	where, args := f.where()
+	q := postCols(where + "ORDER BY id DESC")
+	if f.Limit > 0 {
+		q += " LIMIT ? OFFSET ?"
+		args = append(args, f.Limit, f.Offset)
+	}
+	rows, err := s.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	return scanPosts(rows)
+}
+
+// CountPosts 统计符合条件的投稿数量
+func (s *Store) CountPosts(f PostFilter) (int, error) {
+	where, args := f.where()
+	var n int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM posts "+where, args...).Scan(&n)
+	return n, err
+}
+
 // CountByStatus 统计各状态数量
 func (s *Store) CountByStatus(status model.PostStatus) (int, error) {
 	var n int
@@ -224,6 +528,519 @@ func (s *Store) CountAll() (int, error) {
 	return n, err
 }
 
+// ──────────────────────────────────────────
+// 投票
+// ──────────────────────────────────────────
+
+// ErrAlreadyVoted 同一投票人重复投票
+var ErrAlreadyVoted = errors.New("already voted")
+
+// CastVote 记录一票 (option 从0开始)，每个投票人每个投票只能投一次
+func (s *Store) CastVote(postID int64, voter string, option int) error {
+	res, err := s.db.Exec(
+		"INSERT OR IGNORE INTO poll_votes (post_id,voter,option,create_time) VALUES (?,?,?,?)",
+		postID, voter, option, time.Now().Unix(),
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAlreadyVoted
+	}
+	return nil
+}
+
+// GetVote 返回投票人已投的选项 (从0开始)，未投票时返回 -1
+func (s *Store) GetVote(postID int64, voter string) (int, error) {
+	var opt int
+	err := s.db.QueryRow("SELECT option FROM poll_votes WHERE post_id=? AND voter=?", postID, voter).Scan(&opt)
+	if err == sql.ErrNoRows {
+		return -1, nil
+	}
+	return opt, err
+}
+
+// PollCounts 统计各选项票数，n 为选项数量，越界的选项忽略
+func (s *Store) PollCounts(postID int64, n int) ([]int, error) {
+	counts := make([]int, n)
+	rows, err := s.db.Query("SELECT option, COUNT(*) FROM poll_votes WHERE post_id=? GROUP BY option", postID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	for rows.Next() {
+		var opt, c int
+		if err := rows.Scan(&opt, &c); err != nil {
+			return nil, err
+		}
+		if opt >= 0 && opt < n {
+			counts[opt] = c
+		}
+	}
+	return counts, rows.Err()
+}
+
+// SavePoll 只更新稿件的投票状态，不覆盖其他字段
+func (s *Store) SavePoll(postID int64, poll *model.Poll) error {
+	raw, err := json.Marshal(poll)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec("UPDATE posts SET poll=?, update_time=? WHERE id=?", string(raw), time.Now().Unix(), postID)
+	return err
+}
+
+// ListOpenPolls 列出某面墙已发布、尚未处理结果的投票
+func (s *Store) ListOpenPolls(wallID int64) ([]*model.Post, error) {
+	rows, err := s.db.Query(
+		postCols("WHERE type=? AND status='published' AND poll!='' AND wall_id=? ORDER BY id ASC"), string(model.TypePoll), wallID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	posts, err := scanPosts(rows)
+	if err != nil {
+		return nil, err
+	}
+	open := posts[:0]
+	for _, p := range posts {
+		if !p.Poll.Closed {
+			open = append(open, p)
+		}
+	}
+	return open, nil
+}
+
+// ──────────────────────────────────────────
+// 修订记录
+// ──────────────────────────────────────────
+
+// ErrNotPending 稿件已不是待审核状态，不能修改
+var ErrNotPending = errors.New("post is not pending")
+
+// EditPostText 修改待审核稿件的正文并记录修订，返回本次修订。
+// 首行为 "CW: 理由" 时同投稿一样设为内容警告，没有标记时保留原有设置
+func (s *Store) EditPostText(postID int64, text, editor string) (*model.Revision, error) {
+	cw, cwImages, text := model.ParseCW(text)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var oldText, status string
+	if err := tx.QueryRow("SELECT text, status FROM posts WHERE id=?", postID).Scan(&oldText, &status); err != nil {
+		return nil, err
+	}
+	if model.PostStatus(status) != model.StatusPending {
+		return nil, ErrNotPending
+	}
+	// 先改正文再记录修订: 同时过稿时按状态条件更新，只有一方能成功
+	now := time.Now().Unix()
+	var res sql.Result
+	if cw != "" {
+		cwImagesJSON, _ := json.Marshal(cwImages)
+		res, err = tx.Exec("UPDATE posts SET text=?, cw=?, cw_images=?, update_time=? WHERE id=? AND status=?",
+			text, cw, string(cwImagesJSON), now, postID, string(model.StatusPending))
+	} else {
+		res, err = tx.Exec("UPDATE posts SET text=?, update_time=? WHERE id=? AND status=?", text, now, postID, string(model.StatusPending))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrNotPending
+	}
+	rev := &model.Revision{PostID: postID, OldText: oldText, NewText: text, Editor: editor, CreateTime: now}
+	res, err = tx.Exec(
+		"INSERT INTO post_revisions (post_id,old_text,new_text,editor,create_time) VALUES (?,?,?,?,?)",
+		rev.PostID, rev.OldText, rev.NewText, rev.Editor, rev.CreateTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	rev.ID, _ = res.LastInsertId()
+	return rev, tx.Commit()
+}
+
+// ListRevisions 列出稿件的修订记录 (最早在前)
+func (s *Store) ListRevisions(postID int64) ([]*model.Revision, error) {
+	rows, err := s.db.Query(
+		"SELECT id,post_id,old_text,new_text,editor,create_time FROM post_revisions WHERE post_id=? ORDER BY id ASC", postID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var revs []*model.Revision
+	for rows.Next() {
+		var r model.Revision
+		if err := rows.Scan(&r.ID, &r.PostID, &r.OldText, &r.NewText, &r.Editor, &r.CreateTime); err != nil {
+			return nil, err
+		}
+		revs = append(revs, &r)
+	}
+	return revs, rows.Err()
+}
+
+// RevisionCounts 统计各稿件的修订次数，没有修订的稿件不在结果中
+func (s *Store) RevisionCounts(ids []int64) (map[int64]int, error) {
+	counts := make(map[int64]int)
+	if len(ids) == 0 {
+		return counts, nil
+	}
+	ph := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		ph[i] = "?"
+		args[i] = id
+	}
+	rows, err := s.db.Query(
+		fmt.Sprintf("SELECT post_id, COUNT(*) FROM post_revisions WHERE post_id IN (%s) GROUP BY post_id", strings.Join(ph, ",")),
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	for rows.Next() {
+		var id int64
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		counts[id] = n
+	}
+	return counts, rows.Err()
+}
+
+// ──────────────────────────────────────────
+// 草稿
+// ──────────────────────────────────────────
+
+// SaveDraft 保存草稿 (同一投稿人同一会话只有一份)
+func (s *Store) SaveDraft(d *model.Draft) error {
+	imagesJSON, _ := json.Marshal(d.Images)
+	_, err := s.db.Exec(
+		`INSERT OR REPLACE INTO drafts (uin,group_id,name,anon,text,images,wall_id,create_time,update_time)
+		 VALUES (?,?,?,?,?,?,?,?,?)`,
+		d.UIN, d.GroupID, d.Name, b2i(d.Anon), d.Text, string(imagesJSON), d.WallID, d.CreateTime, d.UpdateTime,
+	)
+	return err
+}
+
+// GetDraft 获取草稿，不存在时返回 nil
+func (s *Store) GetDraft(uin, groupID int64) (*model.Draft, error) {
+	row := s.db.QueryRow(draftCols("WHERE uin=? AND group_id=?"), uin, groupID)
+	d, err := scanDraft(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return d, err
+}
+
+// DeleteDraft 删除草稿
+func (s *Store) DeleteDraft(uin, groupID int64) error {
+	_, err := s.db.Exec("DELETE FROM drafts WHERE uin=? AND group_id=?", uin, groupID)
+	return err
+}
+
+// ListDraftsBefore 列出最后更新早于 before 的草稿
+func (s *Store) ListDraftsBefore(before int64) ([]*model.Draft, error) {
+	rows, err := s.db.Query(draftCols("WHERE update_time < ?"), before)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var drafts []*model.Draft
+	for rows.Next() {
+		d, err := scanDraft(rows)
+		if err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, d)
+	}
+	return drafts, rows.Err()
+}
+
+// ──────────────────────────────────────────
+// 管理群通知消息
+// ──────────────────────────────────────────
+
+// SaveNoticeMsg 记录管理群通知消息对应的稿件，用于回复通知审核
+func (s *Store) SaveNoticeMsg(msgID, postID int64) error {
+	_, err := s.db.Exec(
+		"INSERT OR REPLACE INTO notice_msgs (msg_id,post_id,create_time) VALUES (?,?,?)",
+		msgID, postID, time.Now().Unix(),
+	)
+	return err
+}
+
+// GetNoticePost 查询通知消息对应的稿件ID，未记录时返回 0
+func (s *Store) GetNoticePost(msgID int64) (int64, error) {
+	var postID int64
+	err := s.db.QueryRow("SELECT post_id FROM notice_msgs WHERE msg_id=?", msgID).Scan(&postID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return postID, err
+}
+
+// ──────────────────────────────────────────
+// 匿名回复转达
+// ──────────────────────────────────────────
+
+// SaveRelay 记录一条转达，写入后回填 r.ID
+func (s *Store) SaveRelay(r *model.Relay) error {
+	res, err := s.db.Exec(
+		"INSERT INTO relay_msgs (post_id,from_uin,to_uin,text,msg_id,create_time) VALUES (?,?,?,?,?,?)",
+		r.PostID, r.FromUIN, r.ToUIN, r.Text, r.MsgID, r.CreateTime,
+	)
+	if err != nil {
+		return err
+	}
+	r.ID, err = res.LastInsertId()
+	return err
+}
+
+// SetRelayMsgID 记录转达送达后的私聊消息ID
+func (s *Store) SetRelayMsgID(id, msgID int64) error {
+	_, err := s.db.Exec("UPDATE relay_msgs SET msg_id=? WHERE id=?", msgID, id)
+	return err
+}
+
+// GetRelay 按会话编号获取转达，不存在时返回 nil
+func (s *Store) GetRelay(id int64) (*model.Relay, error) {
+	return scanRelay(s.db.QueryRow(relayCols("WHERE id=?"), id))
+}
+
+// GetRelayByMsg 按机器人发出的私聊消息ID获取转达，不存在时返回 nil
+func (s *Store) GetRelayByMsg(msgID int64) (*model.Relay, error) {
+	if msgID == 0 {
+		return nil, nil
+	}
+	return scanRelay(s.db.QueryRow(relayCols("WHERE msg_id=? ORDER BY id DESC LIMIT 1"), msgID))
+}
+
+// CountRelaysSince 统计某QQ号自 since 以来发起的转达次数，用于限流
+func (s *Store) CountRelaysSince(fromUIN, since int64) (int, error) {
+	var n int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM relay_msgs WHERE from_uin=? AND create_time>=?", fromUIN, since).Scan(&n)
+	return n, err
+}
+
+// ListRelays 列出稿件的全部转达记录，按时间顺序
+func (s *Store) ListRelays(postID int64) ([]*model.Relay, error) {
+	rows, err := s.db.Query(relayCols("WHERE post_id=? ORDER BY id ASC"), postID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var relays []*model.Relay
+	for rows.Next() {
+		r, err := scanRelay(rows)
+		if err != nil {
+			return nil, err
+		}
+		relays = append(relays, r)
+	}
+	return relays, rows.Err()
+}
+
+// SetRelayBlock uin 屏蔽 (blocked 为 false 时解除屏蔽) blockedUIN 发来的转达
+func (s *Store) SetRelayBlock(uin, blockedUIN int64, blocked bool) error {
+	if !blocked {
+		_, err := s.db.Exec("DELETE FROM relay_blocks WHERE uin=? AND blocked_uin=?", uin, blockedUIN)
+		return err
+	}
+	_, err := s.db.Exec(
+		"INSERT OR REPLACE INTO relay_blocks (uin,blocked_uin,create_time) VALUES (?,?,?)",
+		uin, blockedUIN, time.Now().Unix(),
+	)
+	return err
+}
+
+// IsRelayBlocked uin 是否屏蔽了 fromUIN
+func (s *Store) IsRelayBlocked(uin, fromUIN int64) (bool, error) {
+	var n int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM relay_blocks WHERE uin=? AND blocked_uin=?", uin, fromUIN).Scan(&n)
+	return n > 0, err
+}
+
+// SetRelayOptOut 设置QQ号是否拒收匿名回复
+func (s *Store) SetRelayOptOut(uin int64, optOut bool) error {
+	if !optOut {
+		_, err := s.db.Exec("DELETE FROM relay_optouts WHERE uin=?", uin)
+		return err
+	}
+	_, err := s.db.Exec("INSERT OR REPLACE INTO relay_optouts (uin,create_time) VALUES (?,?)", uin, time.Now().Unix())
+	return err
+}
+
+// RelayOptedOut QQ号是否已拒收匿名回复
+func (s *Store) RelayOptedOut(uin int64) (bool, error) {
+	var n int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM relay_optouts WHERE uin=?", uin).Scan(&n)
+	return n > 0, err
+}
+
+// ──────────────────────────────────────────
+// 封禁
+// ──────────────────────────────────────────
+
+// SaveBan 新增封禁，同一对象已有封禁时更新时长、理由 (以本次为准)，写入后回填 b.ID。
+// 已关联稿件的封禁保留原稿件编号，避免之后按对象操作时暴露作者身份
+func (s *Store) SaveBan(b *model.Ban) error {
+	_, err := s.db.Exec(
+		`INSERT INTO bans (kind,target,post_id,reason,operator,expire_time,create_time)
+		 VALUES (?,?,?,?,?,?,?)
+		 ON CONFLICT (kind,target) DO UPDATE SET
+			post_id=CASE WHEN bans.post_id>0 THEN bans.post_id ELSE excluded.post_id END,
+			reason=excluded.reason, operator=excluded.operator,
+			expire_time=excluded.expire_time, create_time=excluded.create_time`,
+		b.Kind, b.Target, b.PostID, b.Reason, b.Operator, b.ExpireTime, b.CreateTime,
+	)
+	if err != nil {
+		return err
+	}
+	return s.db.QueryRow("SELECT id FROM bans WHERE kind=? AND target=?", b.Kind, b.Target).Scan(&b.ID)
+}
+
+// GetBan 按编号获取封禁，不存在时返回 nil
+func (s *Store) GetBan(id int64) (*model.Ban, error) {
+	return scanBan(s.db.QueryRow(banCols("WHERE id=?"), id))
+}
+
+// FindBan 按对象获取封禁 (含已到期)，不存在时返回 nil。
+// 按稿件拉黑的封禁不在此列，否则可借此确认某QQ号是否为稿件作者
+func (s *Store) FindBan(kind, target string) (*model.Ban, error) {
+	return scanBan(s.db.QueryRow(banCols("WHERE kind=? AND target=? AND post_id=0"), kind, target))
+}
+
+// FindPostBan 获取按稿件拉黑作者创建的封禁，不存在时返回 nil
+func (s *Store) FindPostBan(postID int64) (*model.Ban, error) {
+	return scanBan(s.db.QueryRow(banCols("WHERE post_id=? ORDER BY id DESC LIMIT 1"), postID))
+}
+
+// ActiveBan 获取对象当前生效的封禁，未封禁或已到期时返回 nil
+func (s *Store) ActiveBan(kind, target string) (*model.Ban, error) {
+	if target == "" || target == "0" {
+		return nil, nil
+	}
+	return scanBan(s.db.QueryRow(banCols("WHERE kind=? AND target=? AND (expire_time=0 OR expire_time>?)"),
+		kind, target, time.Now().Unix()))
+}
+
+// ListBans 列出当前生效的封禁，最新的在前
+func (s *Store) ListBans() ([]*model.Ban, error) {
+	rows, err := s.db.Query(banCols("WHERE expire_time=0 OR expire_time>? ORDER BY id DESC"), time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var bans []*model.Ban
+	for rows.Next() {
+		b, err := scanBan(rows)
+		if err != nil {
+			return nil, err
+		}
+		bans = append(bans, b)
+	}
+	return bans, rows.Err()
+}
+
+// DeleteBan 解除封禁
+func (s *Store) DeleteBan(id int64) error {
+	_, err := s.db.Exec("DELETE FROM bans WHERE id=?", id)
+	return err
+}
+
+// ──────────────────────────────────────────
+// 群登记
+// ──────────────────────────────────────────
+
+// SaveGroupSetting 保存群设置
+func (s *Store) SaveGroupSetting(g *model.GroupSetting) error {
+	_, err := s.db.Exec(
+		`INSERT OR REPLACE INTO group_settings (group_id,name,enabled,anon,notify,wall_id,updated_by,update_time)
+		 VALUES (?,?,?,?,?,?,?,?)`,
+		g.GroupID, g.Name, b2i(g.Enabled), g.Anon, g.Notify, g.WallID, g.UpdatedBy, g.UpdateTime,
+	)
+	return err
+}
+
+// GetGroupSetting 获取群设置，未登记时返回 nil
+func (s *Store) GetGroupSetting(groupID int64) (*model.GroupSetting, error) {
+	return scanGroupSetting(s.db.QueryRow(groupSettingCols("WHERE group_id=?"), groupID))
+}
+
+// ListGroupSettings 列出全部已登记的群
+func (s *Store) ListGroupSettings() ([]*model.GroupSetting, error) {
+	rows, err := s.db.Query(groupSettingCols("ORDER BY enabled DESC, group_id ASC"))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var groups []*model.GroupSetting
+	for rows.Next() {
+		g, err := scanGroupSetting(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// DeleteGroupSetting 删除群登记，之后该群按未登记处理
+func (s *Store) DeleteGroupSetting(groupID int64) error {
+	_, err := s.db.Exec("DELETE FROM group_settings WHERE group_id=?", groupID)
+	return err
+}
+
+// ──────────────────────────────────────────
+// 墙登录态
+// ──────────────────────────────────────────
+
+// SaveWallCookie 保存墙的QQ空间 Cookie，重启后无需重新扫码
+func (s *Store) SaveWallCookie(wallID int64, cookie string) error {
+	_, err := s.db.Exec(
+		"INSERT OR REPLACE INTO wall_cookies (wall_id,cookie,update_time) VALUES (?,?,?)",
+		wallID, cookie, time.Now().Unix(),
+	)
+	return err
+}
+
+// GetWallCookie 读取墙保存的 Cookie，没有时返回空串
+func (s *Store) GetWallCookie(wallID int64) (string, error) {
+	var cookie string
+	err := s.db.QueryRow("SELECT cookie FROM wall_cookies WHERE wall_id=?", wallID).Scan(&cookie)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return cookie, err
+}
+
 // ──────────────────────────────────────────
 // Account CRUD
 // ──────────────────────────────────────────
@@ -237,27 +1054,39 @@ func (s *Store) CreateAccount(username, passwordHash, salt, role string) error {
 }
 
 func (s *Store) GetAccount(username string) (*model.Account, error) {
-	var a model.Account
-	err := s.db.QueryRow(
-		"SELECT id,username,password_hash,salt,role,create_time FROM accounts WHERE username=?",
-		username,
-	).Scan(&a.ID, &a.Username, &a.PasswordHash, &a.Salt, &a.Role, &a.CreateTime)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
-	return &a, err
+	return scanAccount(s.db.QueryRow(accountCols("WHERE username=?"), username))
 }
 
 func (s *Store) GetAccountByID(id int64) (*model.Account, error) {
-	var a model.Account
-	err := s.db.QueryRow(
-		"SELECT id,username,password_hash,salt,role,create_time FROM accounts WHERE id=?",
-		id,
-	).Scan(&a.ID, &a.Username, &a.PasswordHash, &a.Salt, &a.Role, &a.CreateTime)
-	if err == sql.ErrNoRows {
+	return scanAccount(s.db.QueryRow(accountCols("WHERE id=?"), id))
+}
+
+// GetAccountByQQ 获取绑定了该QQ号的账号，不存在时返回 nil
+func (s *Store) GetAccountByQQ(qq int64) (*model.Account, error) {
+	if qq == 0 {
 		return nil, nil
 	}
-	return &a, err
+	return scanAccount(s.db.QueryRow(accountCols("WHERE qq=? ORDER BY id LIMIT 1"), qq))
+}
+
+// ListAccounts 列出全部账号
+func (s *Store) ListAccounts() ([]*model.Account, error) {
+	rows, err := s.db.Query(accountCols("ORDER BY id ASC"))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var accounts []*model.Account
+	for rows.Next() {
+		a, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
 }
 
 func (s *Store) AccountCount() (int, error) {
@@ -266,6 +1095,21 @@ func (s *Store) AccountCount() (int, error) {
 	return n, err
 }
 
+// UpdateAccountRole 修改账号角色和绑定的QQ号
+func (s *Store) UpdateAccountRole(id int64, role string, qq int64) error {
+	_, err := s.db.Exec("UPDATE accounts SET role=?, qq=? WHERE id=?", role, qq, id)
+	return err
+}
+
+// DeleteAccount 删除账号及其会话
+func (s *Store) DeleteAccount(id int64) error {
+	if _, err := s.db.Exec("DELETE FROM sessions WHERE account_id=?", id); err != nil {
+		return err
+	}
+	_, err := s.db.Exec("DELETE FROM accounts WHERE id=?", id)
+	return err
+}
+
 // UpdateAccountPassword 更新账号密码
 func (s *Store) UpdateAccountPassword(username, passwordHash, salt string) error {
 	_, err := s.db.Exec(
@@ -275,6 +1119,53 @@ func (s *Store) UpdateAccountPassword(username, passwordHash, salt string) error
 	return err
 }
 
+// ──────────────────────────────────────────
+// QQ 角色
+// ──────────────────────────────────────────
+
+// SetQQRole 授予QQ号角色，role 为 RoleUser 时收回
+func (s *Store) SetQQRole(uin int64, role string, grantedBy int64) error {
+	if role == model.RoleUser {
+		_, err := s.db.Exec("DELETE FROM qq_roles WHERE uin=?", uin)
+		return err
+	}
+	_, err := s.db.Exec(
+		"INSERT OR REPLACE INTO qq_roles (uin,role,granted_by,create_time) VALUES (?,?,?,?)",
+		uin, role, grantedBy, time.Now().Unix(),
+	)
+	return err
+}
+
+// GetQQRole 获取QQ号被授予的角色，未授权时返回 RoleUser
+func (s *Store) GetQQRole(uin int64) (string, error) {
+	var role string
+	err := s.db.QueryRow("SELECT role FROM qq_roles WHERE uin=?", uin).Scan(&role)
+	if err == sql.ErrNoRows {
+		return model.RoleUser, nil
+	}
+	return role, err
+}
+
+// ListQQRoles 列出全部QQ授权
+func (s *Store) ListQQRoles() ([]*model.QQRole, error) {
+	rows, err := s.db.Query("SELECT uin,role,granted_by,create_time FROM qq_roles ORDER BY create_time ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var roles []*model.QQRole
+	for rows.Next() {
+		var r model.QQRole
+		if err := rows.Scan(&r.UIN, &r.Role, &r.GrantedBy, &r.CreateTime); err != nil {
+			return nil, err
+		}
+		roles = append(roles, &r)
+	}
+	return roles, rows.Err()
+}
+
 // ──────────────────────────────────────────
 // Session CRUD
 // ──────────────────────────────────────────
@@ -365,39 +1256,138 @@ func CheckCensor(text string, words []string) (bool, string) {
 // ──────────────────────────────────────────
 
 func postCols(where string) string {
-	return "SELECT id,uin,name,group_id,text,images,anon,status,reason,tid,avatar_url,create_time,update_time FROM posts " + where
+	return "SELECT id,uin,name,group_id,text,images,anon,type,fields,poll,cw,cw_images,status,reason,tid,avatar_url,source_msg,ip,wall_id,create_time,update_time FROM posts " + where
 }
 
-func scanPost(row *sql.Row) (*model.Post, error) {
+// rowScanner 兼容 *sql.Row 与 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanPostFields(sc rowScanner) (*model.Post, error) {
 	var p model.Post
+	var imgs, fields, poll, cwImgs string
+	var anon int
+	if err := sc.Scan(&p.ID, &p.UIN, &p.Name, &p.GroupID, &p.Text, &imgs, &anon, &p.Type, &fields, &poll, &p.CW, &cwImgs,
+		&p.Status, &p.Reason, &p.TID, &p.AvatarURL, &p.SourceMsg, &p.IP, &p.WallID, &p.CreateTime, &p.UpdateTime); err != nil {
+		return nil, err
+	}
+	p.Anon = anon != 0
+	_ = json.Unmarshal([]byte(imgs), &p.Images)
+	_ = json.Unmarshal([]byte(cwImgs), &p.CWImages)
+	if fields != "" {
+		p.Fields = &model.PostFields{}
+		_ = json.Unmarshal([]byte(fields), p.Fields)
+	}
+	if poll != "" {
+		p.Poll = &model.Poll{}
+		_ = json.Unmarshal([]byte(poll), p.Poll)
+	}
+	return &p, nil
+}
+
+func accountCols(where string) string {
+	return "SELECT id,username,password_hash,salt,role,qq,create_time FROM accounts " + where
+}
+
+// scanAccount 扫描一行账号，*sql.Row 无结果时返回 nil
+func scanAccount(sc rowScanner) (*model.Account, error) {
+	var a model.Account
+	err := sc.Scan(&a.ID, &a.Username, &a.PasswordHash, &a.Salt, &a.Role, &a.QQ, &a.CreateTime)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func draftCols(where string) string {
+	return "SELECT uin,group_id,name,anon,text,images,wall_id,create_time,update_time FROM drafts " + where
+}
+
+func scanDraft(sc rowScanner) (*model.Draft, error) {
+	var d model.Draft
 	var imgs string
 	var anon int
-	err := row.Scan(&p.ID, &p.UIN, &p.Name, &p.GroupID, &p.Text, &imgs, &anon,
-		&p.Status, &p.Reason, &p.TID, &p.AvatarURL, &p.CreateTime, &p.UpdateTime)
+	if err := sc.Scan(&d.UIN, &d.GroupID, &d.Name, &anon, &d.Text, &imgs, &d.WallID, &d.CreateTime, &d.UpdateTime); err != nil {
+		return nil, err
+	}
+	d.Anon = anon != 0
+	_ = json.Unmarshal([]byte(imgs), &d.Images)
+	return &d, nil
+}
+
+func groupSettingCols(where string) string {
+	return "SELECT group_id,name,enabled,anon,notify,wall_id,updated_by,update_time FROM group_settings " + where
+}
+
+// scanGroupSetting 扫描一行群设置，*sql.Row 无结果时返回 nil
+func scanGroupSetting(sc rowScanner) (*model.GroupSetting, error) {
+	var g model.GroupSetting
+	var enabled int
+	err := sc.Scan(&g.GroupID, &g.Name, &enabled, &g.Anon, &g.Notify, &g.WallID, &g.UpdatedBy, &g.UpdateTime)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	p.Anon = anon != 0
-	_ = json.Unmarshal([]byte(imgs), &p.Images)
-	return &p, nil
+	g.Enabled = enabled != 0
+	return &g, nil
+}
+
+func banCols(where string) string {
+	return "SELECT id,kind,target,post_id,reason,operator,expire_time,create_time FROM bans " + where
+}
+
+// scanBan 扫描一行封禁，*sql.Row 无结果时返回 nil
+func scanBan(sc rowScanner) (*model.Ban, error) {
+	var b model.Ban
+	err := sc.Scan(&b.ID, &b.Kind, &b.Target, &b.PostID, &b.Reason, &b.Operator, &b.ExpireTime, &b.CreateTime)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func relayCols(where string) string {
+	return "SELECT id,post_id,from_uin,to_uin,text,msg_id,create_time FROM relay_msgs " + where
+}
+
+// scanRelay 扫描一行转达记录，*sql.Row 无结果时返回 nil
+func scanRelay(sc rowScanner) (*model.Relay, error) {
+	var r model.Relay
+	err := sc.Scan(&r.ID, &r.PostID, &r.FromUIN, &r.ToUIN, &r.Text, &r.MsgID, &r.CreateTime)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func scanPost(row *sql.Row) (*model.Post, error) {
+	p, err := scanPostFields(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
 }
 
 func scanPosts(rows *sql.Rows) ([]*model.Post, error) {
 	var posts []*model.Post
 	for rows.Next() {
-		var p model.Post
-		var imgs string
-		var anon int
-		if err := rows.Scan(&p.ID, &p.UIN, &p.Name, &p.GroupID, &p.Text, &imgs, &anon,
-			&p.Status, &p.Reason, &p.TID, &p.AvatarURL, &p.CreateTime, &p.UpdateTime); err != nil {
+		p, err := scanPostFields(rows)
+		if err != nil {
 			return nil, err
 		}
-		p.Anon = anon != 0
-		_ = json.Unmarshal([]byte(imgs), &p.Images)
-		posts = append(posts, &p)
+		posts = append(posts, p)
 	}
 	return posts, rows.Err()
 }
@@ -12,15 +12,18 @@ import (
 
 	qzone "github.com/guohuiyuan/qzone-go"
 	"github.com/guohuiyuan/qzonewall-go/internal/config"
+	"github.com/guohuiyuan/qzonewall-go/internal/store"
+	"github.com/guohuiyuan/qzonewall-go/internal/wall"
 	"github.com/mdp/qrterminal/v3"
 	"github.com/tuotoo/qrcode"
 	zero "github.com/wdvxdr1123/ZeroBot"
 	"github.com/wdvxdr1123/ZeroBot/message"
 )
 
-// KeepAlive 定期校验 QQ 空间 Cookie 有效性并自动刷新。
+// KeepAlive 定期校验一面墙的 QQ 空间 Cookie 有效性并自动刷新。
 type KeepAlive struct {
 	cfg    *config.Config
+	wall   *wall.Wall
 	client *qzone.Client
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -31,9 +34,9 @@ type CookieResult struct {
 	Err    error
 }
 
-func NewKeepAlive(cfg *config.Config, client *qzone.Client) *KeepAlive {
+func NewKeepAlive(cfg *config.Config, w *wall.Wall) *KeepAlive {
 	ctx, cancel := context.WithCancel(context.Background())
-	return &KeepAlive{cfg: cfg, client: client, ctx: ctx, cancel: cancel}
+	return &KeepAlive{cfg: cfg, wall: w, client: w.Client, ctx: ctx, cancel: cancel}
 }
 
 func (k *KeepAlive) Start() {
@@ -42,7 +45,7 @@ func (k *KeepAlive) Start() {
 		return
 	}
 	go k.run()
-	log.Printf("[KeepAlive] started, interval=%v", k.cfg.Qzone.KeepAlive.Duration)
+	log.Printf("[KeepAlive] %s: started, interval=%v", k.wall.Name, k.cfg.Qzone.KeepAlive.Duration)
 }
 
 func (k *KeepAlive) Stop() { k.cancel() }
@@ -63,18 +66,22 @@ func (k *KeepAlive) run() {
 }
 
 func (k *KeepAlive) check() {
-	log.Println("[KeepAlive] validating cookie via GetUserInfo...")
+	log.Printf("[KeepAlive] %s: validating cookie via GetUserInfo...", k.wall.Name)
 	if _, err := validateCookieWithUserInfo(k.ctx, k.client); err == nil {
-		log.Println("[KeepAlive] cookie valid")
+		log.Printf("[KeepAlive] %s: cookie valid", k.wall.Name)
 		return
 	}
 
-	log.Println("[KeepAlive] cookie invalid, trying refresh from bot")
-	if k.tryRefreshFromBot() {
+	// 附加墙使用独立账号，机器人的 Cookie 属于默认墙，只能扫码刷新
+	if k.wall.ID == wall.DefaultID {
+		log.Println("[KeepAlive] cookie invalid, trying refresh from bot")
+		if k.tryRefreshFromBot() {
+			return
+		}
+		k.notifyAdmin("⚠️ QQ空间 Cookie 已过期，请使用 /扫码 或 /刷新cookie 重新登录")
 		return
 	}
-
-	k.notifyAdmin("⚠️ QQ空间 Cookie 已过期，请使用 /扫码 或 /刷新cookie 重新登录")
+	k.notifyAdmin(fmt.Sprintf("⚠️ %s 的QQ空间 Cookie 已过期，请使用 /扫码 %s 重新登录", k.wall.Name, k.wall.Name))
 }
 
 func (k *KeepAlive) tryRefreshFromBot() bool {
@@ -85,7 +92,7 @@ func (k *KeepAlive) tryRefreshFromBot() bool {
 		if cookie == "" {
 			return true
 		}
-		if err := k.client.UpdateCookie(cookie); err != nil {
+		if err := k.wall.UpdateCookie(cookie); err != nil {
 			log.Printf("[KeepAlive] refresh from bot(%d) failed: %v", id, err)
 			return true
 		}
@@ -98,7 +105,8 @@ func (k *KeepAlive) tryRefreshFromBot() bool {
 
 // EnsureCookieValidOnStartup validates cookie once during startup and
 // attempts a single refresh flow when invalid.
-func EnsureCookieValidOnStartup(cfg *config.Config, client *qzone.Client) error {
+func EnsureCookieValidOnStartup(cfg *config.Config, w *wall.Wall) error {
+	client := w.Client
 	if client == nil {
 		return fmt.Errorf("nil qzone client")
 	}
@@ -122,12 +130,12 @@ func EnsureCookieValidOnStartup(cfg *config.Config, client *qzone.Client) error
 		return nil
 	}
 
-	refreshFn := RefreshCookie(cfg)
+	refreshFn := RefreshCookie(cfg, w)
 	newCookie, refreshErr := refreshFn()
 	if refreshErr != nil {
 		return fmt.Errorf("startup refresh failed: %w", refreshErr)
 	}
-	if updateErr := client.UpdateCookie(newCookie); updateErr != nil {
+	if updateErr := w.UpdateCookie(newCookie); updateErr != nil {
 		return fmt.Errorf("startup update cookie failed: %w", updateErr)
 	}
 
@@ -146,15 +154,37 @@ func validateCookieWithUserInfo(parent context.Context, client *qzone.Client) (*
 }
 
 func (k *KeepAlive) notifyAdmin(text string) {
-	if k.cfg.Bot.ManageGroup <= 0 {
+	notifyManageGroup(k.wall, text)
+}
+
+// notifyManageGroup 向墙的审核群发送登录态提醒
+func notifyManageGroup(w *wall.Wall, text string) {
+	group := w.ManageGroup()
+	if group <= 0 {
 		return
 	}
 	zero.RangeBot(func(id int64, ctx *zero.Ctx) bool {
-		ctx.SendGroupMessage(k.cfg.Bot.ManageGroup, message.Text(text))
+		ctx.SendGroupMessage(group, message.Text(text))
 		return false
 	})
 }
 
+// RestoreWallCookie 启动时恢复附加墙上次扫码保存的 Cookie。
+// 附加墙使用独立账号，不能从机器人获取 Cookie，没有保存或已失效时需 /扫码 <墙名> 登录。
+func RestoreWallCookie(cfg *config.Config, st *store.Store, w *wall.Wall) error {
+	cookie, err := st.GetWallCookie(w.ID)
+	if err != nil {
+		return fmt.Errorf("load saved cookie: %w", err)
+	}
+	if cookie == "" {
+		return fmt.Errorf("no saved cookie, use /扫码 %s or web admin QR login", w.Name)
+	}
+	if err := w.Client.UpdateCookie(cookie); err != nil {
+		return fmt.Errorf("restore cookie: %w", err)
+	}
+	return EnsureCookieValidOnStartup(cfg, w)
+}
+
 // TryGetCookie sources cookie from two methods in fixed order:
 // 1) ZeroBot GetCookies
 // 2) QR login
@@ -197,20 +227,22 @@ func TryGetCookieAsync(cfg *config.Config) <-chan CookieResult {
 }
 
 // RefreshCookie is used by qzone.WithOnSessionExpired callback.
-func RefreshCookie(cfg *config.Config) func() (string, error) {
+// Only the default wall shares the bot account, other walls must scan QR.
+func RefreshCookie(_ *config.Config, w *wall.Wall) func() (string, error) {
 	return func() (string, error) {
+		if w.ID != wall.DefaultID {
+			log.Printf("[SessionExpired] %s: cookie expired", w.Name)
+			notifyManageGroup(w, fmt.Sprintf("⚠️ %s 的QQ空间 Cookie 过期，请使用 /扫码 %s 重新登录", w.Name, w.Name))
+			return "", fmt.Errorf("cookie refresh failed; please scan QR manually")
+		}
+
 		log.Println("[SessionExpired] cookie expired, trying bot GetCookies...")
 		cookie, ok := tryGetCookieFromBots("[SessionExpired]")
 		if ok {
 			return cookie, nil
 		}
 
-		if cfg.Bot.ManageGroup > 0 {
-			zero.RangeBot(func(id int64, ctx *zero.Ctx) bool {
-				ctx.SendGroupMessage(cfg.Bot.ManageGroup, message.Text("⚠️ QQ空间 Cookie 过期，GetCookies 刷新失败，请使用 /扫码 重新登录"))
-				return false
-			})
-		}
+		notifyManageGroup(w, "⚠️ QQ空间 Cookie 过期，GetCookies 刷新失败，请使用 /扫码 重新登录")
 		return "", fmt.Errorf("cookie refresh failed; please scan QR manually")
 	}
 }
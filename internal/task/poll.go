@@ -0,0 +1,98 @@
+package task
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+)
+
+// pollCloseInterval 检查投票是否到期的间隔
+const pollCloseInterval = time.Minute
+
+// runPollCloser 定期结束到期的投票并发布结果
+func (w *Worker) runPollCloser() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(pollCloseInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+			w.closeExpiredPolls()
+		}
+	}
+}
+
+// closeExpiredPolls 为已到截止时间的投票渲染结果图并发布新说说。
+// 发布失败的投票保持未结束状态，下一轮重试。
+func (w *Worker) closeExpiredPolls() {
+	polls, err := w.store.ListOpenPolls(w.wallID)
+	if err != nil {
+		log.Printf("[Poll] 查询投票失败: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, post := range polls {
+		if post.Poll.Open(now) {
+			continue
+		}
+		if err := w.publishPollResult(post); err != nil {
+			log.Printf("[Poll] 投票 #%d 结果发布失败: %v", post.ID, err)
+			continue
+		}
+		log.Printf("[Poll] 投票 #%d 已结束, 结果 tid=%s", post.ID, post.Poll.ResultTID)
+	}
+}
+
+func (w *Worker) publishPollResult(post *model.Post) error {
+	counts, err := w.store.PollCounts(post.ID, len(post.Poll.Options))
+	if err != nil {
+		return fmt.Errorf("统计票数: %w", err)
+	}
+	if !w.renderer.Available() {
+		return fmt.Errorf("renderer not available")
+	}
+	pages, err := w.renderer.RenderPollResult(w.resolvePostImages(post), counts)
+	if err != nil {
+		return fmt.Errorf("render result: %w", err)
+	}
+
+	total, best := 0, 0
+	for _, c := range counts {
+		total += c
+		best = max(best, c)
+	}
+	text := fmt.Sprintf("【投票结果】%s\n共 %d 票", post.Text, total)
+	if best > 0 {
+		var leaders []string
+		for i, c := range counts {
+			if c == best {
+				leaders = append(leaders, "「"+post.Poll.Options[i]+"」")
+			}
+		}
+		if len(leaders) == 1 {
+			text += fmt.Sprintf("，%s以 %d 票胜出", leaders[0], best)
+		} else {
+			text += fmt.Sprintf("，%s并列第一 (%d 票)", strings.Join(leaders, ""), best)
+		}
+	}
+
+	w.waitRateLimit()
+	tid, parts, err := w.wall.Publish(w.ctx, text, pages)
+	if parts == 0 {
+		return fmt.Errorf("publish: %w", err)
+	}
+	w.mu.Lock()
+	w.lastPublish = time.Now()
+	w.mu.Unlock()
+
+	post.Poll.Closed = true
+	post.Poll.ResultTID = tid
+	return w.store.SavePoll(post.ID, post.Poll)
+}
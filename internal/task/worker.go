@@ -8,21 +8,25 @@ import (
 	"sync"
 	"time"
 
-	qzone "github.com/guohuiyuan/qzone-go"
+	"github.com/guohuiyuan/qzonewall-go/internal/caption"
 	"github.com/guohuiyuan/qzonewall-go/internal/config"
 	"github.com/guohuiyuan/qzonewall-go/internal/model"
 	"github.com/guohuiyuan/qzonewall-go/internal/render"
 	"github.com/guohuiyuan/qzonewall-go/internal/store"
+	"github.com/guohuiyuan/qzonewall-go/internal/wall"
 
 	zero "github.com/wdvxdr1123/ZeroBot" // 新增引入
 )
 
-// Worker 定时轮询已通过稿件并发布到 QQ 空间。
+// Worker 定时轮询一面墙已通过的稿件并发布到该墙的 QQ 空间。
 type Worker struct {
 	cfg         *config.Config
-	client      *qzone.Client
+	wallID      int64
+	wallName    string
+	wall        *wall.Wall
 	store       *store.Store
 	renderer    *render.Renderer
+	captions    *caption.Captions
 	ctx         context.Context
 	cancel      context.CancelFunc
 	wg          sync.WaitGroup
@@ -30,19 +34,22 @@ type Worker struct {
 	mu          sync.Mutex
 }
 
-// NewWorker creates a worker.
+// NewWorker creates a worker for one wall.
 func NewWorker(
 	cfg *config.Config,
-	client *qzone.Client,
+	w *wall.Wall,
 	st *store.Store,
-	renderer *render.Renderer,
+	captions *caption.Captions,
 ) *Worker {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Worker{
 		cfg:      cfg,
-		client:   client,
+		wallID:   w.ID,
+		wallName: w.Name,
+		wall:     w,
 		store:    st,
-		renderer: renderer,
+		renderer: w.Renderer,
+		captions: captions,
 		ctx:      ctx,
 		cancel:   cancel,
 	}
@@ -54,14 +61,16 @@ func (w *Worker) Start() {
 		w.wg.Add(1)
 		go w.run(i)
 	}
-	log.Printf("[Worker] 启动 %d 个工作协程，轮询间隔=%v", w.cfg.Worker.Workers, w.cfg.Worker.PollInterval.Duration)
+	w.wg.Add(1)
+	go w.runPollCloser()
+	log.Printf("[Worker] %s: 启动 %d 个工作协程，轮询间隔=%v", w.wallName, w.cfg.Worker.Workers, w.cfg.Worker.PollInterval.Duration)
 }
 
 // Stop 优雅停止。
 func (w *Worker) Stop() {
 	w.cancel()
 	w.wg.Wait()
-	log.Println("[Worker] stopped")
+	log.Printf("[Worker] %s: stopped", w.wallName)
 }
 
 func (w *Worker) run(id int) {
@@ -84,7 +93,7 @@ func (w *Worker) run(id int) {
 
 func (w *Worker) pollAndPublish(workerID int) {
 	// 拉取已通过但未发布的稿件 (tid='')。
-	posts, err := w.store.GetApprovedPosts(1)
+	posts, err := w.store.GetApprovedPosts(w.wallID, 1)
 	if err != nil {
 		log.Printf("[Worker-%d] 查询失败: %v", workerID, err)
 		return
@@ -117,9 +126,7 @@ func (w *Worker) pollAndPublish(workerID int) {
 	}
 
 	// 所有重试失败后标记为失败。
-	post.Status = model.StatusFailed
-	post.Reason = fmt.Sprintf("发布失败: %v", lastErr)
-	if err := w.store.SavePost(post); err != nil {
+	if err := w.store.UpdatePostStatus(post.ID, model.StatusApproved, model.StatusFailed, post.TID, fmt.Sprintf("发布失败: %v", lastErr)); err != nil {
 		log.Printf("[Worker-%d] 更新状态失败: %v", workerID, err)
 	}
 	log.Printf("[Worker-%d] 稿件 #%d 最终发布失败: %v", workerID, post.ID, lastErr)
@@ -127,48 +134,54 @@ func (w *Worker) pollAndPublish(workerID int) {
 
 // publish 发布到 QQ 空间。
 func (w *Worker) publish(post *model.Post) error {
-	// 构建说说文本。
-	text := post.Text
-	if w.cfg.Wall.ShowAuthor && !post.Anon {
-		text = fmt.Sprintf("【来自 %s 的投稿】\n\n%s", post.ShowName(), text)
-	}
+	// 按配置模板生成说说文本。
+	text := w.captions.Post(post)
 
 	// Only publish rendered screenshot, never raw images.
 	if !w.renderer.Available() {
 		return fmt.Errorf("publish: renderer not available")
 	}
 
-	// 渲染前解析 file ID 为 URL
-	renderPost := w.resolvePostImages(post)
-	screenshot, err := w.renderer.RenderPost(renderPost)
+	// 优先复用预览时的渲染结果，未命中时解析 file ID 为 URL 后渲染；
+	// 同一会话内附原图时复用已下载的图片
+	renderer := w.renderer.Session()
+	pages, err := renderer.RenderPostCached(post, w.resolvePostImages)
 	if err != nil {
 		return fmt.Errorf("publish: render screenshot: %w", err)
 	}
 
-	opt := &qzone.PublishOption{ImageBytes: [][]byte{screenshot}}
+	// 长稿件分页后按顺序全部上传；打码图片、动图按配置另附原图
+	images := pages
+	if wc := w.cfg.Wall; (wc.CWOriginal && post.CW != "") || (wc.GIFOriginal && len(post.Images) > 0) {
+		originals := renderer.OriginalImages(w.resolvePostImages(post), wc.CWOriginal, wc.GIFOriginal)
+		images = append(append([][]byte{}, pages...), originals...)
+	}
 
-	resp, err := w.client.Publish(w.ctx, text, opt)
-	if err != nil {
+	// 超过单条说说图片上限时拆成多条发布
+	tid, parts, err := w.wall.Publish(w.ctx, text, images)
+	if parts == 0 {
 		return fmt.Errorf("publish: %w", err)
 	}
-	if !resp.OK {
-		return fmt.Errorf("publish failed: code=%d, msg=%s", resp.Code, resp.Message)
+	if err != nil {
+		// 第一条已包含正文与截图，视为已发布，不再重试以免重复
+		log.Printf("[Worker] 稿件 #%d 续发说说失败 (已发布 %d 条): %v", post.ID, parts, err)
+	} else if parts > 1 {
+		log.Printf("[Worker] 稿件 #%d 图片 %d 张超过上限，拆分为 %d 条说说", post.ID, len(images), parts)
 	}
 
 	// 回填 TID。
-	if tid := resp.GetString("tid"); tid != "" {
-		post.TID = tid
-	} else if tid := resp.GetString("t1_tid"); tid != "" {
+	if tid != "" {
 		post.TID = tid
 	} else {
 		// Fallback when API does not return a tid.
 		post.TID = fmt.Sprintf("published_%d", time.Now().Unix())
 	}
 
-	post.Status = model.StatusPublished
-	if err := w.store.SavePost(post); err != nil {
+	// 只改状态和 TID，不整行写回
+	if err := w.store.UpdatePostStatus(post.ID, model.StatusApproved, model.StatusPublished, post.TID, ""); err != nil {
 		log.Printf("[Worker] 回填 TID 失败: %v", err)
 	}
+	post.Status = model.StatusPublished
 
 	// 记录发布时间。
 	w.mu.Lock()
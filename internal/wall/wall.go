@@ -0,0 +1,292 @@
+package wall
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	qzone "github.com/guohuiyuan/qzone-go"
+	"github.com/guohuiyuan/qzonewall-go/internal/config"
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+	"github.com/guohuiyuan/qzonewall-go/internal/render"
+	"github.com/guohuiyuan/qzonewall-go/internal/store"
+)
+
+// MaxImages QQ空间单条说说最多可带的图片数
+const MaxImages = 9
+
+// DefaultID 默认墙编号，使用配置顶层的QQ空间账号、管理群与截图品牌
+const DefaultID int64 = 0
+
+// defaultName 默认墙未配置品牌墙名时的名称
+const defaultName = "表白墙"
+
+// Wall 一面墙: 独立的QQ空间账号、审核群、截图主题，稿件按 wall_id 归属
+type Wall struct {
+	ID       int64
+	Name     string
+	Client   *qzone.Client // 由 main 创建后赋值
+	Renderer *render.Renderer
+
+	cfg   *config.Config
+	store *store.Store
+}
+
+// ManageGroup 本墙的审核群，未单独配置时使用 bot.manage_group。
+// 每次从配置读取，网页修改管理群后立即生效。
+func (w *Wall) ManageGroup() int64 {
+	for _, e := range w.cfg.Walls {
+		if e.ID == w.ID && e.ManageGroup > 0 {
+			return e.ManageGroup
+		}
+	}
+	return w.cfg.Bot.ManageGroup
+}
+
+// UpdateCookie 更新本墙的QQ空间登录态并保存，重启后由 main 恢复
+func (w *Wall) UpdateCookie(cookie string) error {
+	if err := w.Client.UpdateCookie(cookie); err != nil {
+		return err
+	}
+	return w.store.SaveWallCookie(w.ID, cookie)
+}
+
+// Publish 发布说说到本墙的QQ空间。图片超过 MaxImages 张时按顺序拆成多条说说，
+// 第一条使用 text，其余为 "（续 2/3）"。返回第一条说说的 tid 与成功发布的条数，
+// 第一条之后失败时 parts>0 且 err 非 nil。
+func (w *Wall) Publish(ctx context.Context, text string, images [][]byte) (tid string, parts int, err error) {
+	chunks := ChunkImages(images)
+	for i, chunk := range chunks {
+		content := text
+		if i > 0 {
+			content = fmt.Sprintf("（续 %d/%d）", i+1, len(chunks))
+		}
+		var opt *qzone.PublishOption
+		if len(chunk) > 0 {
+			opt = &qzone.PublishOption{ImageBytes: chunk}
+		}
+		resp, err := w.Client.Publish(ctx, content, opt)
+		if err != nil {
+			return tid, i, err
+		}
+		if !resp.OK {
+			return tid, i, fmt.Errorf("code=%d, msg=%s", resp.Code, resp.Message)
+		}
+		if i == 0 {
+			if tid = resp.GetString("tid"); tid == "" {
+				tid = resp.GetString("t1_tid")
+			}
+		}
+	}
+	return tid, len(chunks), nil
+}
+
+// ChunkImages 按 MaxImages 张一组切分图片，没有图片时返回一个空组 (纯文字说说)
+func ChunkImages(images [][]byte) [][][]byte {
+	if len(images) == 0 {
+		return [][][]byte{nil}
+	}
+	var chunks [][][]byte
+	for len(images) > MaxImages {
+		chunks = append(chunks, images[:MaxImages])
+		images = images[MaxImages:]
+	}
+	return append(chunks, images)
+}
+
+// LoggedIn 是否已登录QQ空间 (启动时的占位 Cookie 不算)
+func (w *Wall) LoggedIn() bool {
+	if w.Client == nil || w.Client.UIN() <= 0 {
+		return false
+	}
+	return !strings.Contains(w.Client.Session().Cookie(), "p_skey=bootstrap")
+}
+
+// Registry 部署中的全部墙，第一面为默认墙
+type Registry struct {
+	walls []*Wall
+}
+
+// NewRegistry 按配置创建默认墙与附加墙及各自的渲染器，配置不合法时返回错误
+func NewRegistry(cfg *config.Config, st *store.Store) (*Registry, error) {
+	if err := Validate(cfg); err != nil {
+		return nil, err
+	}
+	r := &Registry{}
+	r.walls = append(r.walls, &Wall{
+		ID:       DefaultID,
+		Name:     defaultWallName(cfg),
+		Renderer: newRenderer(cfg, cfg.Render.Brand),
+		cfg:      cfg,
+		store:    st,
+	})
+	for _, e := range cfg.Walls {
+		brand := mergeBrand(cfg.Render.Brand, e.Brand)
+		if e.Brand.WallName == "" {
+			brand.WallName = e.Name
+		}
+		r.walls = append(r.walls, &Wall{
+			ID:       e.ID,
+			Name:     e.Name,
+			Renderer: newRenderer(cfg, brand),
+			cfg:      cfg,
+			store:    st,
+		})
+	}
+	return r, nil
+}
+
+// Validate 检查附加墙配置: 编号为正且不重复，名称非空、不含空格且不与其他墙 (含默认墙) 重名
+func Validate(cfg *config.Config) error {
+	ids := map[int64]bool{DefaultID: true}
+	names := map[string]bool{defaultWallName(cfg): true}
+	for _, e := range cfg.Walls {
+		switch {
+		case e.ID <= 0:
+			return fmt.Errorf("wall %q: id must be positive", e.Name)
+		case e.Name == "" || strings.ContainsAny(e.Name, " \t\r\n"):
+			return fmt.Errorf("wall %d: name must be non-empty without spaces", e.ID)
+		case ids[e.ID]:
+			return fmt.Errorf("wall %d: duplicate id", e.ID)
+		case names[e.Name]:
+			return fmt.Errorf("wall %d: duplicate name %q", e.ID, e.Name)
+		}
+		ids[e.ID], names[e.Name] = true, true
+	}
+	return nil
+}
+
+// defaultWallName 默认墙名称取品牌墙名，去掉空格以便在命令中引用
+func defaultWallName(cfg *config.Config) string {
+	name := strings.Join(strings.Fields(cfg.Render.Brand.WallName), "")
+	if name == "" {
+		return defaultName
+	}
+	return name
+}
+
+func newRenderer(cfg *config.Config, brand config.BrandConfig) *render.Renderer {
+	rc := cfg.Render
+	rc.Brand = brand
+	renderer := render.NewRenderer(rc)
+	renderer.SetAnonIdentity(cfg.Wall.AnonSecret, !cfg.Wall.AnonPerUIN)
+	return renderer
+}
+
+// mergeBrand 用附加墙填写的品牌字段覆盖默认墙的品牌配置
+func mergeBrand(base, over config.BrandConfig) config.BrandConfig {
+	pick := func(a, b string) string {
+		if b != "" {
+			return b
+		}
+		return a
+	}
+	return config.BrandConfig{
+		WallName:  pick(base.WallName, over.WallName),
+		Logo:      pick(base.Logo, over.Logo),
+		Footer:    pick(base.Footer, over.Footer),
+		SubmitURL: pick(base.SubmitURL, over.SubmitURL),
+		Watermark: pick(base.Watermark, over.Watermark),
+		Timezone:  pick(base.Timezone, over.Timezone),
+	}
+}
+
+// All 全部墙，默认墙在前
+func (r *Registry) All() []*Wall {
+	return r.walls
+}
+
+// Multi 是否配置了附加墙
+func (r *Registry) Multi() bool {
+	return len(r.walls) > 1
+}
+
+// Default 默认墙
+func (r *Registry) Default() *Wall {
+	return r.walls[0]
+}
+
+// Get 按编号获取墙，编号不存在 (如附加墙已从配置删除) 时返回 nil。
+// 这类稿件不能审核或发布，否则会发到其他墙的QQ空间
+func (r *Registry) Get(id int64) *Wall {
+	for _, w := range r.walls {
+		if w.ID == id {
+			return w
+		}
+	}
+	return nil
+}
+
+// Name 按编号获取墙名，编号不存在时返回 "已删除的墙 #编号"，用于列表展示
+func (r *Registry) Name(id int64) string {
+	if w := r.Get(id); w != nil {
+		return w.Name
+	}
+	return fmt.Sprintf("已删除的墙 #%d", id)
+}
+
+// Find 按名称或编号查找墙，不存在时返回 nil
+func (r *Registry) Find(arg string) *Wall {
+	arg = strings.TrimSpace(arg)
+	for _, w := range r.walls {
+		if w.Name == arg {
+			return w
+		}
+	}
+	if id, err := strconv.ParseInt(arg, 10, 64); err == nil {
+		return r.Get(id)
+	}
+	return nil
+}
+
+// ManagedBy 以 groupID 为审核群的墙
+func (r *Registry) ManagedBy(groupID int64) []*Wall {
+	if groupID <= 0 {
+		return nil
+	}
+	var walls []*Wall
+	for _, w := range r.walls {
+		if w.ManageGroup() == groupID {
+			walls = append(walls, w)
+		}
+	}
+	return walls
+}
+
+// ForGroup 群投稿默认进入的墙: 有本群设置时按设置；未设置时，附加墙的审核群对应该墙，其余为默认墙。
+// 本群设置的墙已从配置删除时返回 nil，不改投到其他墙
+func (r *Registry) ForGroup(setting *model.GroupSetting, groupID int64) *Wall {
+	if setting != nil {
+		return r.Get(setting.WallID)
+	}
+	if managed := r.ManagedBy(groupID); len(managed) == 1 {
+		return managed[0]
+	}
+	return r.Default()
+}
+
+// Names 全部墙名，用于提示
+func (r *Registry) Names() string {
+	names := make([]string, len(r.walls))
+	for i, w := range r.walls {
+		names[i] = w.Name
+	}
+	return strings.Join(names, " / ")
+}
+
+// GroupPosts 按所属墙分组，保持稿件原有顺序，用于批量发布到各自的QQ空间
+func GroupPosts(posts []*model.Post) [][]*model.Post {
+	var groups [][]*model.Post
+	index := make(map[int64]int)
+	for _, p := range posts {
+		i, ok := index[p.WallID]
+		if !ok {
+			i = len(groups)
+			index[p.WallID] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], p)
+	}
+	return groups
+}
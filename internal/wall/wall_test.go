@@ -0,0 +1,152 @@
+package wall
+
+import (
+	"testing"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/config"
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+)
+
+// TestChunkImages 测试按单条说说图片上限切分
+func TestChunkImages(t *testing.T) {
+	imgs := func(n int) [][]byte {
+		out := make([][]byte, n)
+		for i := range out {
+			out[i] = []byte{byte(i)}
+		}
+		return out
+	}
+	tests := []struct {
+		n    int
+		want []int
+	}{
+		{0, []int{0}},
+		{1, []int{1}},
+		{9, []int{9}},
+		{10, []int{9, 1}},
+		{20, []int{9, 9, 2}},
+	}
+	for _, tt := range tests {
+		chunks := ChunkImages(imgs(tt.n))
+		if len(chunks) != len(tt.want) {
+			t.Fatalf("%d 张: 应切为 %d 组, 实际 %d 组", tt.n, len(tt.want), len(chunks))
+		}
+		next := 0
+		for i, c := range chunks {
+			if len(c) != tt.want[i] {
+				t.Fatalf("%d 张: 第 %d 组应为 %d 张, 实际 %d 张", tt.n, i+1, tt.want[i], len(c))
+			}
+			for _, img := range c {
+				if int(img[0]) != next {
+					t.Fatalf("%d 张: 切分后顺序错误", tt.n)
+				}
+				next++
+			}
+		}
+	}
+}
+
+// TestValidate 测试附加墙配置校验
+func TestValidate(t *testing.T) {
+	entry := func(id int64, name string) config.WallEntry {
+		return config.WallEntry{ID: id, Name: name}
+	}
+	tests := []struct {
+		name    string
+		brand   string
+		walls   []config.WallEntry
+		wantErr bool
+	}{
+		{"只有默认墙", "", nil, false},
+		{"附加墙", "", []config.WallEntry{entry(1, "南区"), entry(2, "北区")}, false},
+		{"编号为0", "", []config.WallEntry{entry(0, "南区")}, true},
+		{"编号为负", "", []config.WallEntry{entry(-1, "南区")}, true},
+		{"编号重复", "", []config.WallEntry{entry(1, "南区"), entry(1, "北区")}, true},
+		{"名称为空", "", []config.WallEntry{entry(1, "")}, true},
+		{"名称含空格", "", []config.WallEntry{entry(1, "南 区")}, true},
+		{"名称重复", "", []config.WallEntry{entry(1, "南区"), entry(2, "南区")}, true},
+		{"与默认墙名重复", "", []config.WallEntry{entry(1, defaultName)}, true},
+		{"与品牌墙名重复 (去空格)", "校园 墙", []config.WallEntry{entry(1, "校园墙")}, true},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{Walls: tt.walls}
+		cfg.Render.Brand.WallName = tt.brand
+		if err := Validate(cfg); (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, 期望出错 %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// TestGroupPosts 测试按墙分组: 组按首次出现的顺序排列，组内保持稿件顺序
+func TestGroupPosts(t *testing.T) {
+	post := func(id, wallID int64) *model.Post {
+		return &model.Post{ID: id, WallID: wallID}
+	}
+	posts := []*model.Post{post(1, 2), post(2, 0), post(3, 2), post(4, 1), post(5, 0)}
+	want := [][]int64{{1, 3}, {2, 5}, {4}}
+
+	groups := GroupPosts(posts)
+	if len(groups) != len(want) {
+		t.Fatalf("应分为 %d 组, 实际 %d 组", len(want), len(groups))
+	}
+	for i, g := range groups {
+		if len(g) != len(want[i]) {
+			t.Fatalf("第 %d 组应有 %d 条, 实际 %d 条", i+1, len(want[i]), len(g))
+		}
+		for j, p := range g {
+			if p.ID != want[i][j] || p.WallID != g[0].WallID {
+				t.Fatalf("第 %d 组第 %d 条应为 #%d, 实际 #%d (墙 %d)", i+1, j+1, want[i][j], p.ID, p.WallID)
+			}
+		}
+	}
+	if groups := GroupPosts(nil); len(groups) != 0 {
+		t.Fatalf("空列表应没有分组, 实际 %d 组", len(groups))
+	}
+}
+
+// TestRegistryGet 测试编号不存在的墙不会退回默认墙
+func TestRegistryGet(t *testing.T) {
+	cfg := &config.Config{Walls: []config.WallEntry{{ID: 3, Name: "南区"}}}
+	r, err := NewRegistry(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := r.Get(DefaultID); w == nil || w != r.Default() {
+		t.Fatal("默认墙应可按编号获取")
+	}
+	if w := r.Get(3); w == nil || w.Name != "南区" {
+		t.Fatal("附加墙应可按编号获取")
+	}
+	if w := r.Get(4); w != nil {
+		t.Fatalf("不存在的墙应返回 nil, 实际 %s", w.Name)
+	}
+	if name := r.Name(4); name != "已删除的墙 #4" {
+		t.Fatalf("不存在的墙名称错误: %q", name)
+	}
+}
+
+// TestRegistryForGroup 测试群投稿路由: 有设置按设置，无设置时附加墙审核群对应该墙
+func TestRegistryForGroup(t *testing.T) {
+	cfg := &config.Config{Walls: []config.WallEntry{{ID: 3, Name: "南区", ManageGroup: 300}}}
+	r, err := NewRegistry(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		setting *model.GroupSetting
+		groupID int64
+		want    *Wall
+	}{
+		{"审核群未设置", nil, 300, r.Get(3)},
+		{"普通群未设置", nil, 500, r.Default()},
+		{"审核群设为默认墙", &model.GroupSetting{GroupID: 300, WallID: DefaultID}, 300, r.Default()},
+		{"普通群设为附加墙", &model.GroupSetting{GroupID: 500, WallID: 3}, 500, r.Get(3)},
+		{"设置的墙已删除", &model.GroupSetting{GroupID: 500, WallID: 4}, 500, nil},
+	}
+	for _, tt := range tests {
+		if got := r.ForGroup(tt.setting, tt.groupID); got != tt.want {
+			t.Errorf("%s: ForGroup = %v, 期望 %v", tt.name, got, tt.want)
+		}
+	}
+}
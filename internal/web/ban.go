@@ -0,0 +1,169 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+)
+
+// activeBan 投稿来源 IP 或填写的QQ号当前生效的封禁，未封禁时返回 nil
+func (s *Server) activeBan(ip string, uin int64) *model.Ban {
+	targets := [][2]string{{model.BanIP, ip}}
+	if uin > 0 {
+		targets = append(targets, [2]string{model.BanQQ, strconv.FormatInt(uin, 10)})
+	}
+	for _, t := range targets {
+		ban, err := s.store.ActiveBan(t[0], t[1])
+		if err != nil {
+			log.Printf("[Web] 查询封禁失败: %v", err)
+			continue
+		}
+		if ban != nil {
+			return ban
+		}
+	}
+	return nil
+}
+
+// banMessage 被封禁时返回给投稿人、投票人的提示
+func banMessage(ban *model.Ban) string {
+	msg := "你已被禁止使用表白墙 (" + ban.ExpireLabel() + ")"
+	if ban.Reason != "" {
+		msg += ": " + ban.Reason
+	}
+	return msg
+}
+
+// banView 封禁列表项，按稿件拉黑的不输出封禁对象
+type banView struct {
+	*model.Ban
+	Label  string `json:"label"`
+	Expire string `json:"expire"`
+}
+
+// handleAPIBans GET 列出生效中的封禁；POST 新增封禁 (kind+target，或 post_id 拉黑稿件作者)
+func (s *Server) handleAPIBans(w http.ResponseWriter, r *http.Request) {
+	account := s.currentAccount(r)
+	if account == nil || !account.HasRole(model.RoleViewer) {
+		jsonResp(w, 403, false, "无权限")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		bans, err := s.store.ListBans()
+		if err != nil {
+			jsonResp(w, 500, false, "查询封禁失败")
+			return
+		}
+		views := make([]banView, len(bans))
+		for i, ban := range bans {
+			if ban.PostID > 0 {
+				ban.Target = ""
+			}
+			views[i] = banView{Ban: ban, Label: ban.TargetLabel(), Expire: ban.ExpireLabel()}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"ok":   true,
+			"bans": views,
+		})
+
+	case http.MethodPost:
+		if !account.HasRole(model.RoleModerator) {
+			jsonResp(w, 403, false, "无权限")
+			return
+		}
+		ban := &model.Ban{
+			Reason:     strings.TrimSpace(r.FormValue("reason")),
+			Operator:   "web:" + account.Username,
+			CreateTime: time.Now().Unix(),
+		}
+		if d := strings.TrimSpace(r.FormValue("duration")); d != "" {
+			dur, ok := model.ParseBanDuration(d)
+			if !ok {
+				jsonResp(w, 400, false, "时长格式错误，如 30m、12h、7d、永久")
+				return
+			}
+			if dur > 0 {
+				ban.ExpireTime = time.Now().Add(dur).Unix()
+			}
+		}
+
+		if postID, _ := strconv.ParseInt(r.FormValue("post_id"), 10, 64); postID > 0 {
+			post, err := s.store.GetPost(postID)
+			if err != nil || post == nil {
+				jsonResp(w, 404, false, "稿件不存在")
+				return
+			}
+			ban.PostID = postID
+			switch {
+			case post.IP != "":
+				ban.Kind, ban.Target = model.BanIP, post.IP
+			case post.UIN > 0:
+				ban.Kind, ban.Target = model.BanQQ, strconv.FormatInt(post.UIN, 10)
+			default:
+				jsonResp(w, 400, false, "该稿件没有可封禁的作者信息")
+				return
+			}
+		} else {
+			ban.Kind, ban.Target = r.FormValue("kind"), strings.TrimSpace(r.FormValue("target"))
+			switch ban.Kind {
+			case model.BanQQ, model.BanGroup:
+				if n, err := strconv.ParseInt(ban.Target, 10, 64); err != nil || n <= 0 {
+					jsonResp(w, 400, false, "号码格式不正确")
+					return
+				}
+			case model.BanIP:
+				if net.ParseIP(ban.Target) == nil {
+					jsonResp(w, 400, false, "IP 格式不正确")
+					return
+				}
+			default:
+				jsonResp(w, 400, false, "封禁类型无效")
+				return
+			}
+		}
+
+		if err := s.store.SaveBan(ban); err != nil {
+			jsonResp(w, 500, false, "封禁失败")
+			return
+		}
+		log.Printf("[Web] %s 拉黑 %s %s (稿件 #%d), %s", account.Username, ban.Kind, ban.Target, ban.PostID, ban.ExpireLabel())
+		jsonResp(w, 200, true, "已拉黑"+ban.TargetLabel())
+
+	default:
+		jsonResp(w, 405, false, "仅支持 GET/POST")
+	}
+}
+
+// handleAPIBanDelete 解除封禁
+func (s *Server) handleAPIBanDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonResp(w, 405, false, "仅支持 POST")
+		return
+	}
+	account := s.currentAccount(r)
+	if account == nil || !account.HasRole(model.RoleModerator) {
+		jsonResp(w, 403, false, "无权限")
+		return
+	}
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	ban, err := s.store.GetBan(id)
+	if err != nil || ban == nil {
+		jsonResp(w, 404, false, "封禁不存在")
+		return
+	}
+	if err := s.store.DeleteBan(id); err != nil {
+		jsonResp(w, 500, false, "解封失败")
+		return
+	}
+	log.Printf("[Web] %s 解封 %s %s (封禁 #%d)", account.Username, ban.Kind, ban.Target, ban.ID)
+	jsonResp(w, 200, true, "已解封"+ban.TargetLabel())
+}
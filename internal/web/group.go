@@ -0,0 +1,116 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+)
+
+// handleAPIGroups GET 列出已登记的群；POST 新增或修改群设置 (仅所有者)
+func (s *Server) handleAPIGroups(w http.ResponseWriter, r *http.Request) {
+	account := s.currentAccount(r)
+	if account == nil || !account.HasRole(model.RoleViewer) {
+		jsonResp(w, 403, false, "无权限")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		groups, err := s.store.ListGroupSettings()
+		if err != nil {
+			jsonResp(w, 500, false, "查询群设置失败")
+			return
+		}
+		resp := map[string]interface{}{
+			"ok":     true,
+			"groups": groups,
+		}
+		if walls := s.multiWalls(); walls != nil {
+			// 与页面下拉框选项格式一致: [编号, 名称]
+			options := make([][2]string, len(walls))
+			for i, wl := range walls {
+				options[i] = [2]string{strconv.FormatInt(wl.ID, 10), wl.Name}
+			}
+			resp["walls"] = options
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+
+	case http.MethodPost:
+		owner := s.requireOwner(w, r)
+		if owner == nil {
+			return
+		}
+		groupID, err := strconv.ParseInt(strings.TrimSpace(r.FormValue("group_id")), 10, 64)
+		if err != nil || groupID <= 0 {
+			jsonResp(w, 400, false, "群号格式不正确")
+			return
+		}
+		anon, ok := model.ParseGroupAnon(r.FormValue("anon"))
+		if !ok {
+			jsonResp(w, 400, false, "默认匿名设置无效")
+			return
+		}
+		notify, ok := model.ParseGroupNotify(r.FormValue("notify"))
+		if !ok {
+			jsonResp(w, 400, false, "通知方式无效")
+			return
+		}
+		g, err := s.store.GetGroupSetting(groupID)
+		if err != nil {
+			jsonResp(w, 500, false, "查询群设置失败")
+			return
+		}
+		if g == nil {
+			// 未指定墙时沿用当前路由，不能留 0，否则附加墙的审核群会改投到默认墙
+			g = &model.GroupSetting{GroupID: groupID, WallID: s.walls.ForGroup(nil, groupID).ID}
+		}
+		if name := strings.TrimSpace(r.FormValue("name")); name != "" {
+			g.Name = name
+		}
+		if arg := r.FormValue("wall_id"); arg != "" {
+			target := s.walls.Find(arg)
+			if target == nil {
+				jsonResp(w, 400, false, "墙不存在")
+				return
+			}
+			g.WallID = target.ID
+		}
+		g.Enabled = r.FormValue("enabled") == "1" || r.FormValue("enabled") == "true"
+		g.Anon, g.Notify = anon, notify
+		g.UpdatedBy, g.UpdateTime = 0, time.Now().Unix()
+		if err := s.store.SaveGroupSetting(g); err != nil {
+			jsonResp(w, 500, false, "保存失败")
+			return
+		}
+		log.Printf("[Web] %s 修改群 %d 设置: 投稿 %v, 匿名 %q, 通知 %q, 墙 %d", owner.Username, groupID, g.Enabled, g.Anon, g.Notify, g.WallID)
+		jsonResp(w, 200, true, "已保存")
+
+	default:
+		jsonResp(w, 405, false, "仅支持 GET/POST")
+	}
+}
+
+// handleAPIGroupDelete 删除群登记，之后该群按群白名单配置处理
+func (s *Server) handleAPIGroupDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonResp(w, 405, false, "仅支持 POST")
+		return
+	}
+	owner := s.requireOwner(w, r)
+	if owner == nil {
+		return
+	}
+	groupID, _ := strconv.ParseInt(r.FormValue("group_id"), 10, 64)
+	if err := s.store.DeleteGroupSetting(groupID); err != nil {
+		jsonResp(w, 500, false, "删除失败")
+		return
+	}
+	log.Printf("[Web] %s 删除群 %d 的登记", owner.Username, groupID)
+	jsonResp(w, 200, true, "已删除")
+}
@@ -0,0 +1,150 @@
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+	"github.com/guohuiyuan/qzonewall-go/internal/store"
+)
+
+// pollVoterCookie 未登录投票人的匿名标识，数据库中只保存其哈希
+const pollVoterCookie = "poll_voter"
+
+// handlePollPage 投票页面，数据由页面脚本通过 /api/poll 获取
+func (s *Server) handlePollPage(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	s.renderTemplate(w, "poll.html", map[string]interface{}{
+		"ID":   id,
+		"Root": s.prefix,
+	})
+}
+
+// getPublishedPoll 读取已发布的投票稿件，失败时已写出错误响应
+func (s *Server) getPublishedPoll(w http.ResponseWriter, raw string) *model.Post {
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id <= 0 {
+		jsonResp(w, 400, false, "投票编号无效")
+		return nil
+	}
+	post, err := s.store.GetPost(id)
+	if err != nil || post == nil || post.Poll == nil || post.Status != model.StatusPublished {
+		jsonResp(w, 404, false, "投票不存在或尚未发布")
+		return nil
+	}
+	return post
+}
+
+// pollVoter 当前请求的投票人标识: 登录账号优先，否则使用 cookie 中的随机标识。
+// 绑定了 QQ 的账号与机器人投票共用 qq:<号码>，同一 QQ 只能投一次。
+// create 为 true 时为没有标识的访客签发 cookie。
+func (s *Server) pollVoter(w http.ResponseWriter, r *http.Request, create bool) string {
+	if account := s.currentAccount(r); account != nil {
+		if account.QQ != 0 {
+			return fmt.Sprintf("qq:%d", account.QQ)
+		}
+		return fmt.Sprintf("account:%d", account.ID)
+	}
+	token := ""
+	if c, err := r.Cookie(pollVoterCookie); err == nil && len(c.Value) == 32 {
+		token = c.Value
+	} else if create {
+		token = randomHex(16)
+		http.SetCookie(w, &http.Cookie{
+			Name:     pollVoterCookie,
+			Value:    token,
+			Path:     s.url("/"),
+			MaxAge:   365 * 24 * 3600,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "web:" + hex.EncodeToString(sum[:16])
+}
+
+// handleAPIPoll 投票详情。票数仅在已投票或投票结束后返回，避免影响投票
+func (s *Server) handleAPIPoll(w http.ResponseWriter, r *http.Request) {
+	post := s.getPublishedPoll(w, r.URL.Query().Get("id"))
+	if post == nil {
+		return
+	}
+	voted := -1
+	if voter := s.pollVoter(w, r, false); voter != "" {
+		voted, _ = s.store.GetVote(post.ID, voter)
+	}
+	open := post.Poll.Open(time.Now())
+	data := map[string]interface{}{
+		"ok":         true,
+		"id":         post.ID,
+		"question":   post.Text,
+		"options":    post.Poll.Options,
+		"open":       open,
+		"close_time": post.Poll.CloseTime,
+		"voted":      voted,
+	}
+	if voted >= 0 || !open {
+		counts, err := s.store.PollCounts(post.ID, len(post.Poll.Options))
+		if err != nil {
+			jsonResp(w, 500, false, "查询票数失败")
+			return
+		}
+		data["counts"] = counts
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+// handleAPIPollVote 网页投票，option 从0开始；按 IP 限流，被封禁的 IP 或绑定QQ号不能投票
+func (s *Server) handleAPIPollVote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonResp(w, 405, false, "仅支持 POST")
+		return
+	}
+	ip := clientIP(r)
+	if !s.votes.allow(ip, time.Now()) {
+		jsonResp(w, 429, false, "操作过于频繁，请稍后再试")
+		return
+	}
+	var uin int64
+	if account := s.currentAccount(r); account != nil {
+		uin = account.QQ
+	}
+	if ban := s.activeBan(ip, uin); ban != nil {
+		jsonResp(w, 403, false, banMessage(ban))
+		return
+	}
+	post := s.getPublishedPoll(w, r.FormValue("id"))
+	if post == nil {
+		return
+	}
+	if !post.Poll.Open(time.Now()) {
+		jsonResp(w, 400, false, "投票已结束")
+		return
+	}
+	option, err := strconv.Atoi(r.FormValue("option"))
+	if err != nil || option < 0 || option >= len(post.Poll.Options) {
+		jsonResp(w, 400, false, "选项无效")
+		return
+	}
+
+	voter := s.pollVoter(w, r, true)
+	if err := s.store.CastVote(post.ID, voter, option); err != nil {
+		if errors.Is(err, store.ErrAlreadyVoted) {
+			jsonResp(w, 409, false, "你已经投过票了")
+			return
+		}
+		jsonResp(w, 500, false, "投票失败")
+		return
+	}
+	jsonResp(w, 200, true, "投票成功")
+}
@@ -0,0 +1,97 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+	"github.com/guohuiyuan/qzonewall-go/internal/store"
+)
+
+// handleAPIEdit 审核员修改待审核稿件的正文，修改记入修订记录
+func (s *Server) handleAPIEdit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonResp(w, 405, false, "仅支持 POST")
+		return
+	}
+	account := s.currentAccount(r)
+	if account == nil || !account.HasRole(model.RoleModerator) {
+		jsonResp(w, 403, false, "无权限")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		jsonResp(w, 400, false, "编号格式错误")
+		return
+	}
+	text := strings.TrimSpace(r.FormValue("text"))
+	if limit := s.wallCfg.MaxTextLen; limit > 0 && len([]rune(text)) > limit {
+		jsonResp(w, 400, false, fmt.Sprintf("内容超过 %d 字", limit))
+		return
+	}
+	post, err := s.store.GetPost(id)
+	if err != nil || post == nil {
+		jsonResp(w, 404, false, "稿件不存在")
+		return
+	}
+	if text == "" && len(post.Images) == 0 {
+		jsonResp(w, 400, false, "内容不能为空")
+		return
+	}
+	if post.Type.Structured() || post.Poll != nil {
+		jsonResp(w, 400, false, "分类和投票稿件不支持修改正文")
+		return
+	}
+	if text == post.Text {
+		jsonResp(w, 200, true, "内容没有变化")
+		return
+	}
+
+	if _, err := s.store.EditPostText(id, text, "web:"+account.Username); err != nil {
+		if errors.Is(err, store.ErrNotPending) {
+			jsonResp(w, 400, false, "只能修改待审核的稿件")
+			return
+		}
+		jsonResp(w, 500, false, "修改失败")
+		return
+	}
+	jsonResp(w, 200, true, fmt.Sprintf("稿件 #%d 已修改", id))
+}
+
+// handleAPIRevisions 稿件的修订记录，每条附带与上一版本的逐行差异
+func (s *Server) handleAPIRevisions(w http.ResponseWriter, r *http.Request) {
+	account := s.currentAccount(r)
+	if account == nil || !account.HasRole(model.RoleViewer) {
+		jsonResp(w, 403, false, "无权限")
+		return
+	}
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		jsonResp(w, 400, false, "编号格式错误")
+		return
+	}
+	revs, err := s.store.ListRevisions(id)
+	if err != nil {
+		jsonResp(w, 500, false, "查询修订记录失败")
+		return
+	}
+
+	type revisionView struct {
+		*model.Revision
+		Diff []model.DiffLine `json:"diff"`
+	}
+	views := make([]revisionView, len(revs))
+	for i, rev := range revs {
+		views[i] = revisionView{Revision: rev, Diff: model.DiffLines(rev.OldText, rev.NewText)}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"ok":        true,
+		"revisions": views,
+	})
+}
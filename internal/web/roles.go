@@ -0,0 +1,197 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/guohuiyuan/qzonewall-go/internal/model"
+)
+
+// requireOwner 校验当前账号为所有者，失败时已写出错误响应
+func (s *Server) requireOwner(w http.ResponseWriter, r *http.Request) *model.Account {
+	account := s.currentAccount(r)
+	if account == nil || !account.HasRole(model.RoleOwner) {
+		jsonResp(w, 403, false, "无权限")
+		return nil
+	}
+	return account
+}
+
+// handleAPIAccounts GET 列出网页账号与QQ授权；POST 新建账号
+func (s *Server) handleAPIAccounts(w http.ResponseWriter, r *http.Request) {
+	owner := s.requireOwner(w, r)
+	if owner == nil {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		accounts, err := s.store.ListAccounts()
+		if err != nil {
+			jsonResp(w, 500, false, "查询账号失败")
+			return
+		}
+		qqRoles, err := s.store.ListQQRoles()
+		if err != nil {
+			jsonResp(w, 500, false, "查询授权失败")
+			return
+		}
+		roles := make([]map[string]string, 0, len(model.Roles)+1)
+		for _, role := range append(model.Roles, model.RoleUser) {
+			roles = append(roles, map[string]string{"value": role, "label": model.RoleLabel(role)})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"ok":       true,
+			"self":     owner.ID,
+			"accounts": accounts,
+			"qq_roles": qqRoles,
+			"roles":    roles,
+		})
+
+	case http.MethodPost:
+		username := strings.TrimSpace(r.FormValue("username"))
+		password := r.FormValue("password")
+		role, ok := model.ParseRole(r.FormValue("role"))
+		if username == "" || !ok {
+			jsonResp(w, 400, false, "用户名或角色无效")
+			return
+		}
+		if len(password) < 6 {
+			jsonResp(w, 400, false, "密码至少6位")
+			return
+		}
+		qq, err := strconv.ParseInt(strings.TrimSpace(r.FormValue("qq")), 10, 64)
+		if err != nil && strings.TrimSpace(r.FormValue("qq")) != "" {
+			jsonResp(w, 400, false, "QQ号格式不正确")
+			return
+		}
+		if qq > 0 {
+			if other, _ := s.store.GetAccountByQQ(qq); other != nil {
+				jsonResp(w, 400, false, "该QQ号已绑定账号 "+other.Username)
+				return
+			}
+		}
+		if err := s.RegisterUser(username, password); err != nil {
+			jsonResp(w, 400, false, err.Error())
+			return
+		}
+		account, err := s.store.GetAccount(username)
+		if err != nil || account == nil {
+			jsonResp(w, 500, false, "创建账号失败")
+			return
+		}
+		if err := s.store.UpdateAccountRole(account.ID, role, qq); err != nil {
+			jsonResp(w, 500, false, "设置角色失败")
+			return
+		}
+		log.Printf("[Web] %s 新建账号 %s (%s)", owner.Username, username, role)
+		jsonResp(w, 200, true, "账号已创建")
+
+	default:
+		jsonResp(w, 405, false, "仅支持 GET/POST")
+	}
+}
+
+// handleAPIAccountUpdate 修改账号角色与绑定的QQ号，不能修改自己的角色
+func (s *Server) handleAPIAccountUpdate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonResp(w, 405, false, "仅支持 POST")
+		return
+	}
+	owner := s.requireOwner(w, r)
+	if owner == nil {
+		return
+	}
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	account, err := s.store.GetAccountByID(id)
+	if err != nil || account == nil {
+		jsonResp(w, 404, false, "账号不存在")
+		return
+	}
+	role, ok := model.ParseRole(r.FormValue("role"))
+	if !ok {
+		jsonResp(w, 400, false, "角色无效")
+		return
+	}
+	if account.ID == owner.ID && role != account.Role {
+		jsonResp(w, 400, false, "不能修改自己的角色")
+		return
+	}
+	qq, err := strconv.ParseInt(strings.TrimSpace(r.FormValue("qq")), 10, 64)
+	if err != nil && strings.TrimSpace(r.FormValue("qq")) != "" {
+		jsonResp(w, 400, false, "QQ号格式不正确")
+		return
+	}
+	if qq > 0 {
+		if other, _ := s.store.GetAccountByQQ(qq); other != nil && other.ID != account.ID {
+			jsonResp(w, 400, false, "该QQ号已绑定账号 "+other.Username)
+			return
+		}
+	}
+	if err := s.store.UpdateAccountRole(account.ID, role, qq); err != nil {
+		jsonResp(w, 500, false, "保存失败")
+		return
+	}
+	log.Printf("[Web] %s 将账号 %s 设为 %s, QQ %d", owner.Username, account.Username, role, qq)
+	jsonResp(w, 200, true, "已保存")
+}
+
+// handleAPIAccountDelete 删除账号，不能删除自己
+func (s *Server) handleAPIAccountDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonResp(w, 405, false, "仅支持 POST")
+		return
+	}
+	owner := s.requireOwner(w, r)
+	if owner == nil {
+		return
+	}
+	id, _ := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if id == owner.ID {
+		jsonResp(w, 400, false, "不能删除自己")
+		return
+	}
+	account, err := s.store.GetAccountByID(id)
+	if err != nil || account == nil {
+		jsonResp(w, 404, false, "账号不存在")
+		return
+	}
+	if err := s.store.DeleteAccount(id); err != nil {
+		jsonResp(w, 500, false, "删除失败")
+		return
+	}
+	log.Printf("[Web] %s 删除账号 %s", owner.Username, account.Username)
+	jsonResp(w, 200, true, "已删除")
+}
+
+// handleAPIQQRole 授予或收回QQ号的机器人管理角色，与 /授权 命令等效
+func (s *Server) handleAPIQQRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonResp(w, 405, false, "仅支持 POST")
+		return
+	}
+	owner := s.requireOwner(w, r)
+	if owner == nil {
+		return
+	}
+	uin, err := strconv.ParseInt(strings.TrimSpace(r.FormValue("uin")), 10, 64)
+	if err != nil || uin <= 0 {
+		jsonResp(w, 400, false, "QQ号格式不正确")
+		return
+	}
+	role, ok := model.ParseRole(r.FormValue("role"))
+	if !ok {
+		jsonResp(w, 400, false, "角色无效")
+		return
+	}
+	if err := s.store.SetQQRole(uin, role, 0); err != nil {
+		jsonResp(w, 500, false, "授权失败")
+		return
+	}
+	log.Printf("[Web] %s 将 QQ %d 设为 %s", owner.Username, uin, role)
+	jsonResp(w, 200, true, "已保存")
+}
@@ -7,10 +7,12 @@ import (
 	"embed"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
 	"log"
+	"mime/multipart"
 	"net"
 	"net/http"
 	"os"
@@ -24,10 +26,12 @@ import (
 	"time"
 
 	qzone "github.com/guohuiyuan/qzone-go"
+	"github.com/guohuiyuan/qzonewall-go/internal/caption"
 	"github.com/guohuiyuan/qzonewall-go/internal/config"
 	"github.com/guohuiyuan/qzonewall-go/internal/model"
 	"github.com/guohuiyuan/qzonewall-go/internal/render"
 	"github.com/guohuiyuan/qzonewall-go/internal/store"
+	"github.com/guohuiyuan/qzonewall-go/internal/wall"
 	zero "github.com/wdvxdr1123/ZeroBot"
 )
 
@@ -41,8 +45,8 @@ type Server struct {
 	fullCfg   *config.Config
 	cfgPath   string
 	store     *store.Store
-	qzClient  *qzone.Client
-	renderer  *render.Renderer
+	walls     *wall.Registry
+	captions  *caption.Captions
 	tmpl      *template.Template
 	server    *http.Server
 	uploadDir string
@@ -50,10 +54,15 @@ type Server struct {
 	// [新增] 路由前缀，例如 "/wall"。默认为 ""
 	prefix string
 
+	// 投稿预览 / 网页投票限流
+	previews *rateLimiter
+	votes    *rateLimiter
+
 	// QR 登录状态
 	qrMu      sync.Mutex
 	qrCode    *qzone.QRCode
-	qrStatus  string // "", "waiting", "scanned", "success", "expired", "error"
+	qrWall    *wall.Wall // 正在扫码登录的墙
+	qrStatus  string     // "", "waiting", "scanned", "success", "expired", "error"
 	qrMessage string
 }
 
@@ -62,8 +71,8 @@ func NewServer(
 	fullCfg *config.Config,
 	cfgPath string,
 	st *store.Store,
-	qzClient *qzone.Client,
-	renderer *render.Renderer,
+	walls *wall.Registry,
+	captions *caption.Captions,
 ) *Server {
 	return &Server{
 		cfg:       fullCfg.Web,
@@ -71,9 +80,11 @@ func NewServer(
 		fullCfg:   fullCfg,
 		cfgPath:   cfgPath,
 		store:     st,
-		qzClient:  qzClient,
-		renderer:  renderer,
+		walls:     walls,
+		captions:  captions,
 		uploadDir: "data/uploads",
+		previews:  newRateLimiter(fullCfg.Web.PreviewLimit, time.Minute),
+		votes:     newRateLimiter(20, time.Minute),
 		// [配置] 在这里设置你的二级路径前缀，例如 "/wall"
 		// 如果在根目录运行，请保持为空字符串 ""
 		prefix: "/wall",
@@ -91,19 +102,7 @@ func (s *Server) Start() error {
 		"formatTime": func(ts int64) string {
 			return time.Unix(ts, 0).Format("2006-01-02 15:04")
 		},
-		"statusText": func(st model.PostStatus) string {
-			m := map[model.PostStatus]string{
-				model.StatusPending:   "待审核",
-				model.StatusApproved:  "已通过",
-				model.StatusRejected:  "已拒绝",
-				model.StatusFailed:    "失败",
-				model.StatusPublished: "已发布",
-			}
-			if v, ok := m[st]; ok {
-				return v
-			}
-			return string(st)
-		},
+		"statusText": func(st model.PostStatus) string { return st.Label() },
 		"statusClass": func(st model.PostStatus) string {
 			m := map[model.PostStatus]string{
 				model.StatusPending:   "pending",
@@ -115,6 +114,15 @@ func (s *Server) Start() error {
 			return m[st]
 		},
 		"hasImages": func(imgs []string) bool { return len(imgs) > 0 },
+		"typeText":  func(t model.PostType) string { return t.Label() },
+		"roleText":  model.RoleLabel,
+		// typeParam 类型筛选参数，普通稿件为 "normal"
+		"typeParam": func(t model.PostType) string {
+			if t == model.TypeNormal {
+				return "normal"
+			}
+			return string(t)
+		},
 	}
 
 	var err error
@@ -150,14 +158,21 @@ func (s *Server) Start() error {
 	mux.HandleFunc(s.url("/logout"), s.handleLogout)
 	mux.HandleFunc(s.url("/submit"), s.handleSubmitPage)
 	mux.HandleFunc(s.url("/admin"), s.handleAdminPage)
+	mux.HandleFunc(s.url("/poll"), s.handlePollPage)
 	mux.HandleFunc(s.url("/icon.png"), s.handleIcon)
 	mux.HandleFunc(s.url("/favicon.ico"), s.handleFavicon)
 
 	// API 路由
 	mux.HandleFunc(s.url("/api/submit"), s.handleAPISubmit)
+	mux.HandleFunc(s.url("/api/preview"), s.handleAPIPreview)
 	mux.HandleFunc(s.url("/api/post/image"), s.handleAPIPostImage)
+	mux.HandleFunc(s.url("/api/poll"), s.handleAPIPoll)
+	mux.HandleFunc(s.url("/api/poll/vote"), s.handleAPIPollVote)
 	mux.HandleFunc(s.url("/api/approve"), s.handleAPIApprove)
 	mux.HandleFunc(s.url("/api/reject"), s.handleAPIReject)
+	mux.HandleFunc(s.url("/api/cw"), s.handleAPICW)
+	mux.HandleFunc(s.url("/api/edit"), s.handleAPIEdit)
+	mux.HandleFunc(s.url("/api/revisions"), s.handleAPIRevisions)
 	mux.HandleFunc(s.url("/api/approve/batch"), s.handleAPIBatchApprove)
 	mux.HandleFunc(s.url("/api/reject/batch"), s.handleAPIBatchReject)
 	mux.HandleFunc(s.url("/api/delete/batch"), s.handleAPIBatchDelete)
@@ -167,7 +182,16 @@ func (s *Server) Start() error {
 	mux.HandleFunc(s.url("/api/qzone/status"), s.handleAPIQzoneStatus)
 	mux.HandleFunc(s.url("/api/qzone/refresh"), s.handleAPIQzoneRefresh)
 	mux.HandleFunc(s.url("/api/config"), s.handleAPIConfig)
+	mux.HandleFunc(s.url("/api/caption/preview"), s.handleAPICaptionPreview)
 	mux.HandleFunc(s.url("/api/change-password"), s.handleAPIChangePassword)
+	mux.HandleFunc(s.url("/api/accounts"), s.handleAPIAccounts)
+	mux.HandleFunc(s.url("/api/accounts/update"), s.handleAPIAccountUpdate)
+	mux.HandleFunc(s.url("/api/accounts/delete"), s.handleAPIAccountDelete)
+	mux.HandleFunc(s.url("/api/qq-role"), s.handleAPIQQRole)
+	mux.HandleFunc(s.url("/api/groups"), s.handleAPIGroups)
+	mux.HandleFunc(s.url("/api/groups/delete"), s.handleAPIGroupDelete)
+	mux.HandleFunc(s.url("/api/bans"), s.handleAPIBans)
+	mux.HandleFunc(s.url("/api/bans/delete"), s.handleAPIBanDelete)
 	mux.HandleFunc(s.url("/api/restart"), s.handleAPIRestart)
 
 	// [修复] 静态资源处理
@@ -233,7 +257,7 @@ func (s *Server) initAdmin() error {
 	salt := randomHex(16)
 	hash := hashPassword("admin123", salt)
 	log.Println("[Web] 初始化默认管理员: admin / admin123，请及时在管理后台修改密码")
-	return s.store.CreateAccount("admin", hash, salt, "admin")
+	return s.store.CreateAccount("admin", hash, salt, model.RoleOwner)
 }
 
 func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
@@ -243,7 +267,7 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	account := s.currentAccount(r)
-	if account != nil && account.IsAdmin() {
+	if account != nil && account.HasRole(model.RoleViewer) {
 		http.Redirect(w, r, s.url("/admin"), http.StatusFound)
 	} else {
 		http.Redirect(w, r, s.url("/submit"), http.StatusFound)
@@ -253,7 +277,7 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodGet {
 		account := s.currentAccount(r)
-		if account != nil && account.IsAdmin() {
+		if account != nil && account.HasRole(model.RoleViewer) {
 			http.Redirect(w, r, s.url("/admin"), http.StatusFound)
 			return
 		}
@@ -274,8 +298,8 @@ func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 		s.renderTemplate(w, "login.html", map[string]interface{}{"Error": "用户名或密码错误", "Root": s.prefix})
 		return
 	}
-	if !account.IsAdmin() {
-		s.renderTemplate(w, "login.html", map[string]interface{}{"Error": "仅管理员可登录", "Root": s.prefix})
+	if !account.HasRole(model.RoleViewer) {
+		s.renderTemplate(w, "login.html", map[string]interface{}{"Error": "该账号没有管理权限", "Root": s.prefix})
 		return
 	}
 
@@ -314,20 +338,17 @@ func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleSubmitPage(w http.ResponseWriter, r *http.Request) {
 	account := s.currentAccount(r)
 
-	var qzoneUIN int64
-	var qzoneOnline bool
-	if s.qzClient != nil {
-		qzoneUIN = s.qzClient.UIN()
-		qzoneOnline = s.isQzoneLoggedIn()
-	}
+	def := s.walls.Default()
+	qzoneUIN, qzoneOnline := wallUIN(def), def.LoggedIn()
 
 	data := map[string]interface{}{
 		"Account":     account,
-		"IsAdmin":     account != nil && account.IsAdmin(),
+		"IsAdmin":     account != nil && account.HasRole(model.RoleViewer),
 		"MaxImages":   s.wallCfg.MaxImages,
 		"Message":     r.URL.Query().Get("msg"),
 		"QzoneUIN":    qzoneUIN,
 		"QzoneOnline": qzoneOnline,
+		"Walls":       s.multiWalls(),
 		"Root":        s.prefix, // [修改] 注入 Root
 	}
 	s.renderTemplate(w, "user.html", data)
@@ -335,15 +356,38 @@ func (s *Server) handleSubmitPage(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleAdminPage(w http.ResponseWriter, r *http.Request) {
 	account := s.currentAccount(r)
-	if account == nil || !account.IsAdmin() {
+	if account == nil || !account.HasRole(model.RoleViewer) {
 		http.Redirect(w, r, s.url("/login"), http.StatusFound)
 		return
 	}
 
 	statusFilter := r.URL.Query().Get("status")
+	typeFilter := r.URL.Query().Get("type")
+	wallFilter := r.URL.Query().Get("wall")
+	var walls []int64
+	if target := s.walls.Find(wallFilter); s.walls.Multi() && wallFilter != "" && target != nil {
+		walls = []int64{target.ID}
+		wallFilter = strconv.FormatInt(target.ID, 10)
+	} else {
+		wallFilter = ""
+	}
+	t, typed := model.ParsePostType(typeFilter)
+	if !typed {
+		typeFilter = ""
+	}
 	var posts []*model.Post
 	var err error
-	if statusFilter != "" {
+	if typed || walls != nil {
+		filter := store.PostFilter{
+			Status: model.PostStatus(statusFilter),
+			Walls:  walls,
+			Limit:  100,
+		}
+		if typed {
+			filter.Types = []model.PostType{t}
+		}
+		posts, err = s.store.ListPosts(filter)
+	} else if statusFilter != "" {
 		posts, err = s.store.ListByStatus(model.PostStatus(statusFilter))
 	} else {
 		posts, err = s.store.ListAll(100, 0)
@@ -353,8 +397,16 @@ func (s *Server) handleAdminPage(w http.ResponseWriter, r *http.Request) {
 	}
 
 	displayPosts := make([]*model.Post, len(posts))
+	ids := make([]int64, len(posts))
+	wallNames := make(map[int64]string)
 	for i, p := range posts {
 		displayPosts[i] = s.resolvePostImages(p)
+		ids[i] = p.ID
+		wallNames[p.WallID] = s.walls.Name(p.WallID)
+	}
+	revisions, err := s.store.RevisionCounts(ids)
+	if err != nil {
+		log.Printf("[Web] 查询修订记录失败: %v", err)
 	}
 
 	totalCount, _ := s.store.CountAll()
@@ -366,55 +418,50 @@ func (s *Server) handleAdminPage(w http.ResponseWriter, r *http.Request) {
 	data := map[string]interface{}{
 		"Account":           account,
 		"Posts":             displayPosts,
+		"Revisions":         revisions,
 		"TotalCount":        totalCount,
 		"PendingCount":      pendingCount,
 		"ApprovedCount":     approvedCount,
 		"RejectedCount":     rejectedCount,
 		"PublishedCount":    publishedCount,
 		"StatusFilter":      statusFilter,
-		"CookieValid":       s.isQzoneLoggedIn(),
-		"QzoneUIN":          int64(0),
+		"TypeFilter":        typeFilter,
+		"WallFilter":        wallFilter,
+		"PostTypes":         model.PostTypes,
+		"Walls":             s.multiWalls(),
+		"WallNames":         wallNames,
+		"CookieValid":       s.walls.Default().LoggedIn(),
+		"QzoneUIN":          wallUIN(s.walls.Default()),
 		"Message":           r.URL.Query().Get("msg"),
 		"Root":              s.prefix, // [修改] 注入 Root
 		"PasswordIsDefault": s.isDefaultAdminPassword(account),
+		"CanModerate":       account.HasRole(model.RoleModerator),
+		"IsOwner":           account.HasRole(model.RoleOwner),
 	}
-	if s.qzClient != nil {
-		data["QzoneUIN"] = s.qzClient.UIN()
-	}
-
 	s.renderTemplate(w, "admin.html", data)
 }
 
+// multiWalls 配置了附加墙时返回全部墙供页面选择，单墙部署返回 nil 以隐藏相关控件
+func (s *Server) multiWalls() []*wall.Wall {
+	if !s.walls.Multi() {
+		return nil
+	}
+	return s.walls.All()
+}
+
 func (s *Server) handleAPISubmit(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		jsonResp(w, 405, false, "仅支持 POST")
 		return
 	}
 
-	account := s.currentAccount(r)
-
-	if err := r.ParseMultipartForm(32 << 20); err != nil {
-		jsonResp(w, 400, false, "请求体过大")
+	post, files, ok := s.readSubmitForm(w, r)
+	if !ok {
 		return
 	}
 
-	text := r.FormValue("text")
-	name := r.FormValue("uin")
-	uin, _ := strconv.ParseInt(name, 10, 64)
-	anon := r.FormValue("anon") == "on" || r.FormValue("anon") == "true"
-	if name == "" && account != nil {
-		name = account.Username
-	}
-	if name == "" {
-		name = "匿名用户"
-	}
-
 	var images []string
-	files := r.MultipartForm.File["images"]
 	for _, fh := range files {
-		if len(images) >= s.wallCfg.MaxImages {
-			break
-		}
 		f, err := fh.Open()
 		if err != nil {
 			continue
@@ -440,28 +487,204 @@ func (s *Server) handleAPISubmit(w http.ResponseWriter, r *http.Request) {
 		// 这里暂存为 /uploads/xxx，如果使用二级目录，前端 img src 需要加上 Root
 		images = append(images, "/uploads/"+filename)
 	}
+	post.Images = images
 
-	if text == "" && len(images) == 0 {
-		jsonResp(w, 400, false, "内容不能为空")
+	if err := s.store.SavePost(post); err != nil {
+		jsonResp(w, 500, false, "保存失败")
 		return
 	}
 
-	post := &model.Post{
+	log.Printf("[Web] received post #%d from %s", post.ID, post.Name)
+	jsonRespData(w, 200, true, fmt.Sprintf("投稿成功，编号 #%d，等待审核", post.ID), post.ID)
+}
+
+// readSubmitForm 解析投稿表单 (投稿与预览共用)，图片只返回文件句柄，由调用方决定是否落盘。
+// 表单有误时已写出错误响应并返回 false。
+func (s *Server) readSubmitForm(w http.ResponseWriter, r *http.Request) (*model.Post, []*multipart.FileHeader, bool) {
+	account := s.currentAccount(r)
+
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		jsonResp(w, 400, false, "请求体过大")
+		return nil, nil, false
+	}
+
+	text := r.FormValue("text")
+	name := r.FormValue("uin")
+	uin, _ := strconv.ParseInt(name, 10, 64)
+	anon := r.FormValue("anon") == "on" || r.FormValue("anon") == "true"
+	ip := clientIP(r)
+	if ban := s.activeBan(ip, uin); ban != nil {
+		jsonResp(w, 403, false, banMessage(ban))
+		return nil, nil, false
+	}
+	if name == "" && account != nil {
+		name = account.Username
+	}
+	if name == "" {
+		name = "匿名用户"
+	}
+	target := s.walls.Default()
+	if arg := r.FormValue("wall"); arg != "" {
+		if target = s.walls.Find(arg); target == nil {
+			jsonResp(w, 400, false, "投稿的墙不存在")
+			return nil, nil, false
+		}
+	}
+
+	files := r.MultipartForm.File["images"]
+	if len(files) > s.wallCfg.MaxImages {
+		files = files[:s.wallCfg.MaxImages]
+	}
+
+	postType, _ := model.ParsePostType(r.FormValue("type"))
+	var fields *model.PostFields
+	if postType.Structured() {
+		fields = &model.PostFields{
+			Item:     strings.TrimSpace(r.FormValue("item")),
+			Location: strings.TrimSpace(r.FormValue("location")),
+			Time:     strings.TrimSpace(r.FormValue("time")),
+			Contact:  strings.TrimSpace(r.FormValue("contact")),
+		}
+		if postType == model.TypeMarket {
+			fields.Price = strings.TrimSpace(r.FormValue("price"))
+		}
+		if fields.Item == "" {
+			jsonResp(w, 400, false, "请填写物品")
+			return nil, nil, false
+		}
+	} else if text == "" && len(files) == 0 {
+		jsonResp(w, 400, false, "内容不能为空")
+		return nil, nil, false
+	}
+
+	cw, cwImages, text := model.ParseCW(text)
+	if r.FormValue("cw") == "on" || r.FormValue("cw") == "true" {
+		if cw = strings.TrimSpace(r.FormValue("cw_reason")); cw == "" {
+			cw = model.CWDefaultLabel
+		}
+	}
+
+	if postType == model.TypeChat {
+		lines := model.ParseChatLog(text)
+		if len(lines) == 0 {
+			jsonResp(w, 400, false, "聊天记录格式错误，每行应为 \"昵称: 内容\"")
+			return nil, nil, false
+		}
+		text = model.FormatChatLog(lines)
+	}
+
+	var poll *model.Poll
+	if postType == model.TypePoll {
+		question, p, err := model.ParsePoll(text, time.Now())
+		if err != nil {
+			jsonResp(w, 400, false, err.Error())
+			return nil, nil, false
+		}
+		text, poll = question, p
+	}
+
+	return &model.Post{
 		UIN:        uin,
 		Name:       name,
 		Text:       text,
-		Images:     images,
 		Anon:       anon,
+		Type:       postType,
+		Fields:     fields,
+		Poll:       poll,
+		CW:         cw,
+		CWImages:   cwImages,
 		Status:     model.StatusPending,
+		IP:         ip,
+		WallID:     target.ID,
 		CreateTime: time.Now().Unix(),
+	}, files, true
+}
+
+// handleAPIPreview 渲染投稿表单的截图预览，不保存稿件和图片，无需登录但按 IP 限流
+func (s *Server) handleAPIPreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonResp(w, 405, false, "仅支持 POST")
+		return
 	}
-	if err := s.store.SavePost(post); err != nil {
-		jsonResp(w, 500, false, "保存失败")
+	if s.cfg.PreviewLimit < 0 {
+		jsonResp(w, 403, false, "预览功能未开启")
 		return
 	}
+	if !s.previews.allow(clientIP(r), time.Now()) {
+		jsonResp(w, 429, false, "预览过于频繁，请稍后再试")
+		return
+	}
+	post, files, ok := s.readSubmitForm(w, r)
+	if !ok {
+		return
+	}
+	defer func() { _ = r.MultipartForm.RemoveAll() }()
+	target := s.walls.Get(post.WallID)
+	if !target.Renderer.Available() {
+		jsonResp(w, 500, false, "渲染器不可用")
+		return
+	}
+	if limit := s.wallCfg.MaxTextLen; limit > 0 && len([]rune(post.Text)) > limit {
+		jsonResp(w, 400, false, fmt.Sprintf("内容超过 %d 字", limit))
+		return
+	}
+	if post.Anon {
+		// 匿名代号与头像不能按填写的 QQ 号派生，否则任何人都能借预览查出他人的代号
+		post.UIN = 0
+	}
 
-	log.Printf("[Web] received post #%d from %s", post.ID, name)
-	jsonRespData(w, 200, true, fmt.Sprintf("投稿成功，编号 #%d，等待审核", post.ID), post.ID)
+	// 上传图片只读入内存，以 preview://N 引用
+	uploads := make(map[string][]byte, len(files))
+	for i, fh := range files {
+		key := fmt.Sprintf("preview://%d", i)
+		post.Images = append(post.Images, key)
+		f, err := fh.Open()
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(f)
+		_ = f.Close()
+		if err == nil {
+			uploads[key] = data
+		}
+	}
+	fallback := render.NewHTTPFetcher()
+	renderer := target.Renderer.WithImageFetcher(render.ImageFetcherFunc(func(url string) ([]byte, error) {
+		if strings.HasPrefix(url, "preview://") {
+			if data, ok := uploads[url]; ok {
+				return data, nil
+			}
+			return nil, fmt.Errorf("预览图片读取失败: %s", url)
+		}
+		return fallback.Fetch(url)
+	}))
+
+	page := 1
+	if v := r.URL.Query().Get("page"); v != "" {
+		var err error
+		if page, err = strconv.Atoi(v); err != nil || page < 1 {
+			jsonResp(w, 400, false, "页码格式错误")
+			return
+		}
+	}
+
+	pages, err := renderer.RenderPost(post)
+	if err != nil {
+		log.Printf("[Web] 预览渲染失败: %v", err)
+		jsonResp(w, 500, false, "渲染失败")
+		return
+	}
+	if page > len(pages) {
+		jsonResp(w, 404, false, "页码超出范围")
+		return
+	}
+	imgData := pages[page-1]
+
+	w.Header().Set("X-Page-Count", strconv.Itoa(len(pages)))
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", http.DetectContentType(imgData))
+	w.Header().Set("Content-Length", strconv.Itoa(len(imgData)))
+	_, _ = w.Write(imgData)
 }
 
 func (s *Server) handleAPIApprove(w http.ResponseWriter, r *http.Request) {
@@ -470,7 +693,7 @@ func (s *Server) handleAPIApprove(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	account := s.currentAccount(r)
-	if account == nil || !account.IsAdmin() {
+	if account == nil || !account.HasRole(model.RoleModerator) {
 		jsonResp(w, 403, false, "无权限")
 		return
 	}
@@ -487,8 +710,17 @@ func (s *Server) handleAPIApprove(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	post.Status = model.StatusApproved
-	if err := s.store.SavePost(post); err != nil {
+	if s.walls.Get(post.WallID) == nil {
+		// 所属的墙已从配置删除，没有对应的发布队列，也不能改发到其他墙
+		jsonResp(w, 400, false, "稿件所属的墙已不存在，无法通过")
+		return
+	}
+	// 只改状态: 整行写回会覆盖读取后投稿人的修改
+	if err := s.store.UpdatePostStatus(post.ID, model.StatusPending, model.StatusApproved, post.TID, ""); err != nil {
+		if errors.Is(err, store.ErrStatusChanged) {
+			jsonResp(w, 409, false, "稿件已不是待审核状态")
+			return
+		}
 		jsonResp(w, 500, false, "更新失败")
 		return
 	}
@@ -501,7 +733,7 @@ func (s *Server) handleAPIReject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	account := s.currentAccount(r)
-	if account == nil || !account.IsAdmin() {
+	if account == nil || !account.HasRole(model.RoleModerator) {
 		jsonResp(w, 403, false, "无权限")
 		return
 	}
@@ -519,22 +751,83 @@ func (s *Server) handleAPIReject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	post.Status = model.StatusRejected
-	post.Reason = reason
-	if err := s.store.SavePost(post); err != nil {
+	if err := s.store.UpdatePostStatus(post.ID, post.Status, model.StatusRejected, post.TID, reason); err != nil {
+		if errors.Is(err, store.ErrStatusChanged) {
+			jsonResp(w, 409, false, "稿件状态已变化，请刷新后重试")
+			return
+		}
 		jsonResp(w, 500, false, "更新失败")
 		return
 	}
 	jsonResp(w, 200, true, fmt.Sprintf("稿件 #%d 已拒绝", id))
 }
 
+// handleAPICW 设置/取消稿件图片的内容警告
+func (s *Server) handleAPICW(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonResp(w, 405, false, "仅支持 POST")
+		return
+	}
+	account := s.currentAccount(r)
+	if account == nil || !account.HasRole(model.RoleModerator) {
+		jsonResp(w, 403, false, "无权限")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.FormValue("id"), 10, 64)
+	if err != nil {
+		jsonResp(w, 400, false, "编号格式错误")
+		return
+	}
+	images, err := model.ParseIndexList(r.FormValue("images"))
+	if err != nil {
+		jsonResp(w, 400, false, err.Error())
+		return
+	}
+	post, err := s.store.GetPost(id)
+	if err != nil || post == nil {
+		jsonResp(w, 404, false, "稿件不存在")
+		return
+	}
+	if post.Status == model.StatusPublished {
+		jsonResp(w, 400, false, "稿件已发布，无法修改")
+		return
+	}
+
+	if r.FormValue("clear") == "true" {
+		post.CW, post.CWImages = "", nil
+	} else {
+		for _, n := range images {
+			if n > len(post.Images) {
+				jsonResp(w, 400, false, fmt.Sprintf("稿件 #%d 只有 %d 张图片", id, len(post.Images)))
+				return
+			}
+		}
+		post.CW = strings.TrimSpace(r.FormValue("label"))
+		if post.CW == "" {
+			post.CW = model.CWDefaultLabel
+		}
+		post.CWImages = images
+	}
+	// 只改内容警告: 整行写回会在读取后被发布时把状态改回未发布，导致重复发布
+	if err := s.store.UpdatePostCW(post.ID, post.CW, post.CWImages); err != nil {
+		if errors.Is(err, store.ErrStatusChanged) {
+			jsonResp(w, 409, false, "稿件已发布或状态已变化")
+			return
+		}
+		jsonResp(w, 500, false, "更新失败")
+		return
+	}
+	jsonResp(w, 200, true, fmt.Sprintf("稿件 #%d 已更新", id))
+}
+
 func (s *Server) handleAPIBatchApprove(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		jsonResp(w, 405, false, "仅支持 POST")
 		return
 	}
 	account := s.currentAccount(r)
-	if account == nil || !account.IsAdmin() {
+	if account == nil || !account.HasRole(model.RoleModerator) {
 		jsonResp(w, 403, false, "无权限")
 		return
 	}
@@ -553,9 +846,19 @@ func (s *Server) handleAPIBatchApprove(w http.ResponseWriter, r *http.Request) {
 
 	var validPosts []*model.Post
 	for _, p := range posts {
-		if p.Status == model.StatusPending {
-			validPosts = append(validPosts, p)
+		if p.Status != model.StatusPending || s.walls.Get(p.WallID) == nil {
+			continue
 		}
+		// 先按状态条件标记为已发布，同时被修改或审核的稿件跳过；
+		// 标记后稿件不能再修改，按重新读取的内容渲染发布
+		claimed, err := s.store.ClaimPost(p.ID, model.StatusPending, model.StatusPublished)
+		if err != nil {
+			if !errors.Is(err, store.ErrStatusChanged) {
+				log.Printf("[Web] 标记稿件 #%d 失败: %v", p.ID, err)
+			}
+			continue
+		}
+		validPosts = append(validPosts, claimed)
 	}
 
 	if len(validPosts) == 0 {
@@ -563,72 +866,125 @@ func (s *Server) handleAPIBatchApprove(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var summaryBuilder strings.Builder
-	fmt.Fprintf(&summaryBuilder, "【表白墙更新】 %s\n", time.Now().Format("01/02"))
-	summaryBuilder.WriteString("----------------\n")
+	// 不同墙的稿件分别合并发布到各自的QQ空间
+	total := 0
+	var notes []string
+	groups := wall.GroupPosts(validPosts)
+	for i, posts := range groups {
+		n, note, err := s.publishBatch(s.walls.Get(posts[0].WallID), posts)
+		if err != nil {
+			// 其余墙的稿件未发布，恢复为待审核
+			for _, rest := range groups[i+1:] {
+				s.releasePosts(rest...)
+			}
+			msg := err.Error()
+			if total > 0 {
+				msg = fmt.Sprintf("已发布 %d 条，%s", total, msg)
+			}
+			jsonResp(w, 500, false, msg)
+			return
+		}
+		total += n
+		if note != "" {
+			notes = append(notes, note)
+		}
+	}
 
-	var imagesData [][]byte
+	msg := fmt.Sprintf("成功发布 %d 条稿件！", total)
+	if len(notes) > 0 {
+		msg += "\n" + strings.Join(notes, "\n")
+	}
+	jsonResp(w, 200, true, msg)
+}
 
-	for _, post := range validPosts {
-		var imgData []byte
-		var renderErr error
-
-		if s.renderer != nil && s.renderer.Available() {
-			// [修复] 使用本地路径解析器，而不是 resolvePostImages
-			// resolvePostImages 会加上 /wall 前缀导致后端无法读取文件
-			renderPost := s.resolvePostImagesForRender(post)
-			imgData, renderErr = s.renderer.RenderPost(renderPost)
-		} else {
-			renderErr = fmt.Errorf("renderer not available")
+// publishBatch 将同一面墙的待审核稿件合并为一条说说发布到该墙的QQ空间 (图片超过上限时拆成多条)，
+// 返回发布的稿件数与需要提示审核员的说明
+func (s *Server) publishBatch(target *wall.Wall, validPosts []*model.Post) (int, string, error) {
+	// 同一批次内附原图时复用渲染时已下载的图片
+	renderer := target.Renderer.Session()
+	var imagesData [][]byte
+	var published []*model.Post
+
+	// 合集模式: 多条稿件合并渲染为长图，减少说说中的图片数量
+	digest := s.wallCfg.BatchDigest && len(validPosts) > 1
+	if digest {
+		if !renderer.Available() {
+			s.releasePosts(validPosts...)
+			return 0, "", fmt.Errorf("渲染器不可用")
 		}
-
-		if renderErr != nil || len(imgData) == 0 {
-			log.Printf("[Web] 渲染失败 #%d: %v", post.ID, renderErr)
-			continue
+		resolved := make([]*model.Post, len(validPosts))
+		for i, p := range validPosts {
+			resolved[i] = s.resolvePostImagesForRender(p)
+		}
+		pages, err := renderer.RenderDigest(resolved)
+		if err != nil {
+			s.releasePosts(validPosts...)
+			return 0, "", fmt.Errorf("合集渲染失败: %w", err)
 		}
-		imagesData = append(imagesData, imgData)
+		imagesData = pages
+	}
 
-		content := []rune(post.Text)
-		if len(content) > 20 {
-			fmt.Fprintf(&summaryBuilder, "#%d: %s...\n", post.ID, string(content[:20]))
-		} else {
-			if post.Text == "" {
-				fmt.Fprintf(&summaryBuilder, "#%d: [图片]\n", post.ID)
+	for _, post := range validPosts {
+		if !digest {
+			var pages [][]byte
+			var renderErr error
+
+			if renderer.Available() {
+				// [修复] 使用本地路径解析器，而不是 resolvePostImages
+				// resolvePostImages 会加上 /wall 前缀导致后端无法读取文件
+				pages, renderErr = renderer.RenderPostCached(post, s.resolvePostImagesForRender)
 			} else {
-				fmt.Fprintf(&summaryBuilder, "#%d: %s\n", post.ID, post.Text)
+				renderErr = fmt.Errorf("renderer not available")
 			}
-		}
 
-		post.Status = model.StatusPublished
-		_ = s.store.SavePost(post)
+			if renderErr != nil || len(pages) == 0 {
+				log.Printf("[Web] 渲染失败 #%d: %v", post.ID, renderErr)
+				s.releasePosts(post)
+				continue
+			}
+			// 长稿件分页后按顺序全部上传
+			imagesData = append(imagesData, pages...)
+		}
+		if wc := s.wallCfg; (wc.CWOriginal && post.CW != "") || (wc.GIFOriginal && len(post.Images) > 0) {
+			imagesData = append(imagesData, renderer.OriginalImages(s.resolvePostImagesForRender(post), wc.CWOriginal, wc.GIFOriginal)...)
+		}
+		published = append(published, post)
 	}
 
 	if len(imagesData) == 0 {
-		jsonResp(w, 500, false, "没有成功渲染的图片，取消发布")
-		return
+		return 0, "", fmt.Errorf("没有成功渲染的图片，取消发布")
 	}
 
-	summaryBuilder.WriteString("----------------\n")
-	summaryBuilder.WriteString("详情见图 👇")
-	finalText := summaryBuilder.String()
-
-	opts := &qzone.PublishOption{
-		ImageBytes: imagesData,
+	finalText, err := s.captions.Batch(published)
+	if err != nil {
+		s.releasePosts(published...)
+		return 0, "", fmt.Errorf("说说文案模板错误: %w", err)
 	}
 
-	_, publishErr := s.qzClient.Publish(context.Background(), finalText, opts)
+	_, parts, publishErr := target.Publish(context.Background(), finalText, imagesData)
 
+	if parts == 0 {
+		log.Printf("[Web] %s 发布说说失败: %v", target.Name, publishErr)
+		s.releasePosts(published...)
+		return 0, "", fmt.Errorf("发布到QQ空间失败: %w", publishErr)
+	}
+	var note string
 	if publishErr != nil {
-		log.Printf("[Web] 发布说说失败: %v", publishErr)
-		for _, p := range validPosts {
-			p.Status = model.StatusPending
-			_ = s.store.SavePost(p)
-		}
-		jsonResp(w, 500, false, "发布到QQ空间失败: "+publishErr.Error())
-		return
+		log.Printf("[Web] %s 续发说说失败 (已发布 %d 条): %v", target.Name, parts, publishErr)
+		note = fmt.Sprintf("图片共 %d 张超出每条说说 %d 张的上限，仅发布了前 %d 条说说", len(imagesData), wall.MaxImages, parts)
+	} else if parts > 1 {
+		note = fmt.Sprintf("图片共 %d 张超出每条说说 %d 张的上限，已拆分为 %d 条说说", len(imagesData), wall.MaxImages, parts)
 	}
+	return len(published), note, nil
+}
 
-	jsonResp(w, 200, true, fmt.Sprintf("成功发布 %d 条稿件！", len(imagesData)))
+// releasePosts 发布失败时将已标记发布的稿件恢复为待审核
+func (s *Server) releasePosts(posts ...*model.Post) {
+	for _, p := range posts {
+		if err := s.store.UpdatePostStatus(p.ID, model.StatusPublished, model.StatusPending, "", p.Reason); err != nil {
+			log.Printf("[Web] 回滚稿件状态失败 #%d: %v", p.ID, err)
+		}
+	}
 }
 
 func (s *Server) handleAPIBatchReject(w http.ResponseWriter, r *http.Request) {
@@ -637,7 +993,7 @@ func (s *Server) handleAPIBatchReject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	account := s.currentAccount(r)
-	if account == nil || !account.IsAdmin() {
+	if account == nil || !account.HasRole(model.RoleModerator) {
 		jsonResp(w, 403, false, "无权限")
 		return
 	}
@@ -662,7 +1018,7 @@ func (s *Server) handleAPIBatchDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	account := s.currentAccount(r)
-	if account == nil || !account.IsAdmin() {
+	if account == nil || !account.HasRole(model.RoleModerator) {
 		jsonResp(w, 403, false, "无权限")
 		return
 	}
@@ -728,13 +1084,15 @@ func (s *Server) applyBatchStatus(ids []int64, status model.PostStatus, reason s
 			skipped++
 			continue
 		}
-		post.Status = status
+		postReason := ""
 		if status == model.StatusRejected {
-			post.Reason = reason
-		} else {
-			post.Reason = ""
+			postReason = reason
 		}
-		if err := s.store.SavePost(post); err != nil {
+		if err := s.store.UpdatePostStatus(post.ID, model.StatusPending, status, post.TID, postReason); err != nil {
+			if errors.Is(err, store.ErrStatusChanged) {
+				skipped++
+				continue
+			}
 			return updated, skipped, err
 		}
 		updated++
@@ -748,11 +1106,19 @@ func (s *Server) applyBatchStatus(ids []int64, status model.PostStatus, reason s
 
 func (s *Server) handleAPIQRCode(w http.ResponseWriter, r *http.Request) {
 	account := s.currentAccount(r)
-	if account == nil || !account.IsAdmin() {
+	if account == nil || !account.HasRole(model.RoleOwner) {
 		jsonResp(w, 403, false, "无权限")
 		return
 	}
 
+	target := s.walls.Default()
+	if arg := r.URL.Query().Get("wall"); arg != "" {
+		if target = s.walls.Find(arg); target == nil {
+			jsonResp(w, 400, false, "墙不存在")
+			return
+		}
+	}
+
 	qr, err := qzone.GetQRCode()
 	if err != nil {
 		jsonResp(w, 500, false, "获取二维码失败: "+err.Error())
@@ -761,6 +1127,7 @@ func (s *Server) handleAPIQRCode(w http.ResponseWriter, r *http.Request) {
 
 	s.qrMu.Lock()
 	s.qrCode = qr
+	s.qrWall = target
 	s.qrStatus = "waiting"
 	s.qrMessage = ""
 	s.qrMu.Unlock()
@@ -773,7 +1140,7 @@ func (s *Server) handleAPIQRCode(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) pollQRLogin() {
 	s.qrMu.Lock()
-	qr := s.qrCode
+	qr, target := s.qrCode, s.qrWall
 	s.qrMu.Unlock()
 	if qr == nil {
 		return
@@ -791,7 +1158,7 @@ func (s *Server) pollQRLogin() {
 		}
 		switch state {
 		case qzone.LoginSuccess:
-			if err := s.qzClient.UpdateCookie(cookie); err != nil {
+			if err := target.UpdateCookie(cookie); err != nil {
 				s.qrMu.Lock()
 				s.qrStatus = "error"
 				s.qrMessage = "Cookie 更新失败: " + err.Error()
@@ -800,7 +1167,7 @@ func (s *Server) pollQRLogin() {
 			}
 			s.qrMu.Lock()
 			s.qrStatus = "success"
-			s.qrMessage = fmt.Sprintf("登录成功, UIN=%d", s.qzClient.UIN())
+			s.qrMessage = fmt.Sprintf("登录成功, UIN=%d", target.Client.UIN())
 			s.qrMu.Unlock()
 			return
 		case qzone.LoginExpired:
@@ -843,17 +1210,34 @@ func (s *Server) handleAPIQzoneStatus(w http.ResponseWriter, r *http.Request) {
 	// [修改] 允许公开访问此接口，以便 user.html 页面刷新状态
 	// 移除了管理员权限校验
 
-	uin := int64(0)
-	if s.qzClient != nil {
-		uin = s.qzClient.UIN()
+	target := s.walls.Default()
+	if arg := r.URL.Query().Get("wall"); arg != "" {
+		if target = s.walls.Find(arg); target == nil {
+			jsonResp(w, 400, false, "墙不存在")
+			return
+		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+	resp := map[string]interface{}{
 		"ok":           true,
-		"cookie_valid": s.isQzoneLoggedIn(),
-		"uin":          uin,
-	})
+		"cookie_valid": target.LoggedIn(),
+		"uin":          wallUIN(target),
+	}
+	if s.walls.Multi() {
+		var walls []map[string]interface{}
+		for _, wl := range s.walls.All() {
+			walls = append(walls, map[string]interface{}{
+				"id":           wl.ID,
+				"name":         wl.Name,
+				"uin":          wallUIN(wl),
+				"cookie_valid": wl.LoggedIn(),
+			})
+		}
+		resp["walls"] = walls
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
 }
 
 func (s *Server) handleAPIQzoneRefresh(w http.ResponseWriter, r *http.Request) {
@@ -862,7 +1246,7 @@ func (s *Server) handleAPIQzoneRefresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	account := s.currentAccount(r)
-	if account == nil || !account.IsAdmin() {
+	if account == nil || !account.HasRole(model.RoleOwner) {
 		jsonResp(w, 403, false, "无权限")
 		return
 	}
@@ -877,12 +1261,14 @@ func (s *Server) handleAPIQzoneRefresh(w http.ResponseWriter, r *http.Request) {
 			return true
 		}
 
-		if err := s.qzClient.UpdateCookie(cookie); err != nil {
+		// Bot 登录的QQ号即默认墙的QQ空间账号
+		def := s.walls.Default()
+		if err := def.UpdateCookie(cookie); err != nil {
 			log.Printf("[Web] 从 Bot(%d) 刷新 Cookie 失败: %v", id, err)
 			return true
 		}
 
-		uin = s.qzClient.UIN()
+		uin = def.Client.UIN()
 		success = true
 		log.Printf("[Web] 成功从 Bot(%d) 拉取 Cookie, UIN=%d", id, uin)
 		return false
@@ -897,7 +1283,7 @@ func (s *Server) handleAPIQzoneRefresh(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleAPIConfig(w http.ResponseWriter, r *http.Request) {
 	account := s.currentAccount(r)
-	if account == nil || !account.IsAdmin() {
+	if account == nil || !account.HasRole(model.RoleOwner) {
 		jsonResp(w, 403, false, "无权限")
 		return
 	}
@@ -924,6 +1310,15 @@ func (s *Server) handleAPIConfig(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if _, err := caption.New(&newCfg); err != nil {
+			jsonResp(w, 400, false, "说说文案模板错误: "+err.Error())
+			return
+		}
+		if err := wall.Validate(&newCfg); err != nil {
+			jsonResp(w, 400, false, "附加墙配置错误: "+err.Error())
+			return
+		}
+
 		// 保存到文件
 		if err := newCfg.Save(s.cfgPath); err != nil {
 			jsonResp(w, 500, false, "保存配置失败: "+err.Error())
@@ -934,6 +1329,9 @@ func (s *Server) handleAPIConfig(w http.ResponseWriter, r *http.Request) {
 		*s.fullCfg = newCfg
 		s.cfg = newCfg.Web
 		s.wallCfg = newCfg.Wall
+		if s.captions != nil {
+			_ = s.captions.Reload()
+		}
 
 		jsonResp(w, 200, true, "配置已保存并生效。Bot/WS/Worker 等配置修改需重启后生效")
 
@@ -942,13 +1340,64 @@ func (s *Server) handleAPIConfig(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleAPICaptionPreview 用最近的稿件试运行未保存的说说文案模板
+func (s *Server) handleAPICaptionPreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonResp(w, 405, false, "仅支持 POST")
+		return
+	}
+	account := s.currentAccount(r)
+	if account == nil || !account.HasRole(model.RoleOwner) {
+		jsonResp(w, 403, false, "无权限")
+		return
+	}
+
+	var cc config.CaptionConfig
+	if err := json.NewDecoder(r.Body).Decode(&cc); err != nil {
+		jsonResp(w, 400, false, "JSON 格式错误: "+err.Error())
+		return
+	}
+	tmp := *s.fullCfg
+	tmp.Caption = cc
+	c, err := caption.New(&tmp)
+	if err != nil {
+		jsonResp(w, 400, false, err.Error())
+		return
+	}
+
+	posts, _ := s.store.ListAll(3, 0)
+	if len(posts) == 0 {
+		now := time.Now().Unix()
+		posts = []*model.Post{
+			{ID: 1, Name: "示例用户", Text: "这是一条示例投稿，用于预览说说文案的效果。", CreateTime: now},
+			{ID: 2, Anon: true, Images: []string{"example.jpg"}, CreateTime: now},
+		}
+	}
+	single, err := c.ExecPost(posts[0])
+	if err != nil {
+		jsonResp(w, 400, false, err.Error())
+		return
+	}
+	batch, err := c.Batch(posts)
+	if err != nil {
+		jsonResp(w, 400, false, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"ok":    true,
+		"post":  single,
+		"batch": batch,
+	})
+}
+
 func (s *Server) handleAPIChangePassword(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		jsonResp(w, 405, false, "仅支持 POST")
 		return
 	}
 	account := s.currentAccount(r)
-	if account == nil || !account.IsAdmin() {
+	if account == nil || !account.HasRole(model.RoleViewer) {
 		jsonResp(w, 403, false, "无权限")
 		return
 	}
@@ -986,7 +1435,7 @@ func (s *Server) handleAPIRestart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	account := s.currentAccount(r)
-	if account == nil || !account.IsAdmin() {
+	if account == nil || !account.HasRole(model.RoleOwner) {
 		jsonResp(w, 403, false, "无权限")
 		return
 	}
@@ -1085,7 +1534,7 @@ func (s *Server) RegisterUser(username, password string) error {
 	}
 	salt := randomHex(16)
 	hash := hashPassword(password, salt)
-	return s.store.CreateAccount(username, hash, salt, "user")
+	return s.store.CreateAccount(username, hash, salt, model.RoleUser)
 }
 
 func (s *Server) SetCookieFile(cookieFile string) {
@@ -1128,12 +1577,12 @@ func openBrowser(url string) {
 	_ = exec.Command(cmd, args...).Start()
 }
 
-func (s *Server) isQzoneLoggedIn() bool {
-	if s.qzClient == nil || s.qzClient.UIN() <= 0 {
-		return false
+// wallUIN 墙当前登录的QQ号，客户端尚未创建时为 0
+func wallUIN(w *wall.Wall) int64 {
+	if w.Client == nil {
+		return 0
 	}
-	raw := s.qzClient.Session().Cookie()
-	return !strings.Contains(raw, "p_skey=bootstrap")
+	return w.Client.UIN()
 }
 
 // ── Image Resolution Helpers ──
@@ -1192,7 +1641,7 @@ func (s *Server) handleAPIPostImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	account := s.currentAccount(r)
-	if account == nil || !account.IsAdmin() {
+	if account == nil || !account.HasRole(model.RoleViewer) {
 		jsonResp(w, 403, false, "无权限")
 		return
 	}
@@ -1209,21 +1658,74 @@ func (s *Server) handleAPIPostImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if s.renderer == nil || !s.renderer.Available() {
+	target := s.walls.Get(post.WallID)
+	if target == nil {
+		jsonResp(w, 404, false, "稿件所属的墙已不存在")
+		return
+	}
+	renderer := target.Renderer
+	if !renderer.Available() {
 		jsonResp(w, 500, false, "渲染器不可用")
 		return
 	}
 
-	renderPost := s.resolvePostImagesForRender(post)
-	imgData, renderErr := s.renderer.RenderPost(renderPost)
+	// 页码从 1 开始，长稿件分页后逐页获取
+	page := 1
+	if v := r.URL.Query().Get("page"); v != "" {
+		page, err = strconv.Atoi(v)
+		if err != nil || page < 1 {
+			jsonResp(w, 400, false, "页码格式错误")
+			return
+		}
+	}
+
+	// ETag 由缓存键生成，稿件内容或主题变化后自动失效。
+	// 只对渲染成功且可缓存的结果下发，客户端才可能带着它来重新验证。
+	etag := fmt.Sprintf(`"%s-p%d"`, renderer.CacheKey(post), page)
+	if etagMatch(r.Header.Get("If-None-Match"), etag) {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "private, no-cache")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	pages, cacheable, renderErr := renderer.RenderPostCacheable(post, s.resolvePostImagesForRender)
 	if renderErr != nil {
 		log.Printf("[Web] 获取图片渲染失败 #%d: %v", post.ID, renderErr)
 		jsonResp(w, 500, false, "渲染失败")
 		return
 	}
+	if page > len(pages) {
+		jsonResp(w, 404, false, "页码超出范围")
+		return
+	}
+	imgData := pages[page-1]
 
-	w.Header().Set("Cache-Control", "public, max-age=86400")
-	w.Header().Set("Content-Type", "image/jpeg")
+	if cacheable {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "private, no-cache")
+	} else {
+		// 含图片读取失败的占位图，不让浏览器缓存，下次重新渲染
+		w.Header().Set("Cache-Control", "no-store")
+	}
+	w.Header().Set("X-Page-Count", strconv.Itoa(len(pages)))
+
+	w.Header().Set("Content-Type", http.DetectContentType(imgData))
 	w.Header().Set("Content-Length", strconv.Itoa(len(imgData)))
 	_, _ = w.Write(imgData)
 }
+
+// etagMatch 判断 If-None-Match 是否命中当前 ETag
+func etagMatch(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimSpace(v)
+		v = strings.TrimPrefix(v, "W/")
+		if v == "*" || v == etag {
+			return true
+		}
+	}
+	return false
+}